@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"enigma/internal/usecase"
+	"enigma/internal/usecase/repository/account"
+	"enigma/internal/usecase/repository/backup"
+	"enigma/internal/usecase/repository/categorymapping"
+	"enigma/internal/usecase/repository/duplicate"
+	"enigma/internal/usecase/repository/goal"
+	"enigma/internal/usecase/repository/idempotence"
+	"enigma/internal/usecase/repository/importprofile"
+	"enigma/internal/usecase/repository/networth"
+	"enigma/internal/usecase/repository/notificationtemplate"
+	"enigma/internal/usecase/repository/outbox"
+	"enigma/internal/usecase/repository/transaction"
+	"enigma/internal/usecase/repository/userstate"
+	"enigma/internal/usecase/repository/webhook"
+	webhooksender "enigma/internal/webhook"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// app holds the database and every usecase over it, it is shared by all commands
+type app struct {
+	db *bolt.DB
+
+	idempotenceUsecase *usecase.Idempotence
+
+	getUserstateUsecase  *usecase.GetUserstate
+	saveUserstateUsecase *usecase.SaveUserstate
+
+	createTransactionUsecase     *usecase.CreateTransaction
+	getTransactionsByDateUsecase *usecase.GetTransactionsByDate
+	getTransactionByID           *usecase.GetTransactionByID
+	searchTransactionsUsecase    *usecase.SearchTransactions
+	getTagsUsecase               *usecase.GetTags
+	exportTransactionsUsecase    *usecase.ExportTransactions
+	getReceiptTransactionUsecase *usecase.GetReceiptTransaction
+	findDuplicatesUsecase        *usecase.FindDuplicates
+
+	createGoalUsecase       *usecase.CreateGoal
+	getGoalsProgressUsecase *usecase.GetGoalsProgress
+	checkGoalsUsecase       *usecase.CheckGoals
+
+	getAccountsUsecase     *usecase.GetAccounts
+	setAccountTypeUsecase  *usecase.SetAccountType
+	setAccountFloorUsecase *usecase.SetAccountFloor
+	declareAccountsUsecase *usecase.DeclareAccounts
+	forecastUsecase        *usecase.Forecast
+	getBalancesUsecase     *usecase.GetBalances
+
+	getNetWorthHistoryUsecase *usecase.GetNetWorthHistory
+	deleteTransactionUsecase  *usecase.DeleteTransaction
+
+	importTransactionsUsecase *usecase.ImportTransactions
+	saveImportProfileUsecase  *usecase.SaveImportProfile
+	getImportProfileUsecase   *usecase.GetImportProfile
+	getImportProfilesUsecase  *usecase.GetImportProfiles
+
+	saveCategoryMappingsUsecase *usecase.SaveCategoryMappings
+	getCategoryMappingsUsecase  *usecase.GetCategoryMappings
+
+	createBackupUsecase  *usecase.CreateBackup
+	inspectBackupUsecase *usecase.InspectBackup
+	restoreBackupUsecase *usecase.RestoreBackup
+
+	getDuplicatesUsecase    *usecase.GetDuplicates
+	dismissDuplicateUsecase *usecase.DismissDuplicate
+
+	updateTransactionUsecase *usecase.UpdateTransaction
+	splitTransactionUsecase  *usecase.SplitTransaction
+
+	createWebhookUsecase   *usecase.CreateWebhook
+	deleteWebhookUsecase   *usecase.DeleteWebhook
+	getWebhooksUsecase     *usecase.GetWebhooks
+	deliverWebhooksUsecase *usecase.DeliverWebhooks
+
+	saveNotificationTemplateUsecase   *usecase.SaveNotificationTemplate
+	deleteNotificationTemplateUsecase *usecase.DeleteNotificationTemplate
+	getNotificationTemplatesUsecase   *usecase.GetNotificationTemplates
+}
+
+// openApp opens the database at path, it fails fast while a running bot holds the lock
+func openApp(path string) (*app, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("database %s is used by another enigma process, stop it or use its HTTP API", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := newApp(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func newApp(db *bolt.DB) (*app, error) {
+	a := &app{db: db}
+
+	idempotenceRepository, err := idempotence.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.idempotenceUsecase = usecase.NewIdempotence(idempotenceRepository)
+
+	userstateRepository, err := userstate.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.getUserstateUsecase = usecase.NewGetUserstate(userstateRepository)
+	a.saveUserstateUsecase = usecase.NewSaveUserstate(userstateRepository)
+
+	webhookRepository, err := webhook.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	outboxRepository, err := outbox.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	publishEventsUsecase := usecase.NewPublishEvents(webhookRepository)
+	a.createWebhookUsecase = usecase.NewCreateWebhook(webhookRepository)
+	a.deleteWebhookUsecase = usecase.NewDeleteWebhook(webhookRepository)
+	a.getWebhooksUsecase = usecase.NewGetWebhooks(webhookRepository, outboxRepository)
+	a.deliverWebhooksUsecase = usecase.NewDeliverWebhooks(webhookRepository, outboxRepository, webhooksender.NewSender())
+
+	transactionRepository, err := transaction.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.createTransactionUsecase = usecase.NewCreateTransaction(transactionRepository, publishEventsUsecase)
+	a.getTransactionsByDateUsecase = usecase.NewGetTransactionsByDate(transactionRepository)
+	a.getTransactionByID = usecase.NewGetTransactionByID(transactionRepository)
+	a.searchTransactionsUsecase = usecase.NewSearchTransactions(transactionRepository)
+	a.getTagsUsecase = usecase.NewGetTags(transactionRepository)
+	a.exportTransactionsUsecase = usecase.NewExportTransactions(transactionRepository)
+	a.getReceiptTransactionUsecase = usecase.NewGetReceiptTransaction(transactionRepository)
+	a.findDuplicatesUsecase = usecase.NewFindDuplicates(transactionRepository)
+
+	goalRepository, err := goal.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.createGoalUsecase = usecase.NewCreateGoal(goalRepository)
+	a.getGoalsProgressUsecase = usecase.NewGetGoalsProgress(goalRepository, transactionRepository)
+	a.checkGoalsUsecase = usecase.NewCheckGoals(goalRepository, transactionRepository)
+
+	accountRepository, err := account.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.getAccountsUsecase = usecase.NewGetAccounts(accountRepository, transactionRepository)
+	a.setAccountTypeUsecase = usecase.NewSetAccountType(accountRepository)
+	a.setAccountFloorUsecase = usecase.NewSetAccountFloor(accountRepository)
+	a.declareAccountsUsecase = usecase.NewDeclareAccounts(accountRepository)
+	a.forecastUsecase = usecase.NewForecast(transactionRepository, accountRepository)
+	a.getBalancesUsecase = usecase.NewGetBalances(accountRepository, transactionRepository)
+
+	netWorthRepository, err := networth.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.getNetWorthHistoryUsecase = usecase.NewGetNetWorthHistory(transactionRepository, accountRepository, netWorthRepository)
+	a.deleteTransactionUsecase = usecase.NewDeleteTransaction(transactionRepository, netWorthRepository, publishEventsUsecase)
+	a.updateTransactionUsecase = usecase.NewUpdateTransaction(transactionRepository, netWorthRepository, publishEventsUsecase)
+	a.splitTransactionUsecase = usecase.NewSplitTransaction(transactionRepository, netWorthRepository, publishEventsUsecase)
+
+	importProfileRepository, err := importprofile.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.importTransactionsUsecase = usecase.NewImportTransactions(transactionRepository, publishEventsUsecase)
+	a.saveImportProfileUsecase = usecase.NewSaveImportProfile(importProfileRepository)
+	a.getImportProfileUsecase = usecase.NewGetImportProfile(importProfileRepository)
+	a.getImportProfilesUsecase = usecase.NewGetImportProfiles(importProfileRepository)
+
+	categoryMappingRepository, err := categorymapping.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.saveCategoryMappingsUsecase = usecase.NewSaveCategoryMappings(categoryMappingRepository)
+	a.getCategoryMappingsUsecase = usecase.NewGetCategoryMappings(categoryMappingRepository)
+
+	// restored backups may come from before some of the repositories existed
+	backupRepository, err := backup.NewBoltDB(
+		db,
+		idempotence.Migrate, userstate.Migrate, webhook.Migrate, outbox.Migrate, transaction.Migrate, goal.Migrate,
+		account.Migrate, networth.Migrate, importprofile.Migrate, categorymapping.Migrate, duplicate.Migrate,
+		notificationtemplate.Migrate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.createBackupUsecase = usecase.NewCreateBackup(backupRepository)
+	a.inspectBackupUsecase = usecase.NewInspectBackup(backupRepository)
+	a.restoreBackupUsecase = usecase.NewRestoreBackup(backupRepository)
+
+	duplicateRepository, err := duplicate.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.getDuplicatesUsecase = usecase.NewGetDuplicates(transactionRepository, duplicateRepository)
+	a.dismissDuplicateUsecase = usecase.NewDismissDuplicate(duplicateRepository)
+
+	notificationTemplateRepository, err := notificationtemplate.NewBoltDB(db)
+	if err != nil {
+		return nil, err
+	}
+	a.saveNotificationTemplateUsecase = usecase.NewSaveNotificationTemplate(notificationTemplateRepository)
+	a.deleteNotificationTemplateUsecase = usecase.NewDeleteNotificationTemplate(notificationTemplateRepository)
+	a.getNotificationTemplatesUsecase = usecase.NewGetNotificationTemplates(notificationTemplateRepository)
+
+	return a, nil
+}
+
+func (a *app) close() error {
+	return a.db.Close()
+}
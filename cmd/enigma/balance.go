@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+func balanceCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("balance", "[account...]")
+	date := fs.String("date", "", "balances at the end of the day, e.g. 2022-05-31, today if empty")
+	asJSON := fs.Bool("json", false, "print JSON Lines instead of a table")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	day := time.Now().UTC()
+	if *date != "" {
+		day, err = time.Parse("2006-01-02", *date)
+		if err != nil {
+			return usageError("invalid date %s, expected 2006-01-02", *date)
+		}
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	balances, err := a.getBalancesUsecase.Execute(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		found := make(map[string]bool, fs.NArg())
+		for _, name := range fs.Args() {
+			found[name] = false
+		}
+
+		filtered := balances[:0]
+		for _, b := range balances {
+			if _, ok := found[b.Account.Name]; ok {
+				filtered = append(filtered, b)
+				found[b.Account.Name] = true
+			}
+		}
+		balances = filtered
+
+		for _, name := range fs.Args() {
+			if !found[name] {
+				return fmt.Errorf("%w: %s", entity.AccountNotFoundErr, name)
+			}
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, b := range balances {
+			err = encoder.Encode(struct {
+				Account  string             `json:"account"`
+				Type     entity.AccountType `json:"type"`
+				Currency string             `json:"currency"`
+				Balance  float64            `json:"balance"`
+			}{b.Account.Name, b.Account.Type, b.Currency, b.Balance})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ACCOUNT\tTYPE\tCURRENCY\tBALANCE")
+	for _, b := range balances {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", b.Account.Name, b.Account.Type, b.Currency, strconv.FormatFloat(b.Balance, 'f', 2, 64))
+	}
+	return table.Flush()
+}
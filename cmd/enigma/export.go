@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"enigma/internal/entity"
+	"enigma/internal/exporter"
+)
+
+func exportCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("export", "")
+	format := fs.String("format", exporter.FormatCSV, "csv, jsonl, ledger, hledger or beancount")
+	period := fs.String("period", "all", "period to export, e.g. 2022, 2022-05 or 2022-01..2022-03")
+	output := fs.String("o", "", "file to write, standard output if empty")
+	delimiter := fs.String("delimiter", "", "CSV delimiter, e.g. ; or tab, comma if empty")
+	decimal := fs.String("decimal", "", "CSV decimal separator, a dot if empty")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return usageError("unexpected arguments %s, flags go before arguments", strings.Join(fs.Args(), " "))
+	}
+
+	since, until, err := entity.ParsePeriod(*period)
+	if err != nil {
+		return usageError("%s", err)
+	}
+
+	csvOptions := exporter.CSVOptions{DecimalSeparator: *decimal}
+	if *delimiter != "" {
+		csvOptions.Delimiter, err = exporter.ParseDelimiter(*delimiter)
+		if err != nil {
+			return usageError("%s", err)
+		}
+	}
+
+	switch *format {
+	case exporter.FormatCSV, exporter.FormatJSONL, exporter.FormatLedger, exporter.FormatHledger, exporter.FormatBeancount:
+	default:
+		return usageError("unsupported format %s", *format)
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	var writer exporter.Writer
+	switch *format {
+	case exporter.FormatCSV:
+		writer, err = exporter.NewCSV(out, csvOptions)
+	case exporter.FormatJSONL:
+		writer = exporter.NewJSONL(out)
+	default:
+		var accounts []entity.Account
+		accounts, err = a.getAccountsUsecase.Execute(ctx)
+		if err == nil {
+			writer, err = exporter.NewJournal(out, *format, accounts)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	count, err := a.exportTransactionsUsecase.Execute(ctx, since, until, writer.Write)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d transactions to %s\n", count, *output)
+		return out.Close()
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"enigma/internal/entity"
+	"enigma/internal/importer"
+	"enigma/internal/usecase"
+)
+
+func importCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("import", "<file>")
+	account := fs.String("account", "", "account of the imported statement, taken from the statement if empty")
+	profile := fs.String("profile", "", "column-mapping profile for CSV import")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageError("a single file is required")
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return importFile(
+		ctx,
+		fs.Arg(0), *account, *profile,
+		a.importTransactionsUsecase, a.getImportProfileUsecase, a.getCategoryMappingsUsecase, a.declareAccountsUsecase,
+	)
+}
+
+func importFile(
+	ctx context.Context,
+	path, account, profileName string,
+	importTransactions *usecase.ImportTransactions,
+	getImportProfile *usecase.GetImportProfile,
+	getCategoryMappings *usecase.GetCategoryMappings,
+	declareAccounts *usecase.DeclareAccounts,
+) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	options := importer.Options{
+		Account:        account,
+		DefaultAccount: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+	}
+
+	var imported []entity.ImportedTransaction
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".txt":
+		if profileName == "" {
+			return usageError("-profile is required to import CSV")
+		}
+
+		profile, err := getImportProfile.Execute(ctx, profileName)
+		if err != nil {
+			return err
+		}
+		if account != "" {
+			profile.Account = account
+		}
+
+		imported, err = importer.ParseCSV(file, profile)
+		if err != nil {
+			return err
+		}
+	case ".qif":
+		transactions, err := importer.ParseQIF(file, options)
+		if err != nil {
+			return err
+		}
+
+		// categories without mapping saved in the bot become accounts as they are
+		accounts, err := getCategoryMappings.Execute(ctx)
+		if err != nil {
+			return err
+		}
+
+		imported = importer.MapQIF(transactions, accounts, options)
+	case ".beancount", ".bean", ".ledger", ".journal", ".hledger", ".dat":
+		journal, err := importer.ParseJournal(file, importer.JournalFormat(path))
+		if err != nil {
+			return err
+		}
+
+		for _, warning := range journal.Warnings {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, warning)
+		}
+
+		_, err = declareAccounts.Execute(ctx, journal.Accounts)
+		if err != nil {
+			return err
+		}
+
+		imported = journal.Transactions
+	default:
+		imported, err = importer.Parse(path, file, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := importTransactions.Execute(ctx, imported)
+	if err != nil {
+		return fmt.Errorf("imported %d transactions before error: %w", result.Created, err)
+	}
+
+	fmt.Printf("Imported %d transactions, %d were imported before\n", result.Created, result.Skipped)
+	for _, pair := range result.Duplicates {
+		fmt.Printf("#%d is likely a duplicate of #%d saved before\n", pair.Second.ID, pair.First.ID)
+	}
+	return nil
+}
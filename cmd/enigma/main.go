@@ -2,71 +2,143 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
-	"log"
-
-	"enigma/internal/entrypoint/telegram"
-	"enigma/internal/usecase"
-	"enigma/internal/usecase/repository/idempotence"
-	"enigma/internal/usecase/repository/transaction"
-	"enigma/internal/usecase/repository/userstate"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
 
-	bolt "go.etcd.io/bbolt"
+// exit codes, so scripts can tell a failure from a mistake in the command line
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitDuplicate = 3
 )
 
-var token = flag.String("token", "", "telegram bot token")
-var adminID = flag.Int64("admin", 0, "admin's telegram id")
+// exitCodeError makes the process exit with code, other errors exit with exitError
+type exitCodeError struct {
+	code int
+	err  error
+
+	// reported errors were already printed, e.g. by the flag package along with the usage
+	reported bool
+}
+
+func (e exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e exitCodeError) Unwrap() error {
+	return e.err
+}
+
+func usageError(format string, args ...interface{}) error {
+	return exitCodeError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"serve", "run the Telegram bot and the HTTP API", serveCommand},
+	{"add", "add a transaction", addCommand},
+	{"list", "list transactions", listCommand},
+	{"balance", "print balances of accounts", balanceCommand},
+	{"import", "import a statement or a journal", importCommand},
+	{"export", "export transactions", exportCommand},
+	{"notification", "parse a bank notification or check templates against samples", notificationCommand},
+	{"webhook", "add, list or remove webhooks notified of changes", webhookCommand},
+}
 
 func main() {
-	flag.Parse()
+	os.Exit(run(os.Args[1:]))
+}
 
-	if *token == "" {
-		log.Fatalln("[ERROR] -token argument is required")
-		return
+func run(args []string) int {
+	// without a command the flags are those of serve, as before commands existed
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	if *adminID == 0 {
-		log.Fatalln("[ERROR] -admin argument is required")
-		return
+	if name == "help" {
+		printUsage()
+		return exitOK
 	}
 
-	db, err := bolt.Open("test.db", 0600, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
 
-	idempotenceRepository, err := idempotence.NewBoltDB(db)
-	if err != nil {
-		log.Fatal(err)
-	}
-	idempotenceUsecase := usecase.NewIdempotence(idempotenceRepository)
+		// the first interrupt cancels ctx so the command stops gracefully, the second one kills the process as usual
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+
+		err := c.run(ctx, args)
+		stop()
+		if err == nil || errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
 
-	userstateRepository, err := userstate.NewBoltDB(db)
-	if err != nil {
-		log.Fatal(err)
+		var exitErr exitCodeError
+		if !errors.As(err, &exitErr) || !exitErr.reported {
+			fmt.Fprintln(os.Stderr, "[ERROR]", err)
+		}
+		if errors.As(err, &exitErr) {
+			return exitErr.code
+		}
+		return exitError
 	}
-	getUserstateUsecase := usecase.NewGetUserstate(userstateRepository)
-	saveUserstateUsecase := usecase.NewSaveUserstate(userstateRepository)
 
-	transactionRepository, err := transaction.NewBoltDB(db)
-	if err != nil {
-		log.Fatal(err)
+	fmt.Fprintf(os.Stderr, "[ERROR] unknown command %s\n\n", name)
+	printUsage()
+	return exitUsage
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: enigma <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.summary)
 	}
-	createTransactionUsecase := usecase.NewCreateTransaction(transactionRepository)
-	getTransactionsByDateUsecase := usecase.NewGetTransactionsByDate(transactionRepository)
-	getTransactionByID := usecase.NewGetTransactionByID(transactionRepository)
-
-	bot, err := telegram.New(
-		*token, *adminID, idempotenceUsecase,
-		getUserstateUsecase, saveUserstateUsecase,
-		createTransactionUsecase, getTransactionsByDateUsecase, getTransactionByID,
-	)
-	if err != nil {
-		log.Fatal(err)
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run enigma <command> -h for flags of the command. Flags go before arguments.")
+	fmt.Fprintf(os.Stderr, "Exit codes: %d success, %d error, %d invalid command line, %d duplicate transaction.\n",
+		exitOK, exitError, exitUsage, exitDuplicate)
+}
+
+// newFlagSet returns flags of the command with the database flag every command has
+func newFlagSet(name, arguments string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: enigma %s [flags] %s\n\nFlags:\n", name, arguments)
+		fs.PrintDefaults()
 	}
 
-	bot.Start(context.Background())
+	dbPath := os.Getenv("ENIGMA_DB")
+	if dbPath == "" {
+		dbPath = "test.db"
+	}
+	return fs, fs.String("db", dbPath, "database file, ENIGMA_DB if set")
+}
 
-	select {}
+// parseFlags parses args of the command, mistakes in them are usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return exitCodeError{code: exitUsage, err: err, reported: true}
+	}
+	return err
 }
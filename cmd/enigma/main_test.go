@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCommand runs enigma with args and returns its exit code and what it printed to stdout
+func runCommand(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout, os.Stderr = w, devNull
+
+	output := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		output <- string(b)
+	}()
+
+	code := run(args)
+	w.Close()
+	return code, <-output
+}
+
+func TestCommands(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "enigma.db")
+
+	tests := []struct {
+		args []string
+		code int
+		out  []string
+	}{
+		{[]string{"add", "-db", db, "-date", "2022-05-01", "card", "cafe", "300", "coffee", "and", "cake"}, exitOK, []string{"Transaction #1 created"}},
+		{[]string{"add", "-db", db, "-date", "2022-05-03", "-currency", "usd", "card", "books", "12,5"}, exitOK, []string{"Transaction #2 created"}},
+		// the same coffee again
+		{[]string{"add", "-db", db, "-date", "2022-05-01", "card", "cafe", "300", "coffee", "and", "cake"}, exitDuplicate, nil},
+		{[]string{"add", "-db", db, "-date", "2022-05-01", "-allow-duplicate", "card", "cafe", "300", "coffee", "and", "cake"}, exitOK, []string{"Transaction #3 created"}},
+
+		{[]string{"add", "-db", db, "card", "cafe"}, exitUsage, nil},
+		{[]string{"add", "-db", db, "card", "cafe", "-5"}, exitUsage, nil},
+		{[]string{"add", "-db", db, "card", "cafe", "lots"}, exitUsage, nil},
+		{[]string{"add", "-db", db, "-date", "yesterday", "card", "cafe", "5"}, exitUsage, nil},
+		{[]string{"add", "-db", db, "-no-such-flag", "card", "cafe", "5"}, exitUsage, nil},
+
+		{[]string{"list", "-db", db}, exitOK, []string{"coffee and cake", "12.50   USD"}},
+		{[]string{"list", "-db", db, "-from", "2022-05-02"}, exitOK, []string{"books"}},
+		{[]string{"list", "-db", db, "-q", "from:card >100", "-json"}, exitOK, []string{`"description":"coffee and cake"`}},
+		{[]string{"list", "-db", db, "-from", "2022-06", "-to", "2022-05"}, exitUsage, nil},
+		{[]string{"list", "-db", db, "-limit", "-1"}, exitUsage, nil},
+		{[]string{"list", "-db", db, "coffee"}, exitUsage, nil},
+
+		{[]string{"balance", "-db", db, "-date", "2022-05-31", "cafe"}, exitOK, []string{"cafe", "600.00"}},
+		{[]string{"balance", "-db", db, "-json", "books"}, exitOK, []string{`"account":"books"`, `"currency":"USD"`, `"balance":12.5`}},
+		{[]string{"balance", "-db", db, "nowhere"}, exitError, nil},
+		{[]string{"balance", "-db", db, "-date", "May"}, exitUsage, nil},
+
+		{[]string{"help"}, exitOK, nil},
+		{[]string{"add", "-h"}, exitOK, nil},
+		{[]string{"transfer", "card", "cafe", "5"}, exitUsage, nil},
+	}
+	for _, tt := range tests {
+		code, out := runCommand(t, tt.args...)
+		if code != tt.code {
+			t.Errorf("%s: exit code %d, want %d", strings.Join(tt.args, " "), code, tt.code)
+		}
+		for _, want := range tt.out {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: output %q has no %q", strings.Join(tt.args, " "), out, want)
+			}
+		}
+	}
+}
+
+func TestListWithoutTransactions(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "enigma.db")
+
+	code, out := runCommand(t, "list", "-db", db, "-from", "2022-05")
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d", code, exitOK)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 {
+		t.Errorf("printed %q, want only the header", out)
+	}
+}
+
+func TestServeFailsWhenAddressIsInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	db := filepath.Join(t.TempDir(), "enigma.db")
+	code, _ := runCommand(t, "serve", "-db", db, "-http", l.Addr().String(), "-api-token", "secret")
+	if code != exitError {
+		t.Errorf("exit code %d, want %d", code, exitError)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"enigma/internal/entity"
+	"enigma/internal/importer"
+)
+
+// notificationSample is a line of a corpus, a nil notification means the text must match no template
+type notificationSample struct {
+	Text         string                   `json:"text"`
+	Notification *entity.BankNotification `json:"notification"`
+}
+
+func notificationCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("notification", "[text]")
+	corpus := fs.String("corpus", "", "check templates against samples of a JSON Lines file, e.g. internal/importer/testdata/notifications.jsonl")
+	builtin := fs.Bool("builtin", false, "use built-in templates only, the database is not opened")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var templates []entity.NotificationTemplate
+	if !*builtin {
+		a, err := openApp(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer a.close()
+
+		templates, err = a.getNotificationTemplatesUsecase.Execute(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *corpus != "" {
+		return checkNotificationCorpus(*corpus, templates)
+	}
+
+	// the text is read from stdin if it is not given, notifications often span lines
+	text := strings.Join(fs.Args(), " ")
+	if text == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+
+	notification, _, err := importer.ParseNotification(text, templates)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(notification)
+}
+
+// checkNotificationCorpus prints samples parsed differently than expected and fails if there are any
+func checkNotificationCorpus(path string, templates []entity.NotificationTemplate) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	failed, total := 0, 0
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var sample notificationSample
+		err = json.Unmarshal(scanner.Bytes(), &sample)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		total++
+
+		var got *entity.BankNotification
+		notification, _, err := importer.ParseNotification(sample.Text, templates)
+		switch {
+		case err == nil:
+			got = &notification
+		case !errors.Is(err, entity.UnknownNotificationErr):
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+
+		// samples omit the default currency, as notifications without a currency are in it
+		if sample.Notification != nil && sample.Notification.Currency == "" {
+			sample.Notification.Currency = entity.DefaultCurrency
+		}
+
+		expected, _ := json.Marshal(sample.Notification)
+		actual, _ := json.Marshal(got)
+		if string(expected) != string(actual) {
+			failed++
+			fmt.Printf("%s:%d: %q\n  expected %s\n  got      %s\n", path, line, sample.Text, expected, actual)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d of %d samples passed\n", total-failed, total)
+	if failed > 0 {
+		return exitCodeError{code: exitError, err: fmt.Errorf("%d samples failed", failed), reported: true}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"enigma/internal/entrypoint/http"
+	"enigma/internal/entrypoint/monitoring"
+	"enigma/internal/entrypoint/telegram"
+	"enigma/internal/entrypoint/web"
+	"enigma/internal/logger"
+	"enigma/internal/metrics"
+	"enigma/internal/usecase/repository"
+	"enigma/internal/webhook"
+)
+
+func serveCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("serve", "")
+	token := fs.String("token", "", "telegram bot token")
+	adminID := fs.Int64("admin", 0, "admin's telegram id")
+	httpAddr := fs.String("http", "", "address to serve the HTTP API on, e.g. :8080, the API is off if empty")
+	apiToken := fs.String("api-token", "", "bearer token of the HTTP API, ENIGMA_API_TOKEN is used if empty")
+	webAddr := fs.String("web", "", "address to serve the dashboard on, e.g. :8081, it requires the bot for signing in")
+	logLevel := fs.String("log-level", "info", "least level of logged entries: debug, info, warn or error")
+	logJSON := fs.Bool("log-json", false, "log JSON lines instead of logfmt text")
+	reportErrors := fs.Bool("report-errors", false, "send unexpected errors of the bot with their trace IDs to the admin's chat")
+	metricsAddr := fs.String("metrics", "", "address to serve /metrics, /healthz and /readyz on, e.g. 127.0.0.1:9090, they are off if empty")
+	webURL := fs.String("web-url", "", "public HTTPS address of the dashboard, e.g. https://enigma.example.com, enables editing in the Mini App")
+
+	// importing with serve flags is kept for scripts written before the import command
+	importPath := fs.String("import", "", "import a statement file and exit, use the import command instead")
+	importAccount := fs.String("account", "", "account of the imported statement, taken from the statement if empty")
+	importProfile := fs.String("profile", "", "column-mapping profile for CSV import")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	level, err := logger.ParseLevel(*logLevel)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	logger.SetDefault(logger.New(os.Stderr, level, *logJSON))
+
+	if *importPath == "" && *token == "" && *httpAddr == "" {
+		return usageError("-token argument is required")
+	}
+
+	if *webAddr != "" && *token == "" {
+		return usageError("-token argument is required for the dashboard")
+	}
+
+	if *webURL != "" && (*webAddr == "" || !strings.HasPrefix(*webURL, "https://")) {
+		return usageError("-web-url requires -web and an https:// address, Telegram opens Mini Apps only over HTTPS")
+	}
+
+	if *importPath == "" && *token != "" && *adminID == 0 {
+		return usageError("-admin argument is required")
+	}
+
+	if *httpAddr != "" && *apiToken == "" {
+		*apiToken = os.Getenv("ENIGMA_API_TOKEN")
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if *importPath != "" {
+		return importFile(
+			ctx,
+			*importPath, *importAccount, *importProfile,
+			a.importTransactionsUsecase, a.getImportProfileUsecase, a.getCategoryMappingsUsecase, a.declareAccountsUsecase,
+		)
+	}
+
+	// work is what components do, it is cancelled only if shutting down takes longer than shutdownTimeout
+	work, abort := context.WithCancel(context.Background())
+	defer abort()
+
+	// components are shut down in the reverse order of starting, e.g. the dashboard before the bot refreshing
+	// messages for it
+	var running []shutdowner
+
+	// a component failing to start stops the ones started before it
+	fail := func(err error) error {
+		shutDown(running, abort)
+		return err
+	}
+
+	// deliveries left in the outbox by the previous run are sent as well
+	dispatcher := webhook.NewDispatcher(a.deliverWebhooksUsecase)
+	dispatcher.Start(work)
+	running = append(running, shutdowner{"webhook dispatcher", dispatcher.Shutdown})
+
+	if *httpAddr != "" {
+		server, err := http.New(
+			*httpAddr, *apiToken,
+			a.createTransactionUsecase, a.getTransactionByID, a.updateTransactionUsecase, a.deleteTransactionUsecase,
+			a.searchTransactionsUsecase, a.exportTransactionsUsecase, a.findDuplicatesUsecase,
+			a.getAccountsUsecase, a.setAccountTypeUsecase, a.setAccountFloorUsecase, a.getBalancesUsecase,
+			a.getTagsUsecase, a.getNetWorthHistoryUsecase, a.forecastUsecase,
+		)
+		if err != nil {
+			return fail(err)
+		}
+
+		err = server.Start(work)
+		if err != nil {
+			return fail(err)
+		}
+		running = append(running, shutdowner{"HTTP API", server.Shutdown})
+	}
+
+	var bot *telegram.Bot
+	if *token != "" {
+		bot, err = telegram.New(*token, *adminID, *webURL, *reportErrors, telegram.Usecases{
+			Idempotence:                a.idempotenceUsecase,
+			GetUserState:               a.getUserstateUsecase,
+			SaveUserState:              a.saveUserstateUsecase,
+			CreateTransaction:          a.createTransactionUsecase,
+			GetTransactionsByDate:      a.getTransactionsByDateUsecase,
+			GetTransactionByID:         a.getTransactionByID,
+			SearchTransactions:         a.searchTransactionsUsecase,
+			GetTags:                    a.getTagsUsecase,
+			CreateGoal:                 a.createGoalUsecase,
+			GetGoalsProgress:           a.getGoalsProgressUsecase,
+			CheckGoals:                 a.checkGoalsUsecase,
+			GetAccounts:                a.getAccountsUsecase,
+			SetAccountType:             a.setAccountTypeUsecase,
+			GetNetWorthHistory:         a.getNetWorthHistoryUsecase,
+			Forecast:                   a.forecastUsecase,
+			SetAccountFloor:            a.setAccountFloorUsecase,
+			ImportTransactions:         a.importTransactionsUsecase,
+			SaveImportProfile:          a.saveImportProfileUsecase,
+			GetImportProfile:           a.getImportProfileUsecase,
+			GetImportProfiles:          a.getImportProfilesUsecase,
+			SaveCategoryMappings:       a.saveCategoryMappingsUsecase,
+			GetCategoryMappings:        a.getCategoryMappingsUsecase,
+			DeclareAccounts:            a.declareAccountsUsecase,
+			ExportTransactions:         a.exportTransactionsUsecase,
+			GetReceiptTransaction:      a.getReceiptTransactionUsecase,
+			CreateBackup:               a.createBackupUsecase,
+			InspectBackup:              a.inspectBackupUsecase,
+			RestoreBackup:              a.restoreBackupUsecase,
+			FindDuplicates:             a.findDuplicatesUsecase,
+			GetDuplicates:              a.getDuplicatesUsecase,
+			DismissDuplicate:           a.dismissDuplicateUsecase,
+			DeleteTransaction:          a.deleteTransactionUsecase,
+			SaveNotificationTemplate:   a.saveNotificationTemplateUsecase,
+			DeleteNotificationTemplate: a.deleteNotificationTemplateUsecase,
+			GetNotificationTemplates:   a.getNotificationTemplatesUsecase,
+		})
+		if err != nil {
+			return fail(err)
+		}
+
+		bot.Start(work)
+		running = append(running, shutdowner{"telegram bot", bot.Shutdown})
+
+		if *webAddr != "" {
+			dashboard, err := web.New(
+				*webAddr, *token, bot.Username(), bot.IsAllowed, bot.RefreshTransaction,
+				a.getTransactionByID, a.splitTransactionUsecase, a.searchTransactionsUsecase, a.getBalancesUsecase, a.getTagsUsecase, a.getNetWorthHistoryUsecase,
+			)
+			if err != nil {
+				return fail(err)
+			}
+
+			err = dashboard.Start(work)
+			if err != nil {
+				return fail(err)
+			}
+			running = append(running, shutdowner{"dashboard", dashboard.Shutdown})
+		}
+	}
+
+	if *metricsAddr != "" {
+		metrics.NewGaugeFunc("enigma_bolt_size_bytes", "Size of the Bolt database file in bytes", func() float64 {
+			size, _ := repository.Size(a.db)
+			return float64(size)
+		})
+
+		// a broken database needs a restart, while Telegram being unreachable only makes the bot not ready
+		liveness := []monitoring.Check{{Name: "bolt", Run: func() error {
+			_, err := repository.Size(a.db)
+			return err
+		}}}
+		var readiness []monitoring.Check
+		if bot != nil {
+			readiness = append(readiness, monitoring.Check{Name: "telegram", Run: bot.Ready})
+		}
+
+		server := monitoring.New(*metricsAddr, liveness, readiness)
+		err = server.Start(work)
+		if err != nil {
+			return fail(err)
+		}
+		// monitoring is shut down last, so the rest is scraped and reported not ready while it stops
+		running = append([]shutdowner{{"monitoring", server.Shutdown}}, running...)
+	}
+
+	<-ctx.Done()
+	logger.Default().Info("shutting down", "timeout", shutdownTimeout)
+	shutDown(running, abort)
+
+	logger.Default().Info("stopped")
+	return nil
+}
+
+// shutDown stops running components in the reverse order of starting, waiting for them up to shutdownTimeout
+func shutDown(running []shutdowner, abort context.CancelFunc) {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// what is still running when the timeout expires is cancelled, so the database can be closed
+	go func() {
+		<-shutdownCtx.Done()
+		abort()
+	}()
+
+	for i := len(running) - 1; i >= 0; i-- {
+		err := running[i].shutdown(shutdownCtx)
+		if err != nil {
+			logger.Default().Error("shut down", "component", running[i].name, "err", err)
+		}
+	}
+}
+
+// shutdownTimeout is how long serve waits for updates and requests in progress after a signal
+const shutdownTimeout = 30 * time.Second
+
+// shutdowner stops a running component, waiting for what it is doing until ctx is done
+type shutdowner struct {
+	name     string
+	shutdown func(ctx context.Context) error
+}
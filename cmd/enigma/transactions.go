@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/exporter"
+)
+
+func addCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("add", "<from> <to> <amount> [description]")
+	date := fs.String("date", "", "date of the transaction, e.g. 2022-05-01, today if empty")
+	currency := fs.String("currency", "", "three-letter currency code, "+entity.DefaultCurrency+" if empty")
+	allowDuplicate := fs.Bool("allow-duplicate", false, "save the transaction even if it looks like one saved before")
+	asJSON := fs.Bool("json", false, "print the created transaction as JSON")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() < 3 {
+		fs.Usage()
+		return usageError("from, to and amount are required")
+	}
+
+	transaction := entity.Transaction{
+		Date:        time.Now().UTC(),
+		FromAccount: fs.Arg(0),
+		ToAccount:   fs.Arg(1),
+		Currency:    strings.ToUpper(*currency),
+		Description: strings.Join(fs.Args()[3:], " "),
+	}
+
+	transaction.Amount, err = strconv.ParseFloat(strings.Replace(fs.Arg(2), ",", ".", 1), 64)
+	if err != nil || transaction.Amount <= 0 || math.IsInf(transaction.Amount, 0) {
+		return usageError("invalid amount %s", fs.Arg(2))
+	}
+
+	if *date != "" {
+		transaction.Date, err = time.Parse("2006-01-02", *date)
+		if err != nil {
+			return usageError("invalid date %s, expected 2006-01-02", *date)
+		}
+	}
+
+	if transaction.Currency == entity.DefaultCurrency {
+		transaction.Currency = ""
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if !*allowDuplicate {
+		duplicates, err := a.findDuplicatesUsecase.Execute(ctx, transaction)
+		if err != nil {
+			return err
+		}
+
+		if len(duplicates) > 0 {
+			fmt.Fprintln(os.Stderr, "The transaction looks like one saved before, add -allow-duplicate to save it anyway:")
+			printTransactions(os.Stderr, duplicates)
+			return exitCodeError{code: exitDuplicate, err: fmt.Errorf("%d possible duplicates", len(duplicates)), reported: true}
+		}
+	}
+
+	transaction, err = a.createTransactionUsecase.Execute(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		writer := exporter.NewJSONL(os.Stdout)
+		err = writer.Write(transaction)
+		if err != nil {
+			return err
+		}
+		return writer.Flush()
+	}
+
+	fmt.Printf("Transaction #%d created\n", transaction.ID)
+	return nil
+}
+
+func listCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("list", "")
+	from := fs.String("from", "", "first day or period to list, e.g. 2022-05-01 or 2022-05")
+	to := fs.String("to", "", "last day or period to list, inclusive")
+	query := fs.String("q", "", "search query like in the bot, e.g. \"pharmacy #spring from:card >1000\"")
+	limit := fs.Int("limit", 0, "list only the newest transactions, all if 0")
+	asJSON := fs.Bool("json", false, "print JSON Lines instead of a table")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return usageError("unexpected arguments %s, flags go before arguments", strings.Join(fs.Args(), " "))
+	}
+	if *limit < 0 {
+		return usageError("-limit must not be negative")
+	}
+
+	filter, err := entity.ParseTransactionFilter(*query)
+	if err != nil {
+		return usageError("%s", err)
+	}
+
+	since, until, _ := entity.ParsePeriod("all")
+	if *from != "" {
+		since, _, err = entity.ParsePeriod(*from)
+		if err != nil {
+			return usageError("invalid -from: %s", err)
+		}
+	}
+	if *to != "" {
+		_, until, err = entity.ParsePeriod(*to)
+		if err != nil {
+			return usageError("invalid -to: %s", err)
+		}
+	}
+	if !since.Before(until) {
+		return usageError("-from must not be after -to")
+	}
+
+	// a period in the query narrows the one of the flags
+	if filter.Since == nil || filter.Since.Before(since) {
+		filter.Since = &since
+	}
+	if filter.Until == nil || filter.Until.After(until) {
+		filter.Until = &until
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	pageSize := *limit
+	if pageSize == 0 {
+		pageSize = math.MaxInt32
+	}
+
+	transactions, _, err := a.searchTransactionsUsecase.Execute(ctx, filter, 0, pageSize)
+	if err != nil {
+		return err
+	}
+
+	// transactions are found newest first, they are printed in the order they happened
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+
+	if *asJSON {
+		writer := exporter.NewJSONL(os.Stdout)
+		for _, t := range transactions {
+			err = writer.Write(t)
+			if err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	}
+
+	return printTransactions(os.Stdout, transactions)
+}
+
+func printTransactions(w *os.File, transactions []entity.Transaction) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tDATE\tFROM\tTO\tAMOUNT\tCURRENCY\tDESCRIPTION")
+	for _, t := range transactions {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, t.Date.Format("2006-01-02"), t.FromAccount, t.ToAccount,
+			strconv.FormatFloat(t.Amount, 'f', 2, 64), t.CurrencyCode(), t.Description)
+	}
+	return table.Flush()
+}
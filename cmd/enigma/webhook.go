@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"enigma/internal/entity"
+)
+
+func webhookCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("webhook command is required: add, list or remove")
+	}
+
+	switch args[0] {
+	case "add":
+		return webhookAddCommand(ctx, args[1:])
+	case "list":
+		return webhookListCommand(ctx, args[1:])
+	case "remove":
+		return webhookRemoveCommand(ctx, args[1:])
+	}
+	return usageError("unknown webhook command %s, expected add, list or remove", args[0])
+}
+
+func webhookAddCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("webhook add", "<url>")
+	events := fs.String("events", "", "comma-separated events to post, e.g. transaction.created, every event if empty")
+	secret := fs.String("secret", "", "secret signing requests, generated if empty")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return usageError("webhook URL is required")
+	}
+
+	var eventTypes []entity.EventType
+	for _, event := range strings.Split(*events, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			eventTypes = append(eventTypes, entity.EventType(event))
+		}
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	webhook, err := a.createWebhookUsecase.Execute(ctx, fs.Arg(0), *secret, eventTypes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Webhook #%d posts to %s\n", webhook.ID, webhook.URL)
+	fmt.Printf("Secret: %s\n", webhook.Secret)
+	return nil
+}
+
+func webhookListCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("webhook list", "")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	webhooks, pending, err := a.getWebhooksUsecase.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tURL\tEVENTS")
+	for _, webhook := range webhooks {
+		events := "all"
+		if len(webhook.Events) > 0 {
+			names := make([]string, 0, len(webhook.Events))
+			for _, event := range webhook.Events {
+				names = append(names, string(event))
+			}
+			events = strings.Join(names, ",")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", webhook.ID, webhook.URL, events)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d deliveries pending\n", pending)
+	return nil
+}
+
+func webhookRemoveCommand(ctx context.Context, args []string) error {
+	fs, dbPath := newFlagSet("webhook remove", "<id>")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return usageError("webhook id is required")
+	}
+
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+	if err != nil {
+		return usageError("invalid webhook id %s", fs.Arg(0))
+	}
+
+	a, err := openApp(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return a.deleteWebhookUsecase.Execute(ctx, id)
+}
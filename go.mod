@@ -4,7 +4,14 @@ go 1.18
 
 require (
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/makiuchi-d/gozxing v0.1.1
 	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.6.0
+	golang.org/x/image v0.5.0
+	golang.org/x/text v0.7.0
 )
 
-require golang.org/x/sys v0.0.0-20220406163625-3f8b81556e12 // indirect
+require (
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+)
@@ -0,0 +1,209 @@
+// Package encryption encrypts streams with a passphrase, it is used for database backups
+// which leave the machine as Telegram documents
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// The stream is the magic, the salt of the key and chunks sealed with AES-256-GCM,
+// every chunk is its plaintext length, the high bit of which marks the last chunk, and the sealed data.
+// Chunks are numbered by their nonces and a stream without the last chunk is rejected.
+var magic = []byte("ENIGMA\x00\x01")
+
+const (
+	saltSize  = 16
+	chunkSize = 64 * 1024
+	lastChunk = 1 << 31
+)
+
+var (
+	WrongPassphraseErr = errors.New("wrong passphrase or corrupted data")
+	TruncatedErr       = errors.New("encrypted data is truncated")
+)
+
+// IsEncrypted reports whether r starts with an encrypted stream without consuming it
+func IsEncrypted(r *bufio.Reader) bool {
+	prefix, err := r.Peek(len(magic))
+	return err == nil && bytes.Equal(prefix, magic)
+}
+
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func nonce(aead cipher.AEAD, counter uint64) []byte {
+	n := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(n[len(n)-8:], counter)
+	return n
+}
+
+type writer struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+// NewWriter encrypts everything written to it with passphrase, Close must be called to write the last chunk
+func NewWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required")
+	}
+
+	salt := make([]byte, saltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(append(append([]byte{}, magic...), salt...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &writer{w: w, aead: aead, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *writer) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed encryption writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+
+		// a full chunk is kept until more data comes, the last chunk is only known on Close
+		if len(e.buf) == cap(e.buf) && len(p) > 0 {
+			err := e.writeChunk(false)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (e *writer) writeChunk(last bool) error {
+	header := uint32(len(e.buf))
+	if last {
+		header |= lastChunk
+	}
+
+	headerBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(headerBytes, header)
+
+	sealed := e.aead.Seal(nil, nonce(e.aead, e.counter), e.buf, headerBytes)
+	e.counter++
+	e.buf = e.buf[:0]
+
+	_, err := e.w.Write(append(headerBytes, sealed...))
+	return err
+}
+
+func (e *writer) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.writeChunk(true)
+}
+
+type reader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	last    bool
+}
+
+// NewReader decrypts a stream written by NewWriter
+func NewReader(r io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, len(magic)+saltSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil || !bytes.Equal(header[:len(magic)], magic) {
+		return nil, errors.New("data is not encrypted")
+	}
+
+	aead, err := newAEAD(passphrase, header[len(magic):])
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{r: r, aead: aead}, nil
+}
+
+func (d *reader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.last {
+			return 0, io.EOF
+		}
+
+		err := d.readChunk()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *reader) readChunk() error {
+	headerBytes := make([]byte, 4)
+	_, err := io.ReadFull(d.r, headerBytes)
+	if err != nil {
+		return TruncatedErr
+	}
+
+	header := binary.BigEndian.Uint32(headerBytes)
+	size := int(header &^ lastChunk)
+	if size > chunkSize {
+		return WrongPassphraseErr
+	}
+
+	sealed := make([]byte, size+d.aead.Overhead())
+	_, err = io.ReadFull(d.r, sealed)
+	if err != nil {
+		return TruncatedErr
+	}
+
+	d.buf, err = d.aead.Open(sealed[:0], nonce(d.aead, d.counter), sealed, headerBytes)
+	if err != nil {
+		return WrongPassphraseErr
+	}
+	d.counter++
+	d.last = header&lastChunk != 0
+
+	return nil
+}
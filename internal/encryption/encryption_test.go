@@ -0,0 +1,131 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+const passphrase = "correct horse battery staple"
+
+// headerSize is the magic and the salt, sealedChunkSize is a full chunk with its length and the tag
+const (
+	headerSize      = 8 + saltSize
+	sealedChunkSize = 4 + chunkSize + 16
+)
+
+func encrypt(t *testing.T, plaintext []byte) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	w, err := NewWriter(&b, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func decrypt(encrypted []byte, passphrase string) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(encrypted), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// plaintext spans two full chunks and a part of the last one
+func plaintext() []byte {
+	p := make([]byte, 2*chunkSize+1000)
+	rand.New(rand.NewSource(1)).Read(p)
+	return p
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, chunkSize, chunkSize + 1, 2*chunkSize + 1000} {
+		p := plaintext()[:size]
+
+		encrypted := encrypt(t, p)
+		if !IsEncrypted(bufio.NewReader(bytes.NewReader(encrypted))) {
+			t.Errorf("%d bytes: encrypted data is not recognized", size)
+		}
+
+		decrypted, err := decrypt(encrypted, passphrase)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+		if !bytes.Equal(decrypted, p) {
+			t.Errorf("%d bytes: decrypted data differs", size)
+		}
+	}
+}
+
+func TestDecryptRejectsTamperedData(t *testing.T) {
+	encrypted := encrypt(t, plaintext())
+	if len(encrypted) != headerSize+2*sealedChunkSize+4+1000+16 {
+		t.Fatalf("encrypted data of %d bytes has unexpected layout", len(encrypted))
+	}
+
+	reordered := append([]byte{}, encrypted[:headerSize]...)
+	reordered = append(reordered, encrypted[headerSize+sealedChunkSize:headerSize+2*sealedChunkSize]...)
+	reordered = append(reordered, encrypted[headerSize:headerSize+sealedChunkSize]...)
+	reordered = append(reordered, encrypted[headerSize+2*sealedChunkSize:]...)
+
+	flipped := append([]byte{}, encrypted...)
+	flipped[headerSize+100] ^= 1
+
+	// the length of a full chunk marked as the last one, so the rest would be dropped
+	markedLast := append([]byte{}, encrypted[:headerSize+sealedChunkSize]...)
+	markedLast[headerSize] |= 0x80
+
+	tests := []struct {
+		name       string
+		encrypted  []byte
+		passphrase string
+		err        error
+	}{
+		{"wrong passphrase", encrypted, "wrong horse battery staple", WrongPassphraseErr},
+		{"reordered chunks", reordered, passphrase, WrongPassphraseErr},
+		{"flipped bit", flipped, passphrase, WrongPassphraseErr},
+		{"chunk marked last", markedLast, passphrase, WrongPassphraseErr},
+		{"without the last chunk", encrypted[:headerSize+2*sealedChunkSize], passphrase, TruncatedErr},
+		{"cut in a chunk", encrypted[:headerSize+sealedChunkSize+100], passphrase, TruncatedErr},
+		{"cut in a length", encrypted[:headerSize+sealedChunkSize+2], passphrase, TruncatedErr},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := decrypt(test.encrypted, test.passphrase)
+			if !errors.Is(err, test.err) {
+				t.Errorf("err %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewReaderRejectsPlainData(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("a database which is not encrypted")), passphrase)
+	if err == nil {
+		t.Error("plain data is accepted")
+	}
+	if IsEncrypted(bufio.NewReader(bytes.NewReader([]byte("ENIGMA")))) {
+		t.Error("prefix of the magic is recognized as encrypted data")
+	}
+}
+
+func TestNewWriterRequiresPassphrase(t *testing.T) {
+	_, err := NewWriter(io.Discard, "")
+	if err == nil {
+		t.Error("empty passphrase is accepted")
+	}
+}
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var AccountNotFoundErr = errors.New("account not found")
+
+type AccountType string
+
+const (
+	UnknownAccount   AccountType = ""
+	AssetAccount     AccountType = "asset"
+	LiabilityAccount AccountType = "liability"
+	IncomeAccount    AccountType = "income"
+	ExpenseAccount   AccountType = "expense"
+	EquityAccount    AccountType = "equity"
+)
+
+var AccountTypes = []AccountType{AssetAccount, LiabilityAccount, IncomeAccount, ExpenseAccount, EquityAccount}
+
+type Account struct {
+	Name string      `json:"name"`
+	Type AccountType `json:"type"`
+
+	// Floor is the lowest balance the account should be kept above
+	Floor *float64 `json:"floor,omitempty"`
+}
+
+// IsBalanceSheet reports whether the account is a part of net worth
+func (a Account) IsBalanceSheet() bool {
+	return a.Type == AssetAccount || a.Type == LiabilityAccount
+}
+
+func ParseAccountType(s string) (AccountType, error) {
+	s = strings.TrimSuffix(strings.ToLower(s), "s")
+	if s == "liabilitie" {
+		s = string(LiabilityAccount)
+	}
+	for _, t := range AccountTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return UnknownAccount, fmt.Errorf("unknown account type %s", s)
+}
+
+// InferAccountType guesses the type of accounts named in the plain-text accounting style, e.g. "assets:card"
+func InferAccountType(name string) AccountType {
+	root := strings.SplitN(name, ":", 2)[0]
+	if root == name {
+		return UnknownAccount
+	}
+	t, err := ParseAccountType(root)
+	if err != nil {
+		return UnknownAccount
+	}
+	return t
+}
+
+// BalanceSnapshot holds balances of every account at the end of Month in the default currency,
+// Other holds balances in other currencies by currency
+type BalanceSnapshot struct {
+	Month    time.Time                     `json:"month"`
+	Balances map[string]float64            `json:"balances"`
+	Other    map[string]map[string]float64 `json:"other,omitempty"`
+}
+
+// NetWorthPoint is in the default currency, Other holds net worth in other currencies by currency
+type NetWorthPoint struct {
+	Month       time.Time
+	Assets      float64
+	Liabilities float64
+	Other       map[string]float64
+}
+
+func (p NetWorthPoint) NetWorth() float64 {
+	return p.Assets + p.Liabilities
+}
+
+// AccountBalance is the balance of an account in one currency
+type AccountBalance struct {
+	Account  Account
+	Currency string
+	Balance  float64
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var BackupPassphraseRequiredErr = errors.New("backup is encrypted, passphrase is required")
+
+// BackupInfo describes a database backup before it is restored
+type BackupInfo struct {
+	Size         int64
+	Transactions int
+
+	// FirstDate and LastDate are zero if there are no transactions
+	FirstDate time.Time
+	LastDate  time.Time
+}
@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DuplicateWindow is how far apart dates of the same purchase recorded twice may be,
+// a bank statement often books a card payment a couple of days after it was entered by hand
+const DuplicateWindow = 3 * 24 * time.Hour
+
+// duplicateSimilarity is the minimal similarity of descriptions of duplicates, from 0 for different to 1 for equal
+const duplicateSimilarity = 0.6
+
+// DuplicatePair is a pair of transactions which are likely the same one recorded twice, First is the older one
+type DuplicatePair struct {
+	First  Transaction
+	Second Transaction
+}
+
+// Key identifies the pair regardless of the order of transactions
+func (p DuplicatePair) Key() (uint64, uint64) {
+	if p.First.ID < p.Second.ID {
+		return p.First.ID, p.Second.ID
+	}
+	return p.Second.ID, p.First.ID
+}
+
+// IsDuplicate reports whether a and b are likely the same transaction: they have the same amount and accounts,
+// their dates are within DuplicateWindow and descriptions are similar
+func IsDuplicate(a, b Transaction) bool {
+	if a.ID != 0 && a.ID == b.ID {
+		return false
+	}
+	if math.Abs(a.Amount-b.Amount) >= 0.005 || a.CurrencyCode() != b.CurrencyCode() {
+		return false
+	}
+	if !strings.EqualFold(a.FromAccount, b.FromAccount) || !strings.EqualFold(a.ToAccount, b.ToAccount) {
+		return false
+	}
+
+	distance := a.Date.Sub(b.Date)
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance > DuplicateWindow {
+		return false
+	}
+
+	return DescriptionSimilarity(a.Description, b.Description) >= duplicateSimilarity
+}
+
+// DescriptionSimilarity compares descriptions by the edit distance of their words ignoring case and punctuation,
+// one description being empty means nothing is known, so such descriptions are considered similar
+func DescriptionSimilarity(a, b string) float64 {
+	x, y := foldDescription(a), foldDescription(b)
+	if len(x) == 0 || len(y) == 0 {
+		return 1
+	}
+
+	longest := len(x)
+	if len(y) > longest {
+		longest = len(y)
+	}
+	return 1 - float64(levenshtein(x, y))/float64(longest)
+}
+
+func foldDescription(s string) []rune {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return []rune(strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r == 'ё' {
+			return 'е'
+		}
+		return r
+	}, strings.Join(words, " ")))
+}
+
+// levenshtein is the number of single rune insertions, deletions and substitutions turning a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsDuplicate(t *testing.T) {
+	day := time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC)
+	entered := Transaction{ID: 1, Date: day, FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "Пятёрочка"}
+
+	tests := []struct {
+		name      string
+		change    func(*Transaction)
+		duplicate bool
+	}{
+		{"booked two days later", func(t *Transaction) { t.ID = 2; t.Date = day.AddDate(0, 0, 2) }, true},
+		{"booked at the end of the window", func(t *Transaction) { t.ID = 2; t.Date = day.Add(DuplicateWindow) }, true},
+		{"booked before", func(t *Transaction) { t.ID = 2; t.Date = day.Add(-DuplicateWindow) }, true},
+		{"booked after the window", func(t *Transaction) { t.ID = 2; t.Date = day.Add(DuplicateWindow + time.Hour) }, false},
+		{"the same transaction", func(t *Transaction) {}, false},
+		{"not saved yet", func(t *Transaction) { t.ID = 0 }, true},
+		{"amount off by a kopeck", func(t *Transaction) { t.ID = 2; t.Amount = 1250.01 }, false},
+		{"amount rounded", func(t *Transaction) { t.ID = 2; t.Amount = 1250.004 }, true},
+		{"another currency", func(t *Transaction) { t.ID = 2; t.Currency = "EUR" }, false},
+		{"explicit default currency", func(t *Transaction) { t.ID = 2; t.Currency = DefaultCurrency }, true},
+		{"account case", func(t *Transaction) { t.ID = 2; t.FromAccount = "Card" }, true},
+		{"another account", func(t *Transaction) { t.ID = 2; t.ToAccount = "cafe" }, false},
+		{"description of the statement", func(t *Transaction) { t.ID = 2; t.Description = "ПЯТЕРОЧКА" }, true},
+		{"empty description", func(t *Transaction) { t.ID = 2; t.Description = "" }, true},
+		{"different description", func(t *Transaction) { t.ID = 2; t.Description = "Лента" }, false},
+	}
+	for _, tt := range tests {
+		other := entered
+		tt.change(&other)
+		if got := IsDuplicate(entered, other); got != tt.duplicate {
+			t.Errorf("%s: IsDuplicate = %v, want %v", tt.name, got, tt.duplicate)
+		}
+		if got := IsDuplicate(other, entered); got != tt.duplicate {
+			t.Errorf("%s: IsDuplicate of swapped transactions = %v, want %v", tt.name, got, tt.duplicate)
+		}
+	}
+}
+
+func TestDescriptionSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"coffee", "coffee", 1},
+		{"Coffee!", "coffee", 1},
+		{"coffee  shop", "coffee, shop", 1},
+		{"ёлка", "Елка", 1},
+		{"", "anything", 1},
+		// one substitution of ten runes
+		{"coffee bar", "coffee car", 0.9},
+		// the threshold is 0.6: four edits of ten runes are still similar, five are not
+		{"coffee bar", "coffee xyz", 0.7},
+		{"abcdefghij", "abcdefwxyz", 0.6},
+		{"abcdefghij", "abcdevwxyz", 0.5},
+		{"abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		if got := DescriptionSimilarity(tt.a, tt.b); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("DescriptionSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"пятёрочка", "пятерочка", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
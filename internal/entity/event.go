@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var WebhookNotFoundErr = errors.New("webhook not found")
+
+type EventType string
+
+const (
+	TransactionCreatedEvent EventType = "transaction.created"
+	TransactionUpdatedEvent EventType = "transaction.updated"
+	TransactionDeletedEvent EventType = "transaction.deleted"
+)
+
+var EventTypes = []EventType{TransactionCreatedEvent, TransactionUpdatedEvent, TransactionDeletedEvent}
+
+// Event is a change of the ledger other tools are notified of
+type Event struct {
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	Transaction Transaction `json:"transaction"`
+
+	// Previous is the transaction before it was updated
+	Previous *Transaction `json:"previous,omitempty"`
+}
+
+// Deliver makes deliveries of an event of a change of transaction to the webhooks subscribed to it, previous is the
+// transaction before an update. Repositories enqueue the deliveries in the database transaction of the change
+type Deliver func(eventType EventType, transaction Transaction, previous *Transaction) []Delivery
+
+// Webhook is a URL events are posted to, requests are signed with Secret
+type Webhook struct {
+	ID     uint64 `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+
+	// Events are the types the webhook is subscribed to, every type if empty
+	Events []EventType `json:"events,omitempty"`
+}
+
+func (w Webhook) IsSubscribed(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is an event waiting in the outbox to be posted to a webhook
+type Delivery struct {
+	// ID is sent with every attempt, so the receiver can tell retries from new events
+	ID        string `json:"id"`
+	WebhookID uint64 `json:"webhook_id"`
+	Event     Event  `json:"event"`
+
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TransactionFilter struct {
+	Terms []string
+	Tags  []string
+
+	FromAccount string
+	ToAccount   string
+
+	MinAmount *float64
+	MaxAmount *float64
+
+	// Since is inclusive, Until is exclusive
+	Since *time.Time
+	Until *time.Time
+}
+
+// ParseTransactionFilter parses queries like "pharmacy #spring from:card >1000 2022-05", a bare year is a term,
+// e.g. of a receipt number, so years are filtered with a prefix like "period:2022"
+func ParseTransactionFilter(query string) (TransactionFilter, error) {
+	var filter TransactionFilter
+
+	for _, word := range strings.Fields(query) {
+		lower := strings.ToLower(word)
+
+		switch {
+		case len(word) > 1 && strings.HasPrefix(word, "#"):
+			filter.Tags = append(filter.Tags, NormalizeTag(word))
+		case strings.HasPrefix(lower, "from:"):
+			filter.FromAccount = word[len("from:"):]
+		case strings.HasPrefix(lower, "to:"):
+			filter.ToAccount = word[len("to:"):]
+		case strings.HasPrefix(lower, "period:"):
+			since, until, err := ParsePeriod(word[len("period:"):])
+			if err != nil {
+				return TransactionFilter{}, err
+			}
+			filter.Since = &since
+			filter.Until = &until
+		case strings.HasPrefix(word, ">="):
+			amount, err := parseFilterAmount(word[2:])
+			if err != nil {
+				return TransactionFilter{}, err
+			}
+			filter.MinAmount = &amount
+		case strings.HasPrefix(word, "<="):
+			amount, err := parseFilterAmount(word[2:])
+			if err != nil {
+				return TransactionFilter{}, err
+			}
+			filter.MaxAmount = &amount
+		case strings.HasPrefix(word, ">"):
+			amount, err := parseFilterAmount(word[1:])
+			if err != nil {
+				return TransactionFilter{}, err
+			}
+			amount = nextAmount(amount)
+			filter.MinAmount = &amount
+		case strings.HasPrefix(word, "<"):
+			amount, err := parseFilterAmount(word[1:])
+			if err != nil {
+				return TransactionFilter{}, err
+			}
+			amount = prevAmount(amount)
+			filter.MaxAmount = &amount
+		default:
+			since, until, ok := parseFilterPeriod(word)
+			if ok && !isYear(word) {
+				filter.Since = &since
+				filter.Until = &until
+				continue
+			}
+			filter.Terms = append(filter.Terms, word)
+		}
+	}
+
+	return filter, nil
+}
+
+func (f TransactionFilter) IsEmpty() bool {
+	return len(f.Terms) == 0 && len(f.Tags) == 0 && f.FromAccount == "" && f.ToAccount == "" &&
+		f.MinAmount == nil && f.MaxAmount == nil && f.Since == nil && f.Until == nil
+}
+
+// Match checks every condition except Terms, which are resolved by the full-text index
+func (f TransactionFilter) Match(t Transaction) bool {
+	for _, tag := range f.Tags {
+		if !t.HasTag(tag) {
+			return false
+		}
+	}
+	if f.FromAccount != "" && !strings.EqualFold(f.FromAccount, t.FromAccount) {
+		return false
+	}
+	if f.ToAccount != "" && !strings.EqualFold(f.ToAccount, t.ToAccount) {
+		return false
+	}
+	if f.MinAmount != nil && t.Amount < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && t.Amount > *f.MaxAmount {
+		return false
+	}
+	if f.Since != nil && t.Date.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && !t.Date.Before(*f.Until) {
+		return false
+	}
+	return true
+}
+
+func parseFilterAmount(s string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s: %w", s, err)
+	}
+	return amount, nil
+}
+
+// amounts are kept with kopeck precision, so strict comparisons move the bound by one kopeck
+func nextAmount(amount float64) float64 {
+	return amount + 0.01
+}
+
+func prevAmount(amount float64) float64 {
+	return amount - 0.01
+}
+
+// ParsePeriod parses periods like "2022-05", "2022-05-01", "2022", ranges of them like "2022-01..2022-03"
+// and "all", the returned since is inclusive and until is exclusive
+func ParsePeriod(s string) (time.Time, time.Time, error) {
+	if strings.EqualFold(s, "all") {
+		return time.Time{}, maxPeriodDate, nil
+	}
+
+	first, last := s, s
+	if i := strings.Index(s, ".."); i >= 0 {
+		first, last = s[:i], s[i+len(".."):]
+	}
+
+	since, _, ok := parseFilterPeriod(first)
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %s", s)
+	}
+	_, until, ok := parseFilterPeriod(last)
+	if !ok || !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %s", s)
+	}
+
+	return since, until, nil
+}
+
+// maxPeriodDate is the exclusive end of an unbounded period, later years don't fit date keys of four digits
+var maxPeriodDate = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func isYear(s string) bool {
+	_, err := time.Parse("2006", s)
+	return len(s) == 4 && err == nil
+}
+
+func parseFilterPeriod(s string) (time.Time, time.Time, bool) {
+	if date, err := time.Parse("2006-01-02", s); err == nil {
+		return date, date.AddDate(0, 0, 1), true
+	}
+	if date, err := time.Parse("2006-01", s); err == nil {
+		return date, date.AddDate(0, 1, 0), true
+	}
+	if len(s) == 4 {
+		if date, err := time.Parse("2006", s); err == nil {
+			return date, date.AddDate(1, 0, 0), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTransactionFilterPeriods(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			panic(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		query        string
+		terms        []string
+		since, until time.Time
+	}{
+		{"pharmacy 2022-05", []string{"pharmacy"}, day("2022-05-01"), day("2022-06-01")},
+		{"pharmacy 2022-05-14", []string{"pharmacy"}, day("2022-05-14"), day("2022-05-15")},
+		// a bare year is likely a number in the description
+		{"receipt 1984", []string{"receipt", "1984"}, time.Time{}, time.Time{}},
+		{"coffee period:2022", []string{"coffee"}, day("2022-01-01"), day("2023-01-01")},
+		{"PERIOD:2022-01..2022-03", nil, day("2022-01-01"), day("2022-04-01")},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseTransactionFilter(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+
+		if strings.Join(filter.Terms, " ") != strings.Join(test.terms, " ") {
+			t.Errorf("%s: terms %q, want %q", test.query, filter.Terms, test.terms)
+		}
+		if test.since.IsZero() {
+			if filter.Since != nil || filter.Until != nil {
+				t.Errorf("%s: filtered by period %v..%v", test.query, filter.Since, filter.Until)
+			}
+			continue
+		}
+		if filter.Since == nil || !filter.Since.Equal(test.since) || filter.Until == nil || !filter.Until.Equal(test.until) {
+			t.Errorf("%s: period %v..%v, want %v..%v", test.query, filter.Since, filter.Until, test.since, test.until)
+		}
+	}
+
+	_, err := ParseTransactionFilter("period:someday")
+	if err == nil {
+		t.Error("invalid period is accepted")
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := map[string]string{
+		"2022":             "2022-01-01..2023-01-01",
+		"2022-05":          "2022-05-01..2022-06-01",
+		"2022-05-14":       "2022-05-14..2022-05-15",
+		"2022-01..2022-03": "2022-01-01..2022-04-01",
+	}
+	for period, want := range tests {
+		since, until, err := ParsePeriod(period)
+		if err != nil {
+			t.Errorf("%s: %v", period, err)
+			continue
+		}
+		if got := since.Format("2006-01-02") + ".." + until.Format("2006-01-02"); got != want {
+			t.Errorf("%s is %s, want %s", period, got, want)
+		}
+	}
+
+	for _, period := range []string{"", "22", "2022-13", "2022-03..2022-01", "yesterday"} {
+		if _, _, err := ParsePeriod(period); err == nil {
+			t.Errorf("invalid period %q is accepted", period)
+		}
+	}
+}
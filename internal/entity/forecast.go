@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// RecurringTransaction is a monthly payment detected in the transaction history
+type RecurringTransaction struct {
+	FromAccount string
+	ToAccount   string
+	Amount      float64
+	Currency    string
+	Description string
+	DayOfMonth  int
+}
+
+type ForecastPoint struct {
+	Date    time.Time
+	Balance float64
+}
+
+// AccountForecast projects the balance of an account in one currency
+type AccountForecast struct {
+	Account  Account
+	Currency string
+	Balance  float64
+
+	// DailySpending is the average discretionary spending subtracted every day
+	DailySpending float64
+	Recurring     []RecurringTransaction
+
+	// Points contains the projected balance at the end of every day
+	Points []ForecastPoint
+
+	// BelowZeroAt and BelowFloorAt are the first days the balance is projected to drop below the limits
+	BelowZeroAt  *time.Time
+	BelowFloorAt *time.Time
+}
+
+// BalanceAt returns the projected balance at the end of date
+func (f AccountForecast) BalanceAt(date time.Time) float64 {
+	balance := f.Balance
+	for _, p := range f.Points {
+		if p.Date.After(date) {
+			break
+		}
+		balance = p.Balance
+	}
+	return balance
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var GoalNotFoundErr = errors.New("goal not found")
+
+type Goal struct {
+	ID        uint64     `json:"id"`
+	Name      string     `json:"name"`
+	Account   string     `json:"account"`
+	Target    float64    `json:"target"`
+	Deadline  time.Time  `json:"deadline"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReachedAt *time.Time `json:"reached_at,omitempty"`
+}
+
+type GoalProgress struct {
+	Goal  Goal
+	Saved float64
+
+	// OtherCurrencies of transactions of the account are not counted, the target is in the default currency
+	OtherCurrencies []string
+
+	// RequiredMonthly is the contribution needed every month to reach the target by the deadline
+	RequiredMonthly float64
+	// MonthlyPace is the average monthly contribution over the recent months
+	MonthlyPace float64
+	// ProjectedCompletion is nil when the goal is not going to be reached at the current pace
+	ProjectedCompletion *time.Time
+}
+
+func (p GoalProgress) Percent() float64 {
+	if p.Goal.Target <= 0 {
+		return 100
+	}
+	percent := p.Saved / p.Goal.Target * 100
+	if percent < 0 {
+		return 0
+	}
+	return percent
+}
@@ -0,0 +1,49 @@
+package entity
+
+import "errors"
+
+var ImportProfileNotFoundErr = errors.New("import profile not found")
+
+// ImportedTransaction is a transaction read from a statement,
+// Key identifies it across imports of overlapping statements
+type ImportedTransaction struct {
+	Key         string
+	Transaction Transaction
+}
+
+type ImportResult struct {
+	Created int
+	Skipped int
+
+	// Duplicates pairs created transactions, as Second, with the saved ones they are likely duplicates of
+	Duplicates []DuplicatePair
+}
+
+// ImportProfile describes how columns of a bank CSV export map onto transactions
+type ImportProfile struct {
+	Name string `json:"name"`
+
+	Delimiter  string `json:"delimiter"`
+	Encoding   string `json:"encoding"`
+	DateFormat string `json:"date_format"`
+	SkipRows   int    `json:"skip_rows"`
+
+	// DecimalSeparator is "." or "," of amounts, it is guessed from every amount if empty
+	DecimalSeparator string `json:"decimal_separator,omitempty"`
+
+	DateColumn        int `json:"date_column"`
+	AmountColumn      int `json:"amount_column"`
+	DescriptionColumn int `json:"description_column"`
+
+	// Account is the bank account the statement belongs to, negative amounts are spent
+	// from it to ExpenseAccount, positive ones come to it from IncomeAccount
+	Account        string `json:"account"`
+	ExpenseAccount string `json:"expense_account"`
+	IncomeAccount  string `json:"income_account"`
+}
+
+// CategoryMapping tells which account a category of other finance software is imported to
+type CategoryMapping struct {
+	Category string `json:"category"`
+	Account  string `json:"account"`
+}
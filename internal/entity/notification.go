@@ -0,0 +1,35 @@
+package entity
+
+import "errors"
+
+var (
+	NotificationTemplateNotFoundErr = errors.New("notification template not found")
+	UnknownNotificationErr          = errors.New("the message matches no bank notification template, add one with /template")
+)
+
+// NotificationTemplate recognizes notifications a bank sends through its bot or SMS,
+// Pattern is a regular expression with named groups amount, currency, merchant, card and balance, only amount is required
+type NotificationTemplate struct {
+	// Name identifies the template, a saved template replaces the built-in one of the same name
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+
+	// Account is the card or bank account of notifications, "card" followed by the card number if empty
+	Account string `json:"account,omitempty"`
+
+	// Income notifications are money coming to Account from IncomeAccount, others are spent from it to ExpenseAccount
+	Income         bool   `json:"income,omitempty"`
+	ExpenseAccount string `json:"expense_account,omitempty"`
+	IncomeAccount  string `json:"income_account,omitempty"`
+}
+
+// BankNotification is what a template extracted from a notification
+type BankNotification struct {
+	Template string   `json:"template"`
+	Income   bool     `json:"income,omitempty"`
+	Amount   float64  `json:"amount"`
+	Currency string   `json:"currency,omitempty"`
+	Merchant string   `json:"merchant,omitempty"`
+	Card     string   `json:"card,omitempty"`
+	Balance  *float64 `json:"balance,omitempty"`
+}
@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ReceiptExistsErr = errors.New("receipt is already entered")
+
+type ReceiptType int
+
+// receipt types of the fiscal data format, "n" field of the QR code
+const (
+	ReceiptIncome        ReceiptType = 1
+	ReceiptIncomeReturn  ReceiptType = 2
+	ReceiptExpense       ReceiptType = 3
+	ReceiptExpenseReturn ReceiptType = 4
+)
+
+func (t ReceiptType) String() string {
+	switch t {
+	case ReceiptIncome:
+		return "purchase"
+	case ReceiptIncomeReturn:
+		return "purchase return"
+	case ReceiptExpense:
+		return "expense"
+	case ReceiptExpenseReturn:
+		return "expense return"
+	default:
+		return "receipt type " + strconv.Itoa(int(t))
+	}
+}
+
+// Receipt holds the fiscal fields of a Russian cash receipt QR code,
+// e.g. "t=20220501T1830&s=1234.50&fn=9289000100123456&i=12345&fp=1234567890&n=1"
+type Receipt struct {
+	// Time is the wall time printed on the receipt, kept in UTC like transaction dates
+	Time time.Time   `json:"time"`
+	Sum  float64     `json:"sum"`
+	FN   string      `json:"fn"`
+	FD   string      `json:"fd"`
+	FP   string      `json:"fp"`
+	Type ReceiptType `json:"type"`
+}
+
+// Key identifies the receipt, the fiscal drive number, document number and fiscal sign are unique together
+func (r Receipt) Key() string {
+	return r.FN + ":" + r.FD + ":" + r.FP
+}
+
+// IsReceiptQR reports whether s looks like the content of a receipt QR code
+func IsReceiptQR(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "t=") && strings.Contains(s, "&fn=") && !strings.ContainsAny(s, " \n")
+}
+
+func ParseReceiptQR(s string) (Receipt, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(s))
+	if err != nil {
+		return Receipt{}, fmt.Errorf("invalid receipt QR code: %w", err)
+	}
+
+	for _, key := range []string{"t", "s", "fn", "i", "fp", "n"} {
+		if values.Get(key) == "" {
+			return Receipt{}, fmt.Errorf("invalid receipt QR code: %s is missing", key)
+		}
+	}
+
+	receipt := Receipt{
+		FN: values.Get("fn"),
+		FD: values.Get("i"),
+		FP: values.Get("fp"),
+	}
+
+	receipt.Time, err = parseReceiptTime(values.Get("t"))
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	receipt.Sum, err = strconv.ParseFloat(strings.Replace(values.Get("s"), ",", ".", 1), 64)
+	if err != nil || receipt.Sum <= 0 {
+		return Receipt{}, fmt.Errorf("invalid receipt sum %s", values.Get("s"))
+	}
+
+	n, err := strconv.Atoi(values.Get("n"))
+	if err != nil || n < int(ReceiptIncome) || n > int(ReceiptExpenseReturn) {
+		return Receipt{}, fmt.Errorf("invalid receipt type %s", values.Get("n"))
+	}
+	receipt.Type = ReceiptType(n)
+
+	return receipt, nil
+}
+
+func parseReceiptTime(s string) (time.Time, error) {
+	for _, layout := range []string{"20060102T1504", "20060102T150405"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid receipt time %s", s)
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReceiptQR(t *testing.T) {
+	receipt, err := ParseReceiptQR("t=20220501T1830&s=1234.50&fn=9289000100123456&i=12345&fp=1234567890&n=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Receipt{
+		Time: time.Date(2022, 5, 1, 18, 30, 0, 0, time.UTC),
+		Sum:  1234.5,
+		FN:   "9289000100123456",
+		FD:   "12345",
+		FP:   "1234567890",
+		Type: ReceiptIncome,
+	}
+	if receipt != want {
+		t.Errorf("parsed %+v, want %+v", receipt, want)
+	}
+	if receipt.Key() != "9289000100123456:12345:1234567890" {
+		t.Errorf("key %s", receipt.Key())
+	}
+
+	// seconds and a decimal comma are written by some cash registers
+	receipt, err = ParseReceiptQR(" t=20220501T183015&s=99,90&fn=1&i=2&fp=3&n=4\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Time.Second() != 15 || receipt.Sum != 99.9 || receipt.Type != ReceiptExpenseReturn {
+		t.Errorf("parsed %+v", receipt)
+	}
+}
+
+func TestParseReceiptQRRejectsMalformed(t *testing.T) {
+	tests := map[string]string{
+		"":                                    "t is missing",
+		"hello":                               "t is missing",
+		"t=20220501T1830&s=10&i=1&fp=2&n=1":   "fn is missing",
+		"t=20220501T1830&s=10&fn=1&i=2&fp=3":  "n is missing",
+		"t=2022-05-01&s=10&fn=1&i=2&fp=3&n=1": "invalid receipt time",
+		"t=20220501T1830&s=ten&fn=1&i=2&fp=3&n=1":   "invalid receipt sum",
+		"t=20220501T1830&s=0&fn=1&i=2&fp=3&n=1":     "invalid receipt sum",
+		"t=20220501T1830&s=-5&fn=1&i=2&fp=3&n=1":    "invalid receipt sum",
+		"t=20220501T1830&s=10&fn=1&i=2&fp=3&n=5":    "invalid receipt type",
+		"t=20220501T1830&s=10&fn=1&i=2&fp=3&n=x":    "invalid receipt type",
+		"t=20220501T1830&s=10%zz&fn=1&i=2&fp=3&n=1": "invalid receipt QR code",
+	}
+	for s, want := range tests {
+		_, err := ParseReceiptQR(s)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseReceiptQR(%q) err %v, want %s", s, err, want)
+		}
+	}
+}
+
+func TestIsReceiptQR(t *testing.T) {
+	tests := map[string]bool{
+		"t=20220501T1830&s=1234.50&fn=9289000100123456&i=12345&fp=1234567890&n=1": true,
+		" t=20220501T1830&s=1&fn=1&i=2&fp=3&n=1\n":                                true,
+		"coffee 300":                     false,
+		"t=20220501T1830&s=1":            false,
+		"t=20220501T1830 &fn=1&i=2&fp=3": false,
+		"https://example.com/?t=1&fn=2":  false,
+	}
+	for s, want := range tests {
+		if got := IsReceiptQR(s); got != want {
+			t.Errorf("IsReceiptQR(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package entity
+
+// StorageError is a failure of the database rather than of the request, e.g. a full disk or a corrupted value,
+// such errors are unexpected and are reported instead of being shown to users
+type StorageError struct {
+	Err error
+}
+
+func (e StorageError) Error() string {
+	return "storage: " + e.Err.Error()
+}
+
+func (e StorageError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+var hashtagRegexp = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// TagSummary sums transactions of a tag in one currency
+type TagSummary struct {
+	Tag      string
+	Currency string
+	Count    int
+	Total    float64
+}
+
+// ExtractTags returns lowercased hashtags from text without the leading "#", in order of appearance
+func ExtractTags(text string) []string {
+	var tags []string
+	seen := make(map[string]struct{})
+
+	for _, match := range hashtagRegexp.FindAllStringSubmatch(text, -1) {
+		tag := NormalizeTag(match[1])
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func (t Transaction) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, own := range t.Tags {
+		if own == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"coffee #Work", []string{"work"}},
+		{"#food, #drinks. #home!", []string{"food", "drinks", "home"}},
+		// tags are compared lowercased and returned once, in order of appearance
+		{"#Trip #food #TRIP #trip", []string{"trip", "food"}},
+		{"#Отпуск2022 в Сочи #отпуск2022 #Ёлка", []string{"отпуск2022", "ёлка"}},
+		{"#spring_sale-2022", []string{"spring_sale"}},
+		{"##double #", []string{"double"}},
+		{"no tags here # ok", nil},
+		{"#☕ #-", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := ExtractTags(tt.text)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") || len(got) != len(tt.want) {
+			t.Errorf("%q: tags %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHasTag(t *testing.T) {
+	transaction := Transaction{Tags: ExtractTags("pharmacy #Health")}
+
+	for _, tag := range []string{"health", "#health", "HEALTH", "#Health"} {
+		if !transaction.HasTag(tag) {
+			t.Errorf("transaction has no tag %q", tag)
+		}
+	}
+	if transaction.HasTag("heal") {
+		t.Errorf("a prefix of the tag matches")
+	}
+}
@@ -1,6 +1,14 @@
 package entity
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var TransactionNotFoundErr = errors.New("transaction not found")
+
+// DefaultCurrency is the currency of transactions which don't specify one
+const DefaultCurrency = "RUB"
 
 type Transaction struct {
 	ID          uint64    `json:"id"`
@@ -9,4 +17,16 @@ type Transaction struct {
 	ToAccount   string    `json:"to_account"`
 	Amount      float64   `json:"amount"`
 	Description string    `json:"description"`
+	Tags        []string  `json:"tags,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+
+	// Receipt holds fiscal fields of transactions entered from a cash receipt
+	Receipt *Receipt `json:"receipt,omitempty"`
+}
+
+func (t Transaction) CurrencyCode() string {
+	if t.Currency == "" {
+		return DefaultCurrency
+	}
+	return t.Currency
 }
@@ -15,6 +15,60 @@ const (
 	CreateTransactionState = "createTransaction"
 
 	ShowTransactionState = "showTransaction"
+
+	SearchTransactionsState = "searchTransactions"
+
+	ListTagsState = "listTags"
+
+	ListGoalsState = "listGoals"
+
+	CreateGoalState = "createGoal"
+
+	ListAccountsState = "listAccounts"
+
+	SetAccountTypeState = "setAccountType"
+
+	NetWorthState = "netWorth"
+
+	ForecastState = "forecast"
+
+	SetAccountFloorState = "setAccountFloor"
+
+	ImportFileState = "importFile"
+
+	ImportCSVState = "importCSV"
+
+	MapCategoriesState = "mapCategories"
+
+	ImportQIFState = "importQIF"
+
+	ExportState = "export"
+
+	ReceiptState = "receipt"
+
+	CreateReceiptTransactionState = "createReceiptTransaction"
+
+	NotificationState = "notification"
+
+	CreateNotificationTransactionState = "createNotificationTransaction"
+
+	NotificationTemplateState = "notificationTemplate"
+
+	ListNotificationTemplatesState = "listNotificationTemplates"
+
+	BackupState = "backup"
+
+	InspectBackupState = "inspectBackup"
+
+	RestoreBackupState = "restoreBackup"
+
+	CancelState = "cancel"
+
+	DuplicatesState = "duplicates"
+
+	DeleteDuplicateState = "deleteDuplicate"
+
+	DismissDuplicateState = "dismissDuplicate"
 )
 
 type UserState struct {
@@ -26,6 +80,22 @@ type UserState struct {
 	MessageID *int  `json:"messageID,omitempty"`
 
 	Date *time.Time `json:"date,omitempty"`
+	Tags []string   `json:"tags,omitempty"`
 
 	TransactionID *uint64 `json:"transactionID,omitempty"`
+
+	Query string `json:"query,omitempty"`
+	Page  int    `json:"page,omitempty"`
+
+	FileID   string `json:"fileID,omitempty"`
+	FileName string `json:"fileName,omitempty"`
+
+	// ReceiptQR is the content of a receipt QR code sent as text, receipts sent as photos are kept in FileID
+	ReceiptQR string `json:"receiptQR,omitempty"`
+
+	// Notification is the text of a forwarded bank notification, Date is when the bank sent it
+	Notification string `json:"notification,omitempty"`
+
+	// SkipDuplicateCheck is set when the user chose to save a transaction which looks like a duplicate
+	SkipDuplicateCheck bool `json:"skipDuplicateCheck,omitempty"`
 }
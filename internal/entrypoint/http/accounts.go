@@ -0,0 +1,117 @@
+package http
+
+import (
+	"encoding/json"
+	"math"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+
+	"enigma/internal/entity"
+)
+
+type accountResponse struct {
+	Name  string             `json:"name"`
+	Type  entity.AccountType `json:"type"`
+	Floor *float64           `json:"floor"`
+}
+
+func newAccountResponse(account entity.Account) accountResponse {
+	return accountResponse{Name: account.Name, Type: account.Type, Floor: account.Floor}
+}
+
+// accountRequest changes only the fields present, a null floor removes it
+type accountRequest struct {
+	Type  *string         `json:"type"`
+	Floor json.RawMessage `json:"floor"`
+}
+
+func (s *Server) listAccounts(w nethttp.ResponseWriter, r *nethttp.Request) {
+	accounts, err := s.getAccountsUsecase.Execute(r.Context())
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	responses := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		responses = append(responses, newAccountResponse(account))
+	}
+
+	writeJSON(w, nethttp.StatusOK, struct {
+		Accounts []accountResponse `json:"accounts"`
+	}{responses})
+}
+
+func (s *Server) updateAccount(w nethttp.ResponseWriter, r *nethttp.Request) {
+	// account names may contain slashes, so the name is taken from the escaped path
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/api/accounts/"))
+	if err != nil || strings.TrimSpace(name) == "" {
+		writeError(w, nethttp.StatusNotFound, "not_found", "no such endpoint "+r.URL.Path)
+		return
+	}
+
+	var request accountRequest
+	err = decodeBody(w, r, &request)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	invalid := validationErrors{}
+
+	var accountType entity.AccountType
+	if request.Type != nil && *request.Type != "" {
+		accountType, err = entity.ParseAccountType(*request.Type)
+		if err != nil {
+			invalid.add("type", err.Error())
+		}
+	}
+
+	var floor *float64
+	if request.Floor != nil && string(request.Floor) != "null" {
+		err = json.Unmarshal(request.Floor, &floor)
+		if err != nil || math.IsNaN(*floor) || math.IsInf(*floor, 0) {
+			invalid.add("floor", "must be a number or null")
+		}
+	}
+
+	if request.Type == nil && request.Floor == nil {
+		invalid.add("body", "type or floor is required")
+	}
+
+	if len(invalid) > 0 {
+		writeUsecaseError(w, invalid)
+		return
+	}
+
+	if request.Type != nil {
+		err = s.setAccountTypeUsecase.Execute(r.Context(), name, accountType)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+	}
+
+	if request.Floor != nil {
+		err = s.setAccountFloorUsecase.Execute(r.Context(), name, floor)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+	}
+
+	accounts, err := s.getAccountsUsecase.Execute(r.Context())
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	for _, account := range accounts {
+		if account.Name == name {
+			writeJSON(w, nethttp.StatusOK, newAccountResponse(account))
+			return
+		}
+	}
+	writeUsecaseError(w, entity.AccountNotFoundErr)
+}
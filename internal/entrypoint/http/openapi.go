@@ -0,0 +1,14 @@
+package http
+
+import (
+	_ "embed"
+	nethttp "net/http"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+func serveOpenAPI(w nethttp.ResponseWriter, _ *nethttp.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	nethttp "net/http"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+type balanceResponse struct {
+	Account  string             `json:"account"`
+	Type     entity.AccountType `json:"type"`
+	Currency string             `json:"currency"`
+	Balance  float64            `json:"balance"`
+}
+
+// balances returns balances of accounts at the end of date, today by default
+func (s *Server) balances(w nethttp.ResponseWriter, r *nethttp.Request) {
+	date := time.Now().UTC()
+	if value := r.URL.Query().Get("date"); value != "" {
+		var err error
+		date, err = parseDate(value)
+		if err != nil {
+			writeUsecaseError(w, validationErrors{"date": err.Error()})
+			return
+		}
+	}
+
+	balances, err := s.getBalancesUsecase.Execute(r.Context(), date)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	responses := make([]balanceResponse, 0, len(balances))
+	for _, b := range balances {
+		responses = append(responses, balanceResponse{
+			Account:  b.Account.Name,
+			Type:     b.Account.Type,
+			Currency: b.Currency,
+			Balance:  b.Balance,
+		})
+	}
+
+	writeJSON(w, nethttp.StatusOK, struct {
+		Date     string            `json:"date"`
+		Balances []balanceResponse `json:"balances"`
+	}{date.Format("2006-01-02"), responses})
+}
+
+func (s *Server) tagsReport(w nethttp.ResponseWriter, r *nethttp.Request) {
+	tags, err := s.getTagsUsecase.Execute(r.Context())
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	type tagResponse struct {
+		Tag      string  `json:"tag"`
+		Currency string  `json:"currency"`
+		Count    int     `json:"count"`
+		Total    float64 `json:"total"`
+	}
+
+	responses := make([]tagResponse, 0, len(tags))
+	for _, tag := range tags {
+		responses = append(responses, tagResponse{Tag: tag.Tag, Currency: tag.Currency, Count: tag.Count, Total: tag.Total})
+	}
+
+	writeJSON(w, nethttp.StatusOK, struct {
+		Tags []tagResponse `json:"tags"`
+	}{responses})
+}
+
+func (s *Server) netWorthReport(w nethttp.ResponseWriter, r *nethttp.Request) {
+	invalid := validationErrors{}
+	months := intParam(r.URL.Query().Get("months"), 12, 1, 1200, "months", invalid)
+	if len(invalid) > 0 {
+		writeUsecaseError(w, invalid)
+		return
+	}
+
+	points, err := s.getNetWorthHistoryUsecase.Execute(r.Context(), months)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	type pointResponse struct {
+		Month       string             `json:"month"`
+		Assets      float64            `json:"assets"`
+		Liabilities float64            `json:"liabilities"`
+		NetWorth    float64            `json:"net_worth"`
+		Other       map[string]float64 `json:"other_currencies"`
+	}
+
+	responses := make([]pointResponse, 0, len(points))
+	for _, p := range points {
+		responses = append(responses, pointResponse{
+			Month:       p.Month.Format("2006-01"),
+			Assets:      p.Assets,
+			Liabilities: p.Liabilities,
+			NetWorth:    p.NetWorth(),
+			Other:       p.Other,
+		})
+	}
+
+	writeJSON(w, nethttp.StatusOK, struct {
+		Months []pointResponse `json:"months"`
+	}{responses})
+}
+
+func (s *Server) forecastReport(w nethttp.ResponseWriter, r *nethttp.Request) {
+	invalid := validationErrors{}
+	days := intParam(r.URL.Query().Get("days"), 30, 1, 366, "days", invalid)
+	if len(invalid) > 0 {
+		writeUsecaseError(w, invalid)
+		return
+	}
+
+	forecasts, err := s.forecastUsecase.Execute(r.Context(), days)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	type pointResponse struct {
+		Date    string  `json:"date"`
+		Balance float64 `json:"balance"`
+	}
+
+	type forecastResponse struct {
+		Account       string          `json:"account"`
+		Currency      string          `json:"currency"`
+		Balance       float64         `json:"balance"`
+		DailySpending float64         `json:"daily_spending"`
+		Points        []pointResponse `json:"points"`
+		BelowZeroAt   *string         `json:"below_zero_at"`
+		BelowFloorAt  *string         `json:"below_floor_at"`
+	}
+
+	formatDate := func(date *time.Time) *string {
+		if date == nil {
+			return nil
+		}
+		s := date.Format("2006-01-02")
+		return &s
+	}
+
+	responses := make([]forecastResponse, 0, len(forecasts))
+	for _, f := range forecasts {
+		response := forecastResponse{
+			Account:       f.Account.Name,
+			Currency:      f.Currency,
+			Balance:       f.Balance,
+			DailySpending: f.DailySpending,
+			Points:        make([]pointResponse, 0, len(f.Points)),
+			BelowZeroAt:   formatDate(f.BelowZeroAt),
+			BelowFloorAt:  formatDate(f.BelowFloorAt),
+		}
+		for _, p := range f.Points {
+			response.Points = append(response.Points, pointResponse{Date: p.Date.Format("2006-01-02"), Balance: p.Balance})
+		}
+		responses = append(responses, response)
+	}
+
+	writeJSON(w, nethttp.StatusOK, struct {
+		Days      int                `json:"days"`
+		Forecasts []forecastResponse `json:"forecasts"`
+	}{days, responses})
+}
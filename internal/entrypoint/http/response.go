@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"sort"
+	"strings"
+
+	"enigma/internal/entity"
+	"enigma/internal/logger"
+)
+
+// maxBodySize limits request bodies, the largest one is a single transaction
+const maxBodySize = 1 << 20
+
+// errorBody is the body of every failed response
+type errorBody struct {
+	Error errorDetails `json:"error"`
+}
+
+type errorDetails struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// Fields maps invalid fields of the request to what is wrong with them
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// Duplicates are saved transactions the new one is likely a duplicate of
+	Duplicates []transactionResponse `json:"duplicates,omitempty"`
+}
+
+// validationErrors collects problems of a request, so all of them are reported at once
+type validationErrors map[string]string
+
+func (v validationErrors) add(field, message string) {
+	if _, ok := v[field]; !ok {
+		v[field] = message
+	}
+}
+
+func (v validationErrors) Error() string {
+	fields := make([]string, 0, len(v))
+	for field, message := range v {
+		fields = append(fields, field+": "+message)
+	}
+	sort.Strings(fields)
+	return "invalid request: " + strings.Join(fields, ", ")
+}
+
+func writeJSON(w nethttp.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		logger.Default().Warn("write the response", "err", err)
+	}
+}
+
+func writeError(w nethttp.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorBody{Error: errorDetails{Code: code, Message: message}})
+}
+
+// writeUsecaseError maps errors of usecases to statuses, unexpected errors are logged and not exposed
+func writeUsecaseError(w nethttp.ResponseWriter, err error) {
+	var invalid validationErrors
+	switch {
+	case errors.As(err, &invalid):
+		writeJSON(w, nethttp.StatusBadRequest, errorBody{Error: errorDetails{
+			Code:    "invalid_request",
+			Message: invalid.Error(),
+			Fields:  invalid,
+		}})
+	case errors.Is(err, entity.TransactionNotFoundErr), errors.Is(err, entity.AccountNotFoundErr):
+		writeError(w, nethttp.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, entity.ReceiptExistsErr):
+		writeError(w, nethttp.StatusConflict, "receipt_exists", err.Error())
+	default:
+		logger.Default().Error("API request failed", "err", err)
+		writeError(w, nethttp.StatusInternalServerError, "internal_error", "internal error")
+	}
+}
+
+// decodeBody reads a JSON body into v rejecting unknown fields, which are usually typos
+func decodeBody(w nethttp.ResponseWriter, r *nethttp.Request, v interface{}) error {
+	decoder := json.NewDecoder(nethttp.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return validationErrors{"body": err.Error()}
+	}
+
+	if decoder.Decode(&struct{}{}) != io.EOF {
+		return validationErrors{"body": "must contain a single JSON object"}
+	}
+
+	return nil
+}
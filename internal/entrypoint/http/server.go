@@ -0,0 +1,155 @@
+// Package http serves a JSON API over the same usecases as the Telegram bot
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	nethttp "net/http"
+	"sort"
+	"strings"
+
+	"enigma/internal/httpserver"
+	"enigma/internal/usecase"
+)
+
+type Server struct {
+	*httpserver.Server
+	token string
+
+	createTransactionUsecase  *usecase.CreateTransaction
+	getTransactionByID        *usecase.GetTransactionByID
+	updateTransactionUsecase  *usecase.UpdateTransaction
+	deleteTransactionUsecase  *usecase.DeleteTransaction
+	searchTransactionsUsecase *usecase.SearchTransactions
+	exportTransactionsUsecase *usecase.ExportTransactions
+	findDuplicatesUsecase     *usecase.FindDuplicates
+
+	getAccountsUsecase     *usecase.GetAccounts
+	setAccountTypeUsecase  *usecase.SetAccountType
+	setAccountFloorUsecase *usecase.SetAccountFloor
+	getBalancesUsecase     *usecase.GetBalances
+
+	getTagsUsecase            *usecase.GetTags
+	getNetWorthHistoryUsecase *usecase.GetNetWorthHistory
+	forecastUsecase           *usecase.Forecast
+}
+
+func New(
+	addr string,
+	token string,
+	createTransactionUsecase *usecase.CreateTransaction,
+	getTransactionByID *usecase.GetTransactionByID,
+	updateTransactionUsecase *usecase.UpdateTransaction,
+	deleteTransactionUsecase *usecase.DeleteTransaction,
+	searchTransactionsUsecase *usecase.SearchTransactions,
+	exportTransactionsUsecase *usecase.ExportTransactions,
+	findDuplicatesUsecase *usecase.FindDuplicates,
+	getAccountsUsecase *usecase.GetAccounts,
+	setAccountTypeUsecase *usecase.SetAccountType,
+	setAccountFloorUsecase *usecase.SetAccountFloor,
+	getBalancesUsecase *usecase.GetBalances,
+	getTagsUsecase *usecase.GetTags,
+	getNetWorthHistoryUsecase *usecase.GetNetWorthHistory,
+	forecastUsecase *usecase.Forecast,
+) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("API token is required")
+	}
+
+	s := &Server{
+		token: token,
+
+		createTransactionUsecase:  createTransactionUsecase,
+		getTransactionByID:        getTransactionByID,
+		updateTransactionUsecase:  updateTransactionUsecase,
+		deleteTransactionUsecase:  deleteTransactionUsecase,
+		searchTransactionsUsecase: searchTransactionsUsecase,
+		exportTransactionsUsecase: exportTransactionsUsecase,
+		findDuplicatesUsecase:     findDuplicatesUsecase,
+
+		getAccountsUsecase:     getAccountsUsecase,
+		setAccountTypeUsecase:  setAccountTypeUsecase,
+		setAccountFloorUsecase: setAccountFloorUsecase,
+		getBalancesUsecase:     getBalancesUsecase,
+
+		getTagsUsecase:            getTagsUsecase,
+		getNetWorthHistoryUsecase: getNetWorthHistoryUsecase,
+		forecastUsecase:           forecastUsecase,
+	}
+
+	s.Server = httpserver.New("the API", addr, s.Handler())
+
+	return s, nil
+}
+
+// Handler routes API requests, everything except the OpenAPI document requires the bearer token
+func (s *Server) Handler() nethttp.Handler {
+	api := nethttp.NewServeMux()
+	api.HandleFunc("/api/transactions", methods{
+		nethttp.MethodGet:  s.listTransactions,
+		nethttp.MethodPost: s.createTransaction,
+	}.handle)
+	api.HandleFunc("/api/transactions/", methods{
+		nethttp.MethodGet:    s.getTransaction,
+		nethttp.MethodPut:    s.updateTransaction,
+		nethttp.MethodDelete: s.deleteTransaction,
+	}.handle)
+	api.HandleFunc("/api/accounts", methods{
+		nethttp.MethodGet: s.listAccounts,
+	}.handle)
+	api.HandleFunc("/api/accounts/", methods{
+		nethttp.MethodPatch: s.updateAccount,
+	}.handle)
+	api.HandleFunc("/api/balances", methods{
+		nethttp.MethodGet: s.balances,
+	}.handle)
+	api.HandleFunc("/api/reports/tags", methods{
+		nethttp.MethodGet: s.tagsReport,
+	}.handle)
+	api.HandleFunc("/api/reports/networth", methods{
+		nethttp.MethodGet: s.netWorthReport,
+	}.handle)
+	api.HandleFunc("/api/reports/forecast", methods{
+		nethttp.MethodGet: s.forecastReport,
+	}.handle)
+	api.HandleFunc("/", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		writeError(w, nethttp.StatusNotFound, "not_found", "no such endpoint "+r.URL.Path)
+	})
+
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("/api/openapi.json", methods{
+		nethttp.MethodGet: serveOpenAPI,
+	}.handle)
+	mux.Handle("/", s.authenticate(api))
+	return mux
+}
+
+func (s *Server) authenticate(next nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="enigma"`)
+			writeError(w, nethttp.StatusUnauthorized, "unauthorized", "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// methods dispatches a request by its method, the standard mux only routes by path
+type methods map[string]nethttp.HandlerFunc
+
+func (m methods) handle(w nethttp.ResponseWriter, r *nethttp.Request) {
+	handler, ok := m[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(m))
+		for method := range m {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, nethttp.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed for "+r.URL.Path)
+		return
+	}
+	handler(w, r)
+}
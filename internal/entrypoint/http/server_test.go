@@ -0,0 +1,257 @@
+package http_test
+
+import (
+	"encoding/json"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"enigma/internal/entrypoint/http"
+	"enigma/internal/usecase"
+	"enigma/internal/usecase/repository/account"
+	"enigma/internal/usecase/repository/networth"
+	"enigma/internal/usecase/repository/outbox"
+	"enigma/internal/usecase/repository/transaction"
+	"enigma/internal/usecase/repository/webhook"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const token = "secret-token"
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "enigma.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = outbox.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookRepository, err := webhook.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionRepository, err := transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountRepository, err := account.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	netWorthRepository, err := networth.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishEvents := usecase.NewPublishEvents(webhookRepository)
+	server, err := http.New(
+		"", token,
+		usecase.NewCreateTransaction(transactionRepository, publishEvents),
+		usecase.NewGetTransactionByID(transactionRepository),
+		usecase.NewUpdateTransaction(transactionRepository, netWorthRepository, publishEvents),
+		usecase.NewDeleteTransaction(transactionRepository, netWorthRepository, publishEvents),
+		usecase.NewSearchTransactions(transactionRepository),
+		usecase.NewExportTransactions(transactionRepository),
+		usecase.NewFindDuplicates(transactionRepository),
+		usecase.NewGetAccounts(accountRepository, transactionRepository),
+		usecase.NewSetAccountType(accountRepository),
+		usecase.NewSetAccountFloor(accountRepository),
+		usecase.NewGetBalances(accountRepository, transactionRepository),
+		usecase.NewGetTags(transactionRepository),
+		usecase.NewGetNetWorthHistory(transactionRepository, accountRepository, netWorthRepository),
+		usecase.NewForecast(transactionRepository, accountRepository),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// do sends the request with the token and decodes the JSON response into v unless it is nil
+func do(t *testing.T, ts *httptest.Server, method, path, body string, v interface{}) *nethttp.Response {
+	t.Helper()
+
+	request, err := nethttp.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := ts.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if v != nil {
+		err = json.NewDecoder(response.Body).Decode(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		_, _ = io.Copy(io.Discard, response.Body)
+	}
+	return response
+}
+
+type errorResponse struct {
+	Error struct {
+		Code       string            `json:"code"`
+		Fields     map[string]string `json:"fields"`
+		Duplicates []struct {
+			ID uint64 `json:"id"`
+		} `json:"duplicates"`
+	} `json:"error"`
+}
+
+func TestAuthentication(t *testing.T) {
+	ts := newServer(t)
+
+	tests := map[string]int{
+		"":                      nethttp.StatusUnauthorized,
+		token:                   nethttp.StatusUnauthorized,
+		"Basic " + token:        nethttp.StatusUnauthorized,
+		"Bearer":                nethttp.StatusUnauthorized,
+		"Bearer wrong":          nethttp.StatusUnauthorized,
+		"Bearer " + token + "x": nethttp.StatusUnauthorized,
+		"Bearer " + token:       nethttp.StatusOK,
+		"bearer " + token:       nethttp.StatusOK,
+	}
+	for header, want := range tests {
+		request, err := nethttp.NewRequest(nethttp.MethodGet, ts.URL+"/api/accounts", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header != "" {
+			request.Header.Set("Authorization", header)
+		}
+
+		response, err := ts.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != want {
+			t.Errorf("Authorization %q: status %d, want %d", header, response.StatusCode, want)
+		}
+		if want == nethttp.StatusUnauthorized && response.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: no WWW-Authenticate header", header)
+		}
+	}
+
+	// the OpenAPI document is public
+	response, err := ts.Client().Get(ts.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != nethttp.StatusOK {
+		t.Errorf("OpenAPI document status %d, want %d", response.StatusCode, nethttp.StatusOK)
+	}
+}
+
+func TestCreateTransaction(t *testing.T) {
+	ts := newServer(t)
+
+	var created struct {
+		ID       uint64   `json:"id"`
+		Amount   float64  `json:"amount"`
+		Currency string   `json:"currency"`
+		Tags     []string `json:"tags"`
+	}
+	body := `{"date": "2022-05-01", "from_account": "card", "to_account": "cafe", "amount": 300, "description": "Coffee #work"}`
+	response := do(t, ts, nethttp.MethodPost, "/api/transactions", body, &created)
+	if response.StatusCode != nethttp.StatusCreated {
+		t.Fatalf("status %d, want %d", response.StatusCode, nethttp.StatusCreated)
+	}
+	if created.ID == 0 || created.Amount != 300 || created.Currency != "RUB" || len(created.Tags) != 1 || created.Tags[0] != "work" {
+		t.Errorf("created %+v", created)
+	}
+	if location := response.Header.Get("Location"); location != "/api/transactions/1" {
+		t.Errorf("Location %s", location)
+	}
+
+	// the same coffee booked by the bank a day later
+	var conflict errorResponse
+	body = `{"date": "2022-05-02", "from_account": "card", "to_account": "cafe", "amount": 300, "description": "COFFEE #WORK"}`
+	response = do(t, ts, nethttp.MethodPost, "/api/transactions", body, &conflict)
+	if response.StatusCode != nethttp.StatusConflict || conflict.Error.Code != "duplicate" ||
+		len(conflict.Error.Duplicates) != 1 || conflict.Error.Duplicates[0].ID != created.ID {
+		t.Errorf("status %d, body %+v, want a conflict with the first transaction", response.StatusCode, conflict)
+	}
+
+	body = `{"date": "2022-05-02", "from_account": "card", "to_account": "cafe", "amount": 300, "description": "COFFEE #WORK", "allow_duplicate": true}`
+	response = do(t, ts, nethttp.MethodPost, "/api/transactions", body, nil)
+	if response.StatusCode != nethttp.StatusCreated {
+		t.Errorf("status %d of an allowed duplicate, want %d", response.StatusCode, nethttp.StatusCreated)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	ts := newServer(t)
+
+	var invalid errorResponse
+	body := `{"date": "yesterday", "from_account": " ", "to_account": "cafe", "amount": -5, "currency": "euro"}`
+	response := do(t, ts, nethttp.MethodPost, "/api/transactions", body, &invalid)
+	if response.StatusCode != nethttp.StatusBadRequest || invalid.Error.Code != "invalid_request" {
+		t.Fatalf("status %d, body %+v, want invalid_request", response.StatusCode, invalid)
+	}
+	for _, field := range []string{"date", "from_account", "amount", "currency"} {
+		if invalid.Error.Fields[field] == "" {
+			t.Errorf("%s is not reported, fields %v", field, invalid.Error.Fields)
+		}
+	}
+	if _, ok := invalid.Error.Fields["to_account"]; ok {
+		t.Errorf("valid to_account is reported")
+	}
+
+	for _, path := range []string{"/api/transactions?limit=0", "/api/transactions?period=someday"} {
+		response = do(t, ts, nethttp.MethodGet, path, "", nil)
+		if response.StatusCode != nethttp.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", path, response.StatusCode, nethttp.StatusBadRequest)
+		}
+	}
+
+	response = do(t, ts, nethttp.MethodPost, "/api/transactions", `{"amount": `, nil)
+	if response.StatusCode != nethttp.StatusBadRequest {
+		t.Errorf("malformed JSON: status %d, want %d", response.StatusCode, nethttp.StatusBadRequest)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	ts := newServer(t)
+
+	tests := map[string]int{
+		nethttp.MethodGet + " /api/transactions/42":    nethttp.StatusNotFound,
+		nethttp.MethodDelete + " /api/transactions/42": nethttp.StatusNotFound,
+		nethttp.MethodGet + " /api/transactions/abc":   nethttp.StatusNotFound,
+		nethttp.MethodGet + " /api/nothing":            nethttp.StatusNotFound,
+		nethttp.MethodPut + " /api/transactions":       nethttp.StatusMethodNotAllowed,
+	}
+	for request, want := range tests {
+		method, path, _ := strings.Cut(request, " ")
+
+		var body errorResponse
+		response := do(t, ts, method, path, "", &body)
+		if response.StatusCode != want {
+			t.Errorf("%s: status %d, want %d", request, response.StatusCode, want)
+		}
+		if want == nethttp.StatusNotFound && body.Error.Code != "not_found" {
+			t.Errorf("%s: code %s, want not_found", request, body.Error.Code)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package http
+
+import (
+	"errors"
+	"math"
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+var invalidDateErr = errors.New("must be a date like 2022-05-01 or an RFC 3339 time")
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+
+	maxAccountLength     = 200
+	maxDescriptionLength = 1000
+)
+
+type transactionResponse struct {
+	ID          uint64          `json:"id"`
+	Date        time.Time       `json:"date"`
+	FromAccount string          `json:"from_account"`
+	ToAccount   string          `json:"to_account"`
+	Amount      float64         `json:"amount"`
+	Currency    string          `json:"currency"`
+	Description string          `json:"description"`
+	Tags        []string        `json:"tags"`
+	Receipt     *entity.Receipt `json:"receipt,omitempty"`
+}
+
+func newTransactionResponse(t entity.Transaction) transactionResponse {
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return transactionResponse{
+		ID:          t.ID,
+		Date:        t.Date,
+		FromAccount: t.FromAccount,
+		ToAccount:   t.ToAccount,
+		Amount:      t.Amount,
+		Currency:    t.CurrencyCode(),
+		Description: t.Description,
+		Tags:        tags,
+		Receipt:     t.Receipt,
+	}
+}
+
+func newTransactionResponses(transactions []entity.Transaction) []transactionResponse {
+	responses := make([]transactionResponse, 0, len(transactions))
+	for _, t := range transactions {
+		responses = append(responses, newTransactionResponse(t))
+	}
+	return responses
+}
+
+type transactionRequest struct {
+	// Date is "2006-01-02" or RFC 3339, the current time if empty
+	Date        string   `json:"date"`
+	FromAccount string   `json:"from_account"`
+	ToAccount   string   `json:"to_account"`
+	Amount      *float64 `json:"amount"`
+	Currency    string   `json:"currency"`
+	Description string   `json:"description"`
+
+	// AllowDuplicate saves the transaction even if it looks like one saved before
+	AllowDuplicate bool `json:"allow_duplicate"`
+}
+
+func (r transactionRequest) transaction() (entity.Transaction, error) {
+	invalid := validationErrors{}
+
+	transaction := entity.Transaction{
+		Date:        time.Now().UTC(),
+		FromAccount: strings.TrimSpace(r.FromAccount),
+		ToAccount:   strings.TrimSpace(r.ToAccount),
+		Currency:    strings.ToUpper(strings.TrimSpace(r.Currency)),
+		Description: strings.TrimSpace(r.Description),
+	}
+
+	if r.Date != "" {
+		date, err := parseDate(r.Date)
+		if err != nil {
+			invalid.add("date", err.Error())
+		}
+		transaction.Date = date
+	}
+
+	for field, account := range map[string]string{"from_account": transaction.FromAccount, "to_account": transaction.ToAccount} {
+		switch {
+		case account == "":
+			invalid.add(field, "is required")
+		case len(account) > maxAccountLength:
+			invalid.add(field, "is longer than "+strconv.Itoa(maxAccountLength)+" bytes")
+		}
+	}
+
+	switch {
+	case r.Amount == nil:
+		invalid.add("amount", "is required")
+	case math.IsNaN(*r.Amount) || math.IsInf(*r.Amount, 0) || *r.Amount <= 0:
+		invalid.add("amount", "must be a positive number")
+	default:
+		transaction.Amount = *r.Amount
+	}
+
+	if transaction.Currency != "" && !isCurrencyCode(transaction.Currency) {
+		invalid.add("currency", "must be a three-letter currency code")
+	}
+	if transaction.Currency == entity.DefaultCurrency {
+		transaction.Currency = ""
+	}
+
+	if len(transaction.Description) > maxDescriptionLength {
+		invalid.add("description", "is longer than "+strconv.Itoa(maxDescriptionLength)+" bytes")
+	}
+
+	if len(invalid) > 0 {
+		return entity.Transaction{}, invalid
+	}
+
+	return transaction, nil
+}
+
+// parseDate accepts dates and times, times are converted to UTC like every transaction date
+func parseDate(s string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		date, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, invalidDateErr
+		}
+	}
+
+	date = date.UTC()
+	if date.Year() < 1 || date.Year() > 9999 {
+		return time.Time{}, invalidDateErr
+	}
+	return date, nil
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// listTransactions returns transactions matching the search query q within period, newest first
+func (s *Server) listTransactions(w nethttp.ResponseWriter, r *nethttp.Request) {
+	query := r.URL.Query()
+	invalid := validationErrors{}
+
+	filter, err := entity.ParseTransactionFilter(query.Get("q"))
+	if err != nil {
+		invalid.add("q", err.Error())
+	}
+
+	if period := query.Get("period"); period != "" {
+		since, until, err := entity.ParsePeriod(period)
+		if err != nil {
+			invalid.add("period", err.Error())
+		}
+		filter.Since, filter.Until = &since, &until
+	}
+	if filter.IsEmpty() {
+		since, until, _ := entity.ParsePeriod("all")
+		filter.Since, filter.Until = &since, &until
+	}
+
+	offset := intParam(query.Get("offset"), 0, 0, math.MaxInt32, "offset", invalid)
+	limit := intParam(query.Get("limit"), defaultPageSize, 1, maxPageSize, "limit", invalid)
+
+	if len(invalid) > 0 {
+		writeUsecaseError(w, invalid)
+		return
+	}
+
+	transactions, total, err := s.searchTransactionsUsecase.Execute(r.Context(), filter, offset, limit)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, struct {
+		Transactions []transactionResponse `json:"transactions"`
+		Total        int                   `json:"total"`
+		Offset       int                   `json:"offset"`
+		Limit        int                   `json:"limit"`
+	}{newTransactionResponses(transactions), total, offset, limit})
+}
+
+func (s *Server) createTransaction(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var request transactionRequest
+	err := decodeBody(w, r, &request)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	transaction, err := request.transaction()
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	if !request.AllowDuplicate {
+		duplicates, err := s.findDuplicatesUsecase.Execute(r.Context(), transaction)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+
+		if len(duplicates) > 0 {
+			writeJSON(w, nethttp.StatusConflict, errorBody{Error: errorDetails{
+				Code:       "duplicate",
+				Message:    "the transaction looks like one saved before, set allow_duplicate to save it anyway",
+				Duplicates: newTransactionResponses(duplicates),
+			}})
+			return
+		}
+	}
+
+	transaction, err = s.createTransactionUsecase.Execute(r.Context(), transaction)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/transactions/"+strconv.FormatUint(transaction.ID, 10))
+	writeJSON(w, nethttp.StatusCreated, newTransactionResponse(transaction))
+}
+
+func (s *Server) getTransaction(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	transaction, err := s.getTransactionByID.Execute(r.Context(), id)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, newTransactionResponse(transaction))
+}
+
+// updateTransaction replaces the transaction with the request, the date is kept if it is empty
+func (s *Server) updateTransaction(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	var request transactionRequest
+	err := decodeBody(w, r, &request)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	transaction, err := request.transaction()
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	previous, err := s.getTransactionByID.Execute(r.Context(), id)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	transaction.ID = id
+	if request.Date == "" {
+		transaction.Date = previous.Date
+	}
+
+	transaction, err = s.updateTransactionUsecase.Execute(r.Context(), transaction)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	writeJSON(w, nethttp.StatusOK, newTransactionResponse(transaction))
+}
+
+func (s *Server) deleteTransaction(w nethttp.ResponseWriter, r *nethttp.Request) {
+	id, ok := transactionID(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.deleteTransactionUsecase.Execute(r.Context(), id)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// transactionID reads the ID from paths like /api/transactions/42, an error response is written if it is invalid
+func transactionID(w nethttp.ResponseWriter, r *nethttp.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/api/transactions/"), 10, 64)
+	if err != nil || id == 0 {
+		writeError(w, nethttp.StatusNotFound, "not_found", "no such endpoint "+r.URL.Path)
+		return 0, false
+	}
+	return id, true
+}
+
+// intParam parses an optional integer query parameter within [min, max]
+func intParam(s string, def, min, max int, name string, invalid validationErrors) int {
+	if s == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || n > max {
+		invalid.add(name, "must be an integer from "+strconv.Itoa(min)+" to "+strconv.Itoa(max))
+		return def
+	}
+	return n
+}
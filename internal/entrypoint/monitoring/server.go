@@ -0,0 +1,73 @@
+// Package monitoring serves metrics and health checks of the running bot, it is meant for the internal network only
+package monitoring
+
+import (
+	"fmt"
+	"net/http"
+
+	"enigma/internal/httpserver"
+	"enigma/internal/metrics"
+)
+
+// Check is a named probe of a dependency, e.g. the database, nil means it is fine
+type Check struct {
+	Name string
+	Run  func() error
+}
+
+type Server struct {
+	*httpserver.Server
+
+	// liveness checks fail if the process should be restarted, readiness checks also fail while it can't serve
+	liveness  []Check
+	readiness []Check
+}
+
+func New(addr string, liveness, readiness []Check) *Server {
+	s := &Server{
+		liveness:  liveness,
+		readiness: append(append([]Check{}, liveness...), readiness...),
+	}
+
+	s.Server = httpserver.New("monitoring", addr, s.Handler())
+
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		runChecks(w, r, s.liveness)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		runChecks(w, r, s.readiness)
+	})
+	return mux
+}
+
+// runChecks responds 200 if every check passes and 503 otherwise, the body lists results of the checks
+func runChecks(w http.ResponseWriter, r *http.Request, checks []Check) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := http.StatusOK
+	body := ""
+	for _, check := range checks {
+		err := check.Run()
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			body += fmt.Sprintf("%s: %v\n", check.Name, err)
+			continue
+		}
+		body += check.Name + ": ok\n"
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	fmt.Fprint(w, body)
+}
@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+func (b *Bot) listAccounts(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	accounts, err := b.getAccountsUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accounts) == 0 {
+		return tgbotapi.NewMessage(state.ChatID, "No accounts yet"), nil
+	}
+
+	message := "Accounts:\n\n"
+	for _, account := range accounts {
+		accountType := string(account.Type)
+		if account.Type == entity.UnknownAccount {
+			accountType = "type not set"
+		}
+		message += fmt.Sprintf("%s: %s", account.Name, accountType)
+		if account.Floor != nil {
+			message += fmt.Sprintf(", floor %.2f RUB", *account.Floor)
+		}
+		message += "\n"
+	}
+	message += "\nSet a type with /account <name> <asset|liability|income|expense|equity>"
+	message += "\nSet a floor with /floor <name> <amount|off>"
+
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func (b *Bot) setAccountType(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	parts := strings.Fields(state.Raw)
+	if len(parts) != 2 {
+		return nil, errors.New("usage: /account <name> <asset|liability|income|expense|equity>")
+	}
+
+	accountType, err := entity.ParseAccountType(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.setAccountTypeUsecase.Execute(ctx, parts[0], accountType)
+	if err != nil {
+		return nil, err
+	}
+
+	return tgbotapi.NewMessage(state.ChatID, fmt.Sprintf("Type of %s is set to %s", parts[0], accountType)), nil
+}
+
+func (b *Bot) setAccountFloor(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	parts := strings.Fields(state.Raw)
+	if len(parts) != 2 {
+		return nil, errors.New("usage: /floor <name> <amount|off>")
+	}
+
+	var floor *float64
+	if parts[1] != "off" {
+		amount, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount %s: %w", parts[1], err)
+		}
+		floor = &amount
+	}
+
+	err := b.setAccountFloorUsecase.Execute(ctx, parts[0], floor)
+	if err != nil {
+		return nil, err
+	}
+
+	if floor == nil {
+		return tgbotapi.NewMessage(state.ChatID, fmt.Sprintf("Floor of %s is removed", parts[0])), nil
+	}
+	return tgbotapi.NewMessage(state.ChatID, fmt.Sprintf("Floor of %s is set to %.2f RUB", parts[0], *floor)), nil
+}
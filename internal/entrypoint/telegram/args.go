@@ -1,7 +1,9 @@
 package telegram
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"enigma/internal/entity"
@@ -14,7 +16,32 @@ func rawParser(state entity.UserState, args string) (entity.UserState, error) {
 	return state, nil
 }
 
+// createParser starts entering a new transaction, it is checked for duplicates until the user saves it anyway
+func createParser(state entity.UserState, args string) (entity.UserState, error) {
+	state.Raw = args
+	state.SkipDuplicateCheck = false
+	state.MessageID = nil
+	return state, nil
+}
+
+func saveAnywayParser(state entity.UserState, _ string) (entity.UserState, error) {
+	state.SkipDuplicateCheck = true
+	return state, nil
+}
+
+// dateParser accepts a date optionally followed by hashtags to filter by, e.g. "01.05.2022 #vacation"
 func dateParser(state entity.UserState, args string) (entity.UserState, error) {
+	state.Tags = nil
+	var dateArgs []string
+	for _, word := range strings.Fields(args) {
+		if len(word) > 1 && strings.HasPrefix(word, "#") {
+			state.Tags = append(state.Tags, entity.NormalizeTag(word))
+		} else {
+			dateArgs = append(dateArgs, word)
+		}
+	}
+	args = strings.Join(dateArgs, " ")
+
 	if args == "" {
 		now := time.Now().UTC()
 		state.Date = &now
@@ -40,3 +67,94 @@ func transactionIDParser(state entity.UserState, args string) (entity.UserState,
 	state.TransactionID = &uid
 	return state, nil
 }
+
+func searchParser(state entity.UserState, args string) (entity.UserState, error) {
+	query := strings.TrimSpace(args)
+	if query == "" {
+		return state, errors.New("usage: /search <terms> [from:account] [to:account] [>amount] [2022-05 | period:2022]")
+	}
+
+	state.Query = query
+	state.Page = 0
+	return state, nil
+}
+
+func pageParser(state entity.UserState, args string) (entity.UserState, error) {
+	page, err := strconv.Atoi(args)
+	if err != nil {
+		return state, err
+	}
+	state.Page = page
+	return state, nil
+}
+
+func tagParser(state entity.UserState, args string) (entity.UserState, error) {
+	return searchParser(state, "#"+entity.NormalizeTag(args))
+}
+
+// documentParser accepts "<file id> <file name>"
+func documentParser(state entity.UserState, args string) (entity.UserState, error) {
+	parts := strings.SplitN(args, " ", 2)
+	state.FileID = parts[0]
+	state.FileName = ""
+	if len(parts) > 1 {
+		state.FileName = parts[1]
+	}
+	return state, nil
+}
+
+// photoParser accepts the file id of a photo of a receipt QR code
+func photoParser(state entity.UserState, args string) (entity.UserState, error) {
+	state.FileID = args
+	state.FileName = ""
+	state.ReceiptQR = ""
+	return state, nil
+}
+
+func receiptParser(state entity.UserState, args string) (entity.UserState, error) {
+	state.ReceiptQR = strings.TrimSpace(args)
+	state.FileID = ""
+	state.FileName = ""
+	return state, nil
+}
+
+func duplicatesParser(state entity.UserState, _ string) (entity.UserState, error) {
+	state.Page = 0
+	state.MessageID = nil
+	return state, nil
+}
+
+// forwardParser accepts "<unix time of the original message> <text>" of a forwarded message
+func forwardParser(state entity.UserState, args string) (entity.UserState, error) {
+	unix, text, _ := strings.Cut(args, " ")
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return state, err
+	}
+
+	date := time.Unix(seconds, 0).UTC()
+	state.Date = &date
+	state.Notification = text
+	state.MessageID = nil
+	return state, nil
+}
+
+// notificationParser accepts a notification pasted after /notification, e.g. an SMS which can't be forwarded
+func notificationParser(state entity.UserState, args string) (entity.UserState, error) {
+	if strings.TrimSpace(args) == "" {
+		return state, errors.New("usage: /notification <text of the bank notification>, or forward the notification")
+	}
+
+	now := time.Now().UTC()
+	state.Date = &now
+	state.Notification = args
+	state.MessageID = nil
+	return state, nil
+}
+
+// saveNotificationParser saves the notification with the accounts of the preview, the preview becomes the reply
+func saveNotificationParser(state entity.UserState, _ string) (entity.UserState, error) {
+	state.Raw = ""
+	state.SkipDuplicateCheck = false
+	return state, nil
+}
@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+// isBackupFile reports whether the uploaded file is a backup made by /backup
+func isBackupFile(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".db", ".enc":
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *Bot) backup(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	passphrase := strings.TrimSpace(state.Raw)
+
+	name := "enigma-" + time.Now().UTC().Format("2006-01-02") + ".db"
+	if passphrase != "" {
+		name += ".enc"
+	}
+
+	// the snapshot is written to the upload request as it is read from the database
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := b.createBackupUsecase.Execute(ctx, w, passphrase)
+		w.CloseWithError(err)
+		done <- err
+	}()
+
+	document := tgbotapi.NewDocument(state.ChatID, tgbotapi.FileReader{Name: name, Reader: r})
+	if passphrase != "" {
+		document.Caption = "The backup is encrypted, it can't be restored without the passphrase. " +
+			"Delete the message with the passphrase from the chat."
+	}
+
+	_, sendErr := b.api.Send(document)
+	r.Close()
+
+	err := <-done
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *Bot) inspectBackup(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	if state.FileID == "" || !isBackupFile(state.FileName) {
+		return nil, errors.New("send a backup file first")
+	}
+
+	// a passphrase is only sent in reply to the request for it
+	passphrase := ""
+	if state.Name == entity.InspectBackupState {
+		passphrase = strings.TrimSpace(state.Raw)
+	}
+
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := b.inspectBackupUsecase.Execute(ctx, file, passphrase)
+	if errors.Is(err, entity.BackupPassphraseRequiredErr) {
+		return tgbotapi.NewMessage(state.ChatID, "The backup is encrypted, send the passphrase"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Backup of %s with %s.\n\nRestoring it replaces all the current data.", formatSize(info.Size), describeBackup(info))
+
+	keyboard := newInlineKeyboard(2)
+	keyboard.addButton("Restore", "restore")
+	keyboard.addButton("Cancel", "cancel")
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply, nil
+}
+
+func (b *Bot) restoreBackup(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	if state.FileID == "" || !isBackupFile(state.FileName) {
+		return nil, errors.New("send a backup file first")
+	}
+
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := b.restoreBackupUsecase.Execute(ctx, file, strings.TrimSpace(state.Raw))
+	if err != nil {
+		return nil, err
+	}
+
+	message := "Restored the backup with " + describeBackup(info)
+	if state.MessageID != nil {
+		return tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, message), nil
+	}
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func describeBackup(info entity.BackupInfo) string {
+	if info.Transactions == 0 {
+		return "no transactions"
+	}
+	return fmt.Sprintf("%d transactions from %s to %s",
+		info.Transactions, info.FirstDate.Format("02.01.2006"), info.LastDate.Format("02.01.2006"))
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -0,0 +1,170 @@
+package telegram
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	chartWidth  = 800
+	chartHeight = 400
+
+	chartMarginLeft   = 80
+	chartMarginRight  = 20
+	chartMarginTop    = 20
+	chartMarginBottom = 30
+
+	chartTicks = 5
+)
+
+var (
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartGrid       = color.RGBA{R: 0xe0, G: 0xe0, B: 0xe0, A: 0xff}
+	chartAxis       = color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	chartLine       = color.RGBA{R: 0x1e, G: 0x88, B: 0xe5, A: 0xff}
+	chartText       = color.RGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xff}
+)
+
+// lineChart renders values as a PNG line chart, labels are placed under the points
+func lineChart(labels []string, values []float64) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: chartBackground}, image.Point{}, draw.Src)
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+	if len(values) == 0 {
+		minValue, maxValue = 0, 0
+	}
+	if minValue == maxValue {
+		minValue, maxValue = minValue-1, maxValue+1
+	}
+
+	plotWidth := chartWidth - chartMarginLeft - chartMarginRight
+	plotHeight := chartHeight - chartMarginTop - chartMarginBottom
+
+	x := func(i int) int {
+		if len(values) < 2 {
+			return chartMarginLeft + plotWidth/2
+		}
+		return chartMarginLeft + i*plotWidth/(len(values)-1)
+	}
+	y := func(v float64) int {
+		return chartMarginTop + int(math.Round((maxValue-v)/(maxValue-minValue)*float64(plotHeight)))
+	}
+
+	for tick := 0; tick <= chartTicks; tick++ {
+		v := minValue + (maxValue-minValue)*float64(tick)/chartTicks
+		drawLine(img, chartMarginLeft, y(v), chartWidth-chartMarginRight, y(v), chartGrid)
+		drawText(img, 4, y(v)+4, formatChartValue(v))
+	}
+
+	if minValue < 0 && maxValue > 0 {
+		drawLine(img, chartMarginLeft, y(0), chartWidth-chartMarginRight, y(0), chartAxis)
+	}
+	drawLine(img, chartMarginLeft, chartMarginTop, chartMarginLeft, chartHeight-chartMarginBottom, chartAxis)
+
+	// keep at least 60 pixels between labels so they don't overlap
+	labelStep := 1
+	if len(labels) > 1 {
+		labelStep = int(math.Ceil(60 / (float64(plotWidth) / float64(len(labels)-1))))
+	}
+	for i, label := range labels {
+		if i%labelStep == 0 {
+			drawText(img, x(i)-len(label)*7/2, chartHeight-chartMarginBottom+18, label)
+		}
+	}
+
+	for i := range values {
+		if i > 0 {
+			drawThickLine(img, x(i-1), y(values[i-1]), x(i), y(values[i]), chartLine)
+		}
+		fillRect(img, x(i)-3, y(values[i])-3, x(i)+3, y(values[i])+3, chartLine)
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func formatChartValue(v float64) string {
+	switch {
+	case math.Abs(v) >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case math.Abs(v) >= 1e3:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	default:
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+func drawText(img draw.Image, x, y int, text string) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(chartText),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+func drawThickLine(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	for d := -1; d <= 1; d++ {
+		drawLine(img, x0, y0+d, x1, y1+d, c)
+	}
+}
+
+// drawLine draws a line with the Bresenham's algorithm
+func drawLine(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func fillRect(img draw.Image, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
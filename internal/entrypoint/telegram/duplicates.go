@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+// checkDuplicates asks what to do with transaction if it looks like one saved before, nil means it can be saved
+func (b *Bot) checkDuplicates(ctx context.Context, state entity.UserState, transaction entity.Transaction) (tgbotapi.Chattable, error) {
+	if state.SkipDuplicateCheck {
+		return nil, nil
+	}
+
+	duplicates, err := b.findDuplicatesUsecase.Execute(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(duplicates) == 0 {
+		return nil, nil
+	}
+
+	message := "This looks like a transaction saved before:\n\n"
+	for _, t := range duplicates {
+		message += formatDuplicate(t) + "\n\n"
+	}
+	message += "Save it anyway?"
+
+	keyboard := newInlineKeyboard(2)
+	keyboard.addButton("Save anyway", "save")
+	keyboard.addButton("Discard", "cancel")
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply, nil
+}
+
+// transactionCreated replaces the duplicate warning if the transaction was saved from it
+func transactionCreated(state entity.UserState) tgbotapi.Chattable {
+	if state.MessageID != nil {
+		return tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, "Transaction created")
+	}
+	return tgbotapi.NewMessage(state.ChatID, "Transaction created")
+}
+
+func (b *Bot) cancel(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	if state.MessageID != nil {
+		return tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, "Cancelled"), nil
+	}
+	return tgbotapi.NewMessage(state.ChatID, "Cancelled"), nil
+}
+
+func (b *Bot) duplicates(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	pairs, err := b.getDuplicatesUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// a page shows one pair, the page of a pair which was just resolved is taken by the next one
+	page := state.Page
+	if page >= len(pairs) {
+		page = len(pairs) - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	message := "No duplicates found"
+	keyboard := newInlineKeyboard(2)
+
+	if len(pairs) > 0 {
+		pair := pairs[page]
+
+		message = fmt.Sprintf("Possible duplicates, %d of %d:\n\n", page+1, len(pairs))
+		message += formatDuplicate(pair.First) + "\n\n" + formatDuplicate(pair.Second)
+
+		keyboard.addButton(fmt.Sprintf("Delete #%d", pair.First.ID), fmt.Sprintf("delete %d", pair.First.ID))
+		keyboard.addButton(fmt.Sprintf("Delete #%d", pair.Second.ID), fmt.Sprintf("delete %d", pair.Second.ID))
+		keyboard.addButton("Not duplicates", fmt.Sprintf("dismiss %d %d", pair.First.ID, pair.Second.ID))
+		keyboard.addRow()
+
+		if page > 0 {
+			keyboard.addButton("⬅️", fmt.Sprintf("duplicates %d", page-1))
+		}
+		if page+1 < len(pairs) {
+			keyboard.addButton("➡️", fmt.Sprintf("duplicates %d", page+1))
+		}
+	}
+
+	if state.MessageID != nil {
+		reply := tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, message)
+		if len(pairs) > 0 {
+			reply.ReplyMarkup = keyboard.markup()
+		}
+		return reply, nil
+	}
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	if len(pairs) > 0 {
+		reply.ReplyMarkup = keyboard.markup()
+	}
+	return reply, nil
+}
+
+func (b *Bot) deleteDuplicate(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	if state.TransactionID == nil {
+		return nil, errors.New("transaction id is required")
+	}
+
+	err := b.deleteTransactionUsecase.Execute(ctx, *state.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.duplicates(ctx, state)
+}
+
+func (b *Bot) dismissDuplicate(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	ids := strings.Fields(state.Raw)
+	if len(ids) != 2 {
+		return nil, errors.New("two transaction ids are required")
+	}
+
+	first, err := strconv.ParseUint(ids[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	second, err := strconv.ParseUint(ids[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.dismissDuplicateUsecase.Execute(ctx, first, second)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.duplicates(ctx, state)
+}
+
+func formatDuplicate(t entity.Transaction) string {
+	return fmt.Sprintf("#%d %s %s -> %s %v %s: %s", t.ID, t.Date.Format("02.01.2006"), t.FromAccount, t.ToAccount, t.Amount, t.CurrencyCode(), t.Description)
+}
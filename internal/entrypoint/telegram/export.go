@@ -0,0 +1,201 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+	"enigma/internal/exporter"
+)
+
+const exportUsage = "/export <period> [csv [delimiter] [decimal separator] | jsonl | ledger | hledger | beancount], " +
+	"e.g. /export 2022-05 csv ; , or /export all beancount"
+
+// journalExtensions are file extensions of plain-text accounting journals by format
+var journalExtensions = map[string]string{
+	exporter.FormatLedger:    ".ledger",
+	exporter.FormatHledger:   ".journal",
+	exporter.FormatBeancount: ".beancount",
+}
+
+type exportArgs struct {
+	period string
+	since  time.Time
+	until  time.Time
+
+	format     string
+	csvOptions exporter.CSVOptions
+}
+
+// parseExportArgs parses "<period> [format] [delimiter] [decimal separator]", missing trailing parts are asked for
+func parseExportArgs(raw string) (exportArgs, error) {
+	var args exportArgs
+
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return args, nil
+	}
+
+	var err error
+	args.period = fields[0]
+	args.since, args.until, err = entity.ParsePeriod(args.period)
+	if err != nil {
+		return args, err
+	}
+
+	if len(fields) < 2 {
+		return args, nil
+	}
+
+	args.format = strings.ToLower(fields[1])
+	switch args.format {
+	case exporter.FormatCSV:
+		if len(fields) > 2 {
+			args.csvOptions.Delimiter, err = exporter.ParseDelimiter(fields[2])
+			if err != nil {
+				return args, err
+			}
+		}
+		if len(fields) > 3 {
+			args.csvOptions.DecimalSeparator = fields[3]
+		}
+	case exporter.FormatJSONL, exporter.FormatLedger, exporter.FormatHledger, exporter.FormatBeancount:
+	default:
+		return args, fmt.Errorf("unsupported format %s, usage: %s", fields[1], exportUsage)
+	}
+
+	return args, nil
+}
+
+// exportParser appends a typed answer to the arguments collected so far, or starts over once they are complete
+func exportParser(state entity.UserState, args string) (entity.UserState, error) {
+	collected, err := parseExportArgs(state.Raw)
+	if err != nil || collected.format != "" {
+		state.Raw = strings.TrimSpace(args)
+		return state, nil
+	}
+
+	state.Raw = strings.TrimSpace(state.Raw + " " + args)
+	return state, nil
+}
+
+func (b *Bot) export(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	args, err := parseExportArgs(state.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case args.period == "":
+		return exportPeriodPrompt(state.ChatID), nil
+	case args.format == "":
+		return exportFormatPrompt(state.ChatID, args.period), nil
+	}
+
+	var name string
+	var writer exporter.Writer
+
+	r, w := io.Pipe()
+	switch args.format {
+	case exporter.FormatCSV:
+		name = "transactions-" + args.period + ".csv"
+		writer, err = exporter.NewCSV(w, args.csvOptions)
+		if err != nil {
+			return nil, err
+		}
+	case exporter.FormatJSONL:
+		name = "transactions-" + args.period + ".jsonl"
+		writer = exporter.NewJSONL(w)
+	default:
+		accounts, err := b.getAccountsUsecase.Execute(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		name = "transactions-" + args.period + journalExtensions[args.format]
+		writer, err = exporter.NewJournal(w, args.format, accounts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type exportResult struct {
+		count int
+		err   error
+	}
+
+	// transactions are written to the upload request as they are read, nothing is buffered in memory,
+	// the document is sent after the first one, an empty period gets a message instead
+	first := make(chan struct{})
+	done := make(chan exportResult, 1)
+	go func() {
+		var once sync.Once
+		count, err := b.exportTransactionsUsecase.Execute(ctx, args.since, args.until, func(t entity.Transaction) error {
+			once.Do(func() { close(first) })
+			return writer.Write(t)
+		})
+		if err == nil && count > 0 {
+			err = writer.Flush()
+		}
+		w.CloseWithError(err)
+		done <- exportResult{count, err}
+	}()
+
+	var sendErr error
+	var result exportResult
+	select {
+	case <-first:
+		document := tgbotapi.NewDocument(state.ChatID, tgbotapi.FileReader{Name: strings.ReplaceAll(name, "..", "_"), Reader: r})
+		_, sendErr = b.api.Send(document)
+		r.Close()
+		result = <-done
+	case result = <-done:
+		r.Close()
+	}
+
+	switch {
+	case result.err == nil && result.count == 0:
+		return tgbotapi.NewMessage(state.ChatID, "No transactions for "+args.period), nil
+	case sendErr != nil:
+		return nil, sendErr
+	case result.err != nil:
+		return nil, result.err
+	}
+
+	return nil, nil
+}
+
+func exportPeriodPrompt(chatID int64) tgbotapi.Chattable {
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	keyboard := newInlineKeyboard(2)
+	keyboard.addButton("This month", "export "+thisMonth.Format("2006-01"))
+	keyboard.addButton("Last month", "export "+thisMonth.AddDate(0, -1, 0).Format("2006-01"))
+	keyboard.addButton("This year", "export "+thisMonth.Format("2006"))
+	keyboard.addButton("All time", "export all")
+
+	reply := tgbotapi.NewMessage(chatID, "Choose a period or send one, e.g. 2022-05, 2022 or 2022-01..2022-03")
+	reply.ReplyMarkup = keyboard.markup()
+	return reply
+}
+
+func exportFormatPrompt(chatID int64, period string) tgbotapi.Chattable {
+	keyboard := newInlineKeyboard(1)
+	keyboard.addButton("CSV", "export "+period+" csv")
+	keyboard.addButton("CSV for Excel (; and decimal comma)", "export "+period+" csv ; ,")
+	keyboard.addButton("JSON Lines", "export "+period+" jsonl")
+	keyboard.addButton("ledger", "export "+period+" ledger")
+	keyboard.addButton("hledger", "export "+period+" hledger")
+	keyboard.addButton("beancount", "export "+period+" beancount")
+
+	reply := tgbotapi.NewMessage(chatID, "Choose a format or send one, usage: "+exportUsage)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply
+}
@@ -0,0 +1,27 @@
+package telegram
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// downloadFile opens a file uploaded to the bot, the caller must close it
+func (b *Bot) downloadFile(fileID string) (io.ReadCloser, error) {
+	url, err := b.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download file: unexpected status %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
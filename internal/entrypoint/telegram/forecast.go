@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+var forecastPeriods = []int{30, 60, 90}
+
+func (b *Bot) forecast(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	days := forecastPeriods[0]
+	if args := strings.TrimSpace(state.Raw); args != "" {
+		var err error
+		days, err = strconv.Atoi(args)
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid number of days %s", args)
+		}
+	}
+
+	forecasts, err := b.forecastUsecase.Execute(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Forecast for %d days:\n", days)
+	if len(forecasts) == 0 {
+		message = "No asset accounts to forecast, set types with /account <name> asset"
+	}
+
+	for _, f := range forecasts {
+		message += fmt.Sprintf("\n%s: %.2f %s now\n", f.Account.Name, f.Balance, f.Currency)
+
+		for _, period := range forecastPeriods {
+			if period > days {
+				break
+			}
+			date := f.Points[period-1].Date
+			message += fmt.Sprintf("%s (%d days): %.2f %s\n", date.Format("02.01.2006"), period, f.BalanceAt(date), f.Currency)
+		}
+		if last := f.Points[len(f.Points)-1]; !isForecastPeriod(days) {
+			message += fmt.Sprintf("%s (%d days): %.2f %s\n", last.Date.Format("02.01.2006"), days, last.Balance, f.Currency)
+		}
+
+		message += fmt.Sprintf("Spending about %.2f %s a day", f.DailySpending, f.Currency)
+		if len(f.Recurring) > 0 {
+			message += fmt.Sprintf(" and %d recurring payments", len(f.Recurring))
+		}
+		message += "\n"
+
+		if f.BelowZeroAt != nil {
+			message += fmt.Sprintf("⚠️ Goes below zero on %s\n", f.BelowZeroAt.Format("02.01.2006"))
+		}
+		if f.BelowFloorAt != nil {
+			message += fmt.Sprintf("⚠️ Goes below the floor of %.2f %s on %s\n", *f.Account.Floor, f.Currency, f.BelowFloorAt.Format("02.01.2006"))
+		}
+	}
+
+	keyboard := newInlineKeyboard(3)
+	for _, period := range forecastPeriods {
+		keyboard.addButton(fmt.Sprintf("%d days", period), fmt.Sprintf("forecast %d", period))
+	}
+
+	if state.MessageID != nil {
+		reply := tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, message)
+		reply.ReplyMarkup = keyboard.markup()
+		return reply, nil
+	}
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply, nil
+}
+
+func isForecastPeriod(days int) bool {
+	for _, period := range forecastPeriods {
+		if period == days {
+			return true
+		}
+	}
+	return false
+}
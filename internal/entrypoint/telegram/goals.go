@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+	"enigma/internal/logger"
+)
+
+const progressBarWidth = 10
+
+func (b *Bot) createGoal(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	goal, err := makeGoalFromArgs(state.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.createGoalUsecase.Execute(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+
+	return tgbotapi.NewMessage(state.ChatID, "Goal created"), nil
+}
+
+// makeGoalFromArgs parses "<account> <target> <deadline> <name>"
+func makeGoalFromArgs(args string) (entity.Goal, error) {
+	parts := strings.SplitN(args, " ", 4)
+	if len(parts) != 4 {
+		return entity.Goal{}, errors.New("usage: /goal <account> <target> <dd.mm.yyyy> <name>")
+	}
+
+	target, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return entity.Goal{}, fmt.Errorf("invalid target %s: %w", parts[1], err)
+	}
+
+	deadline, err := time.Parse("02.01.2006", parts[2])
+	if err != nil {
+		return entity.Goal{}, fmt.Errorf("invalid deadline %s: %w", parts[2], err)
+	}
+
+	return entity.Goal{
+		Account:  parts[0],
+		Target:   target,
+		Deadline: deadline,
+		Name:     parts[3],
+	}, nil
+}
+
+func (b *Bot) listGoals(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	goals, err := b.getGoalsProgressUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(goals) == 0 {
+		return tgbotapi.NewMessage(state.ChatID, "No goals yet, create one with /goal <account> <target> <dd.mm.yyyy> <name>"), nil
+	}
+
+	message := "Goals:\n"
+	for _, p := range goals {
+		message += fmt.Sprintf("\n%s (%s)\n", p.Goal.Name, p.Goal.Account)
+		message += fmt.Sprintf("%s %.0f%%\n", progressBar(p.Percent()), p.Percent())
+		message += fmt.Sprintf("%.2f of %.2f RUB by %s\n", p.Saved, p.Goal.Target, p.Goal.Deadline.Format("02.01.2006"))
+		if len(p.OtherCurrencies) > 0 {
+			message += fmt.Sprintf("Transactions in %s are not counted\n", strings.Join(p.OtherCurrencies, ", "))
+		}
+
+		if p.Goal.ReachedAt != nil {
+			message += fmt.Sprintf("Reached on %s\n", p.Goal.ReachedAt.Format("02.01.2006"))
+			continue
+		}
+
+		message += fmt.Sprintf("Required: %.2f RUB a month\n", p.RequiredMonthly)
+		if p.ProjectedCompletion != nil {
+			message += fmt.Sprintf("At %.2f RUB a month it will be reached on %s\n", p.MonthlyPace, p.ProjectedCompletion.Format("02.01.2006"))
+		} else {
+			message += "No recent contributions to project completion\n"
+		}
+	}
+
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func (b *Bot) notifyReachedGoals(ctx context.Context, chatID int64) {
+	goals, err := b.checkGoalsUsecase.Execute(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("check goals", "err", err)
+		return
+	}
+
+	for _, goal := range goals {
+		message := fmt.Sprintf("🎉 Goal \"%s\" is reached: %.2f RUB saved on %s", goal.Name, goal.Target, goal.Account)
+		_, err = b.api.Send(tgbotapi.NewMessage(chatID, message))
+		if err != nil {
+			logger.FromContext(ctx).Error("notify of a reached goal", "goal", goal.Name, "err", err)
+		}
+	}
+}
+
+func progressBar(percent float64) string {
+	filled := int(math.Round(math.Min(percent, 100) / 100 * progressBarWidth))
+	return strings.Repeat("▓", filled) + strings.Repeat("░", progressBarWidth-filled)
+}
@@ -0,0 +1,281 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+	"enigma/internal/importer"
+)
+
+const (
+	csvPreviewRows = 5
+	// csvPreviewFieldLength keeps the preview within the message length limit
+	csvPreviewFieldLength = 40
+)
+
+const mappingUsage = "/mapping name=<profile> account=<account> date=<column> amount=<column> " +
+	"[description=<column>] [delimiter=;] [encoding=windows-1251] [dateformat=02.01.2006] [skip=1] [decimal=,] " +
+	"[expense=<account>] [income=<account>]"
+
+func (b *Bot) importFile(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	switch strings.ToLower(filepath.Ext(state.FileName)) {
+	case ".csv", ".txt":
+		return b.previewCSV(ctx, state)
+	case ".qif":
+		return b.previewQIF(ctx, state)
+	}
+
+	if isBackupFile(state.FileName) {
+		return b.inspectBackup(ctx, state)
+	}
+
+	if format := importer.JournalFormat(state.FileName); format != "" {
+		return b.importJournal(ctx, state, format)
+	}
+
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	imported, err := importer.Parse(state.FileName, file, importer.Options{DefaultAccount: fileAccount(state.FileName)})
+	if errors.Is(err, importer.UnsupportedFormatErr) {
+		return nil, fmt.Errorf("unsupported file %s", state.FileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return b.importTransactions(ctx, state, imported)
+}
+
+func (b *Bot) previewCSV(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, delimiter, err := importer.PreviewCSV(bytes.NewReader(data), "", "", csvPreviewRows)
+	if err != nil {
+		// most of the local banks which are not UTF-8 export in Windows-1251
+		rows, delimiter, err = importer.PreviewCSV(bytes.NewReader(data), "", "windows-1251", csvPreviewRows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	message := fmt.Sprintf("%s, delimiter \"%s\":\n\n", state.FileName, delimiter)
+	for _, row := range rows {
+		for i, field := range row {
+			field = strings.TrimSpace(field)
+			if runes := []rune(field); len(runes) > csvPreviewFieldLength {
+				field = string(runes[:csvPreviewFieldLength]) + "…"
+			}
+			message += fmt.Sprintf("[%d] %s  ", i, field)
+		}
+		message += "\n\n"
+	}
+
+	profiles, err := b.getImportProfilesUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keyboard := newInlineKeyboard(3)
+	for _, profile := range profiles {
+		data := "profile " + profile.Name
+		if len(data) <= maxCallbackDataLength {
+			keyboard.addButton(profile.Name, data)
+		}
+	}
+
+	if len(profiles) > 0 {
+		message += "Choose a saved profile or describe the columns:\n" + mappingUsage
+	} else {
+		message += "Describe the columns:\n" + mappingUsage
+	}
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	if len(profiles) > 0 {
+		reply.ReplyMarkup = keyboard.markup()
+	}
+	return reply, nil
+}
+
+func (b *Bot) importCSV(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	if state.FileID == "" {
+		return nil, errors.New("send a CSV file first")
+	}
+
+	var profile entity.ImportProfile
+	var err error
+	if strings.Contains(state.Raw, "=") {
+		profile, err = makeImportProfileFromArgs(state.Raw)
+		if err != nil {
+			return nil, err
+		}
+
+		err = b.saveImportProfileUsecase.Execute(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		profile, err = b.getImportProfileUsecase.Execute(ctx, strings.TrimSpace(state.Raw))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	imported, err := importer.ParseCSV(file, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.importTransactions(ctx, state, imported)
+}
+
+// maxJournalWarnings keeps the import report within the message length limit
+const maxJournalWarnings = 20
+
+func (b *Bot) importJournal(ctx context.Context, state entity.UserState, format string) (tgbotapi.Chattable, error) {
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	journal, err := importer.ParseJournal(file, format)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = b.declareAccountsUsecase.Execute(ctx, journal.Accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := b.importTransactions(ctx, state, journal.Transactions)
+	if err != nil || len(journal.Warnings) == 0 {
+		return reply, err
+	}
+
+	message := reply.(tgbotapi.MessageConfig)
+	message.Text += fmt.Sprintf("\n\n%d lines were not imported:\n", len(journal.Warnings))
+	for i, warning := range journal.Warnings {
+		if i == maxJournalWarnings {
+			message.Text += "…\n"
+			break
+		}
+		message.Text += warning.String() + "\n"
+	}
+	return message, nil
+}
+
+func (b *Bot) importTransactions(ctx context.Context, state entity.UserState, imported []entity.ImportedTransaction) (tgbotapi.Chattable, error) {
+	result, err := b.importTransactionsUsecase.Execute(ctx, imported)
+	if err != nil {
+		return nil, fmt.Errorf("imported %d transactions before error: %w", result.Created, err)
+	}
+
+	b.notifyReachedGoals(ctx, state.ChatID)
+
+	message := fmt.Sprintf("Imported %d transactions", result.Created)
+	if result.Skipped > 0 {
+		message += fmt.Sprintf(", %d were imported before", result.Skipped)
+	}
+	if len(result.Duplicates) > 0 {
+		message += fmt.Sprintf("\n\n%d possible duplicates of transactions saved before, review them with /duplicates", len(result.Duplicates))
+	}
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+// makeImportProfileFromArgs parses "key=value" pairs of the /mapping command
+func makeImportProfileFromArgs(args string) (entity.ImportProfile, error) {
+	profile := entity.ImportProfile{
+		DateFormat:        "02.01.2006",
+		SkipRows:          1,
+		DescriptionColumn: -1,
+		ExpenseAccount:    importer.DefaultExpenseAccount,
+		IncomeAccount:     importer.DefaultIncomeAccount,
+	}
+	required := map[string]bool{"name": false, "account": false, "date": false, "amount": false}
+
+	for _, pair := range strings.Fields(args) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || value == "" {
+			return entity.ImportProfile{}, fmt.Errorf("invalid mapping %s, usage: %s", pair, mappingUsage)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "name":
+			profile.Name = value
+		case "account":
+			profile.Account = value
+		case "date":
+			profile.DateColumn, err = strconv.Atoi(value)
+		case "amount":
+			profile.AmountColumn, err = strconv.Atoi(value)
+		case "description":
+			profile.DescriptionColumn, err = strconv.Atoi(value)
+		case "delimiter":
+			profile.Delimiter = value
+		case "encoding":
+			profile.Encoding = value
+		case "dateformat":
+			profile.DateFormat = value
+		case "skip":
+			profile.SkipRows, err = strconv.Atoi(value)
+		case "decimal":
+			profile.DecimalSeparator = value
+		case "expense":
+			profile.ExpenseAccount = value
+		case "income":
+			profile.IncomeAccount = value
+		default:
+			return entity.ImportProfile{}, fmt.Errorf("unknown mapping key %s, usage: %s", key, mappingUsage)
+		}
+		if err != nil {
+			return entity.ImportProfile{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+
+		if _, ok := required[strings.ToLower(key)]; ok {
+			required[strings.ToLower(key)] = true
+		}
+	}
+
+	for key, ok := range required {
+		if !ok {
+			return entity.ImportProfile{}, fmt.Errorf("%s is required, usage: %s", key, mappingUsage)
+		}
+	}
+
+	return profile, nil
+}
+
+// fileAccount names the account of statements which don't name it themselves after the file
+func fileAccount(fileName string) string {
+	return strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+}
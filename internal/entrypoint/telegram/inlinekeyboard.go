@@ -2,6 +2,9 @@ package telegram
 
 import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
+// maxCallbackDataLength is the Telegram limit for callback data in bytes
+const maxCallbackDataLength = 64
+
 type inlineKeyboard struct {
 	rows             [][]tgbotapi.InlineKeyboardButton
 	maxButtonsPerRow int
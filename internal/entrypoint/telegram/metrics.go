@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"enigma/internal/metrics"
+)
+
+// pollStaleAfter is how long the bot is ready without a successful getUpdates, long polls take up to a minute
+const pollStaleAfter = 2 * time.Minute
+
+var (
+	updatesTotal = metrics.NewCounter(
+		"enigma_telegram_updates_total",
+		"Updates processed by the state they came in, what triggered the transition and the next state, none if there is no transition",
+		"state", "trigger", "next",
+	)
+	handlerDuration = metrics.NewHistogram(
+		"enigma_telegram_handler_duration_seconds",
+		"Duration of handlers of states, including sending the reply",
+		metrics.DefaultBuckets,
+		"state",
+	)
+	handlerErrors = metrics.NewCounter(
+		"enigma_telegram_handler_errors_total",
+		"Updates which failed in the state they led to",
+		"state",
+	)
+	apiErrors = metrics.NewCounter(
+		"enigma_telegram_api_errors_total",
+		"Failed requests to the Telegram Bot API by method",
+		"method",
+	)
+
+	// lastPoll is the unix time in nanoseconds of the last successful getUpdates
+	lastPoll int64
+
+	_ = metrics.NewGaugeFunc(
+		"enigma_telegram_last_poll_timestamp_seconds",
+		"Unix time of the last successful getUpdates, zero before the first one",
+		func() float64 {
+			return float64(atomic.LoadInt64(&lastPoll)) / float64(time.Second)
+		},
+	)
+)
+
+// instrumentedClient counts failed Bot API requests and remembers when updates were received last
+type instrumentedClient struct {
+	client *http.Client
+
+	// pollCtx is cancelled when the bot shuts down, it interrupts getUpdates only so replies are still sent
+	pollCtx context.Context
+}
+
+func (c instrumentedClient) Do(request *http.Request) (*http.Response, error) {
+	method := path.Base(request.URL.Path)
+	if method == "getUpdates" {
+		request = request.WithContext(c.pollCtx)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil && c.pollCtx.Err() != nil && method == "getUpdates" {
+		return response, err
+	}
+	if err != nil || response.StatusCode != http.StatusOK {
+		apiErrors.Inc(method)
+		return response, err
+	}
+
+	if method == "getUpdates" {
+		atomic.StoreInt64(&lastPoll, time.Now().UnixNano())
+	}
+	return response, nil
+}
+
+// Ready reports an error if updates were not received for a while, e.g. the token was revoked or Telegram is unreachable
+func (b *Bot) Ready() error {
+	select {
+	case <-b.stopping:
+		return errors.New("shutting down")
+	default:
+	}
+
+	last := atomic.LoadInt64(&lastPoll)
+	if last == 0 {
+		return errors.New("no successful getUpdates yet")
+	}
+
+	if since := time.Since(time.Unix(0, last)); since > pollStaleAfter {
+		return fmt.Errorf("last successful getUpdates %s ago", since.Round(time.Second))
+	}
+	return nil
+}
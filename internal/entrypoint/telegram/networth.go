@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+const (
+	defaultNetWorthMonths = 12
+
+	// maxCaptionLength is the Telegram limit for photo captions
+	maxCaptionLength = 1024
+)
+
+func (b *Bot) netWorth(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	months := defaultNetWorthMonths
+	if args := strings.TrimSpace(state.Raw); args != "" {
+		var err error
+		months, err = strconv.Atoi(args)
+		if err != nil || months <= 0 {
+			return nil, fmt.Errorf("invalid number of months %s", args)
+		}
+	}
+
+	points, err := b.getNetWorthHistoryUsecase.Execute(ctx, months)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(points) == 0 {
+		return tgbotapi.NewMessage(state.ChatID, "No transactions yet"), nil
+	}
+
+	message := fmt.Sprintf("%-7s %12s %12s %12s\n", "Month", "Assets", "Liabilities", "Net worth")
+	labels := make([]string, 0, len(points))
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		message += fmt.Sprintf("%-7s %12.2f %12.2f %12.2f\n", p.Month.Format("01.2006"), p.Assets, p.Liabilities, p.NetWorth())
+		labels = append(labels, p.Month.Format("01.06"))
+		values = append(values, p.NetWorth())
+	}
+	message = "<pre>" + message + "</pre>"
+
+	// net worth in other currencies is not converted, so it is only listed for the current month
+	if other := points[len(points)-1].Other; len(other) > 0 {
+		currencies := make([]string, 0, len(other))
+		for currency := range other {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+
+		message += "\nNot included:"
+		for _, currency := range currencies {
+			message += fmt.Sprintf(" %.2f %s", other[currency], currency)
+		}
+	}
+
+	if len(points) < 2 {
+		reply := tgbotapi.NewMessage(state.ChatID, message)
+		reply.ParseMode = tgbotapi.ModeHTML
+		return reply, nil
+	}
+
+	chart, err := lineChart(labels, values)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := tgbotapi.NewPhoto(state.ChatID, tgbotapi.FileBytes{Name: "networth.png", Bytes: chart})
+	if len(message) <= maxCaptionLength {
+		reply.Caption = message
+		reply.ParseMode = tgbotapi.ModeHTML
+		return reply, nil
+	}
+
+	table := tgbotapi.NewMessage(state.ChatID, message)
+	table.ParseMode = tgbotapi.ModeHTML
+	_, err = b.api.Send(table)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
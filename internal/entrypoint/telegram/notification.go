@@ -0,0 +1,223 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+	"enigma/internal/importer"
+)
+
+const templateUsage = "/template name=<name> [account=<account>] [expense=<account>] [income=<account>] [type=income] pattern=<regular expression>, " +
+	"the pattern goes last and takes the rest of the line, /template name=<name> deletes the template"
+
+func (b *Bot) notification(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	notification, transaction, err := b.parseNotification(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("%s notification, %v %s", notification.Template, notification.Amount, notification.Currency)
+	if notification.Income {
+		message += " received"
+	}
+	if notification.Merchant != "" {
+		message += ", " + notification.Merchant
+	}
+	if notification.Card != "" {
+		message += ", card *" + notification.Card
+	}
+	if notification.Balance != nil {
+		message += fmt.Sprintf(", balance %v", *notification.Balance)
+	}
+
+	message += fmt.Sprintf("\n\n%s %s -> %s %v %s: %s\n\n", transaction.Date.Format(receiptTimeLayout),
+		transaction.FromAccount, transaction.ToAccount, transaction.Amount, transaction.CurrencyCode(), transaction.Description)
+	message += "Save it or send \"<from> <to> [description]\" to change the accounts"
+
+	keyboard := newInlineKeyboard(2)
+	keyboard.addButton("Save", "save")
+	keyboard.addButton("Cancel", "cancel")
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply, nil
+}
+
+func (b *Bot) createNotificationTransaction(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	_, transaction, err := b.parseNotification(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(state.Raw) != "" {
+		parts := strings.SplitN(strings.TrimSpace(state.Raw), " ", 3)
+		if len(parts) < 2 {
+			return nil, errors.New("invalid message format, send \"<from> <to> [description]\"")
+		}
+
+		transaction.FromAccount, transaction.ToAccount = parts[0], parts[1]
+		if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+			transaction.Description = strings.TrimSpace(parts[2])
+		}
+	}
+
+	reply, err := b.checkDuplicates(ctx, state, transaction)
+	if err != nil || reply != nil {
+		return reply, err
+	}
+
+	_, err = b.createTransactionUsecase.Execute(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	b.notifyReachedGoals(ctx, state.ChatID)
+
+	return transactionCreated(state), nil
+}
+
+// parseNotification matches the notification of state against templates of the user and built-in ones,
+// merchants are mapped onto accounts like categories of imported files
+func (b *Bot) parseNotification(ctx context.Context, state entity.UserState) (entity.BankNotification, entity.Transaction, error) {
+	if state.Notification == "" {
+		return entity.BankNotification{}, entity.Transaction{}, errors.New("forward a bank notification first")
+	}
+
+	templates, err := b.getNotificationTemplatesUsecase.Execute(ctx)
+	if err != nil {
+		return entity.BankNotification{}, entity.Transaction{}, err
+	}
+
+	notification, template, err := importer.ParseNotification(state.Notification, templates)
+	if err != nil {
+		return entity.BankNotification{}, entity.Transaction{}, err
+	}
+
+	accounts, err := b.getCategoryMappingsUsecase.Execute(ctx)
+	if err != nil {
+		return entity.BankNotification{}, entity.Transaction{}, err
+	}
+
+	date := time.Now().UTC()
+	if state.Date != nil {
+		date = *state.Date
+	}
+
+	return notification, importer.NotificationTransaction(notification, template, date, accounts), nil
+}
+
+func (b *Bot) notificationTemplate(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	template, err := makeNotificationTemplateFromArgs(state.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if template.Pattern == "" {
+		err = b.deleteNotificationTemplateUsecase.Execute(ctx, template.Name)
+		if err != nil {
+			return nil, err
+		}
+		return tgbotapi.NewMessage(state.ChatID, fmt.Sprintf("Template %s deleted", template.Name)), nil
+	}
+
+	err = b.saveNotificationTemplateUsecase.Execute(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+
+	return tgbotapi.NewMessage(state.ChatID, fmt.Sprintf("Template %s saved, forward a notification to try it", template.Name)), nil
+}
+
+func makeNotificationTemplateFromArgs(args string) (entity.NotificationTemplate, error) {
+	var template entity.NotificationTemplate
+
+	args = strings.TrimSpace(args)
+	if strings.HasPrefix(args, "pattern=") {
+		template.Pattern = strings.TrimPrefix(args, "pattern=")
+		args = ""
+	} else if i := strings.Index(args, " pattern="); i >= 0 {
+		template.Pattern = strings.TrimSpace(args[i+len(" pattern="):])
+		args = args[:i]
+	}
+
+	for _, pair := range strings.Fields(args) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || value == "" {
+			return entity.NotificationTemplate{}, fmt.Errorf("invalid parameter %s, usage: %s", pair, templateUsage)
+		}
+
+		switch strings.ToLower(key) {
+		case "name":
+			template.Name = value
+		case "account":
+			template.Account = value
+		case "expense":
+			template.ExpenseAccount = value
+		case "income":
+			template.IncomeAccount = value
+		case "type":
+			if value != "income" && value != "expense" {
+				return entity.NotificationTemplate{}, fmt.Errorf("invalid type %s, it is income or expense", value)
+			}
+			template.Income = value == "income"
+		default:
+			return entity.NotificationTemplate{}, fmt.Errorf("unknown parameter %s, usage: %s", key, templateUsage)
+		}
+	}
+
+	if template.Name == "" {
+		return entity.NotificationTemplate{}, errors.New("usage: " + templateUsage)
+	}
+
+	return template, nil
+}
+
+func (b *Bot) listNotificationTemplates(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	templates, err := b.getNotificationTemplatesUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	message := "Your templates:\n\n"
+	if len(templates) == 0 {
+		message = "No templates of your own, add one with " + templateUsage + "\n\n"
+	}
+	saved := make(map[string]bool)
+	for _, t := range templates {
+		saved[t.Name] = true
+		message += formatNotificationTemplate(t) + "\n\n"
+	}
+
+	message += "Built-in templates:"
+	for _, t := range importer.NotificationTemplates {
+		if saved[t.Name] {
+			continue
+		}
+		message += "\n" + t.Name
+	}
+
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func formatNotificationTemplate(t entity.NotificationTemplate) string {
+	s := t.Name
+	if t.Income {
+		s += " (income)"
+	}
+	if t.Account != "" {
+		s += ", account " + t.Account
+	}
+	if t.ExpenseAccount != "" {
+		s += ", expenses to " + t.ExpenseAccount
+	}
+	if t.IncomeAccount != "" {
+		s += ", income from " + t.IncomeAccount
+	}
+	return s + ": " + t.Pattern
+}
@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"enigma/internal/logger"
+	"enigma/internal/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// workers is how many chats are handled at once
+	workers = 8
+
+	// queueSize is how many updates wait for a worker, once its queue is full polling pauses until it catches up
+	queueSize = 16
+)
+
+var (
+	// queuedUpdates is the number of updates waiting in the queues of all workers
+	queuedUpdates int64
+
+	_ = metrics.NewGaugeFunc(
+		"enigma_telegram_queued_updates",
+		"Updates received and waiting for a worker",
+		func() float64 {
+			return float64(atomic.LoadInt64(&queuedUpdates))
+		},
+	)
+)
+
+// pool handles updates of different chats in parallel. Updates are sharded by chat, every chat has its worker,
+// so updates of a chat are handled one by one in the order they came and transitions of its state never interleave
+type pool struct {
+	handle func(ctx context.Context, update tgbotapi.Update)
+	queues []chan tgbotapi.Update
+	done   sync.WaitGroup
+}
+
+func newPool(workers, queueSize int, handle func(ctx context.Context, update tgbotapi.Update)) *pool {
+	p := &pool{
+		handle: handle,
+		queues: make([]chan tgbotapi.Update, workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan tgbotapi.Update, queueSize)
+	}
+	return p
+}
+
+// start runs the workers, updates are handled with ctx
+func (p *pool) start(ctx context.Context) {
+	for _, queue := range p.queues {
+		p.done.Add(1)
+		go p.work(ctx, queue)
+	}
+}
+
+func (p *pool) work(ctx context.Context, queue chan tgbotapi.Update) {
+	defer p.done.Done()
+
+	for update := range queue {
+		atomic.AddInt64(&queuedUpdates, -1)
+
+		// once ctx is done handlers would only fail, what is left is dropped to let the process stop
+		if ctx.Err() != nil {
+			logger.Default().Warn("drop the update", "update_id", update.UpdateID, "err", ctx.Err())
+			continue
+		}
+		p.handle(ctx, update)
+	}
+}
+
+// submit queues the update to the worker of its chat, waiting while the queue is full,
+// it returns false once ctx is done or stopping is closed
+func (p *pool) submit(ctx context.Context, stopping <-chan struct{}, update tgbotapi.Update) bool {
+	queue := p.queues[uint64(chatKey(update))%uint64(len(p.queues))]
+
+	select {
+	case queue <- update:
+		atomic.AddInt64(&queuedUpdates, 1)
+		return true
+	default:
+	}
+
+	logger.Default().Debug("wait for the worker of the chat", "update_id", update.UpdateID)
+
+	select {
+	case queue <- update:
+		atomic.AddInt64(&queuedUpdates, 1)
+		return true
+	case <-stopping:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// close lets the workers handle the updates in their queues and waits for them, nothing is submitted after it
+func (p *pool) close() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.done.Wait()
+}
+
+// chatKey is the chat an update is answered in. States are kept per user and replies go to the chat of the user,
+// so it is the sender, updates without one, which the bot ignores, go by their chat
+func chatKey(update tgbotapi.Update) int64 {
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	return 0
+}
@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newUpdate(id int, userID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		Message: &tgbotapi.Message{
+			From: &tgbotapi.User{ID: userID},
+			Chat: &tgbotapi.Chat{ID: userID},
+		},
+	}
+}
+
+// TestPoolTransitionsOfUserDoNotInterleave handles updates like transitions of states, reading the state of the user,
+// pausing and saving the next one, interleaved transitions would overlap or lose a step
+func TestPoolTransitionsOfUserDoNotInterleave(t *testing.T) {
+	const users, updatesPerUser = 6, 50
+
+	var (
+		mu      sync.Mutex
+		states  = make(map[int64]int)
+		handled = make(map[int64][]int)
+
+		inFlight    [users + 1]int32
+		running     int32
+		maxParallel int32
+	)
+
+	// 4 workers for 6 users, so some users share a worker
+	p := newPool(4, 2, func(_ context.Context, update tgbotapi.Update) {
+		userID := update.Message.From.ID
+
+		if atomic.AddInt32(&inFlight[userID], 1) != 1 {
+			t.Errorf("update %d of user %d is handled along with another of the user", update.UpdateID, userID)
+		}
+		defer atomic.AddInt32(&inFlight[userID], -1)
+
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxParallel)
+			if now <= max || atomic.CompareAndSwapInt32(&maxParallel, max, now) {
+				break
+			}
+		}
+
+		mu.Lock()
+		state := states[userID]
+		mu.Unlock()
+
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+
+		mu.Lock()
+		states[userID] = state + 1
+		handled[userID] = append(handled[userID], update.UpdateID)
+		mu.Unlock()
+	})
+	p.start(context.Background())
+
+	stopping := make(chan struct{})
+	id := 0
+	for i := 0; i < updatesPerUser; i++ {
+		for userID := int64(1); userID <= users; userID++ {
+			id++
+			if !p.submit(context.Background(), stopping, newUpdate(id, userID)) {
+				t.Fatalf("update %d is not submitted", id)
+			}
+		}
+	}
+	p.close()
+
+	for userID := int64(1); userID <= users; userID++ {
+		if states[userID] != updatesPerUser {
+			t.Errorf("user %d made %d transitions, want %d", userID, states[userID], updatesPerUser)
+		}
+		for i := 1; i < len(handled[userID]); i++ {
+			if handled[userID][i] < handled[userID][i-1] {
+				t.Errorf("user %d: update %d is handled after %d", userID, handled[userID][i], handled[userID][i-1])
+			}
+		}
+	}
+
+	if maxParallel < 2 {
+		t.Errorf("at most %d updates were handled at once, users are expected to be handled in parallel", maxParallel)
+	}
+}
+
+func TestPoolSlowChatDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	otherHandled := make(chan struct{})
+
+	p := newPool(2, 1, func(_ context.Context, update tgbotapi.Update) {
+		if update.Message.From.ID == 2 {
+			<-release
+			return
+		}
+		close(otherHandled)
+	})
+	p.start(context.Background())
+	defer p.close()
+	defer close(release)
+
+	stopping := make(chan struct{})
+	p.submit(context.Background(), stopping, newUpdate(1, 2))
+	p.submit(context.Background(), stopping, newUpdate(2, 1))
+
+	select {
+	case <-otherHandled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("update of another chat waits for the slow one")
+	}
+}
+
+func TestPoolSubmitWaitsWhileQueueIsFull(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	p := newPool(1, 1, func(_ context.Context, _ tgbotapi.Update) {
+		started <- struct{}{}
+		<-release
+	})
+	p.start(context.Background())
+
+	stopping := make(chan struct{})
+	p.submit(context.Background(), stopping, newUpdate(1, 1))
+	<-started
+
+	// the worker is busy with the first update and the second one fills the queue
+	if !p.submit(context.Background(), stopping, newUpdate(2, 1)) {
+		t.Fatal("second update is not queued")
+	}
+
+	submitted := make(chan bool)
+	go func() {
+		submitted <- p.submit(context.Background(), stopping, newUpdate(3, 1))
+	}()
+
+	select {
+	case <-submitted:
+		t.Fatal("third update is queued beyond the size of the queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(stopping)
+	if <-submitted {
+		t.Fatal("update is queued after stopping")
+	}
+
+	close(release)
+	<-started
+	p.close()
+}
+
+func TestPoolCloseHandlesQueuedUpdates(t *testing.T) {
+	var count int32
+	p := newPool(3, 10, func(_ context.Context, _ tgbotapi.Update) {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&count, 1)
+	})
+	p.start(context.Background())
+
+	stopping := make(chan struct{})
+	for i := 0; i < 30; i++ {
+		p.submit(context.Background(), stopping, newUpdate(i, int64(i%5)))
+	}
+	p.close()
+
+	if count != 30 {
+		t.Errorf("%d updates are handled before close returned, want 30", count)
+	}
+}
+
+func TestPoolDropsQueuedUpdatesOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var count int32
+	p := newPool(1, 5, func(_ context.Context, _ tgbotapi.Update) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			close(started)
+			<-release
+		}
+	})
+	p.start(ctx)
+
+	stopping := make(chan struct{})
+	p.submit(ctx, stopping, newUpdate(1, 1))
+	<-started
+	for i := 2; i <= 4; i++ {
+		p.submit(ctx, stopping, newUpdate(i, 1))
+	}
+
+	cancel()
+	close(release)
+	p.close()
+
+	if count != 1 {
+		t.Errorf("%d updates are handled, the queued ones are expected to be dropped after cancelling", count)
+	}
+}
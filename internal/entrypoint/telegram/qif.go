@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+	"enigma/internal/importer"
+)
+
+func (b *Bot) previewQIF(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	transactions, err := b.parseQIF(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	unmapped, err := b.unmappedCategories(ctx, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(unmapped) == 0 {
+		return b.importQIF(ctx, state)
+	}
+
+	message := fmt.Sprintf("%s has %d transactions, these categories are not mapped onto accounts:\n\n", state.FileName, len(transactions))
+	message += strings.Join(unmapped, "\n")
+	message += "\n\nMap them with /map followed by \"<category> = <account>\" lines, " +
+		"then load the file with /import, unmapped categories become accounts as they are"
+
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func (b *Bot) mapCategories(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	var mappings []entity.CategoryMapping
+	for _, line := range strings.Split(state.Raw, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		category, account, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping %s, usage: /map <category> = <account>", line)
+		}
+		mappings = append(mappings, entity.CategoryMapping{
+			Category: strings.TrimSpace(category),
+			Account:  strings.TrimSpace(account),
+		})
+	}
+
+	if len(mappings) == 0 {
+		return nil, errors.New("usage: /map <category> = <account>, one mapping per line")
+	}
+
+	err := b.saveCategoryMappingsUsecase.Execute(ctx, mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Saved %d mappings", len(mappings))
+
+	if state.FileID != "" && strings.EqualFold(filepath.Ext(state.FileName), ".qif") {
+		transactions, err := b.parseQIF(ctx, state)
+		if err != nil {
+			return nil, err
+		}
+
+		unmapped, err := b.unmappedCategories(ctx, transactions)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(unmapped) > 0 {
+			message += ", still not mapped:\n\n" + strings.Join(unmapped, "\n")
+		}
+		message += "\n\nLoad the file with /import"
+	}
+
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func (b *Bot) importQIF(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	if state.FileID == "" || !strings.EqualFold(filepath.Ext(state.FileName), ".qif") {
+		return nil, errors.New("send a QIF file first")
+	}
+
+	transactions, err := b.parseQIF(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := b.getCategoryMappingsUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.importTransactions(ctx, state, importer.MapQIF(transactions, accounts, importer.Options{}))
+}
+
+func (b *Bot) parseQIF(ctx context.Context, state entity.UserState) ([]importer.QIFTransaction, error) {
+	file, err := b.downloadFile(state.FileID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return importer.ParseQIF(file, importer.Options{DefaultAccount: fileAccount(state.FileName)})
+}
+
+func (b *Bot) unmappedCategories(ctx context.Context, transactions []importer.QIFTransaction) ([]string, error) {
+	accounts, err := b.getCategoryMappingsUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var unmapped []string
+	for _, category := range importer.QIFCategories(transactions) {
+		if _, ok := accounts[category]; !ok {
+			unmapped = append(unmapped, category)
+		}
+	}
+	return unmapped, nil
+}
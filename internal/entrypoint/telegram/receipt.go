@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+	"enigma/internal/importer"
+)
+
+const receiptTimeLayout = "02.01.2006 15:04"
+
+func (b *Bot) receipt(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	receipt, err := b.readReceipt(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := b.getReceiptTransactionUsecase.Execute(ctx, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		keyboard := newInlineKeyboard(1)
+		keyboard.addButton("Show", fmt.Sprintf("show %d", existing.ID))
+
+		reply := tgbotapi.NewMessage(state.ChatID, fmt.Sprintf("This receipt is already entered as transaction #%d", existing.ID))
+		reply.ReplyMarkup = keyboard.markup()
+		return reply, nil
+	}
+
+	message := fmt.Sprintf("Receipt of %s, %v %s, %s\n\n", receipt.Time.Format(receiptTimeLayout), receipt.Sum, entity.DefaultCurrency, receipt.Type)
+	message += "Send \"<from> <to> [description]\" to save it"
+	return tgbotapi.NewMessage(state.ChatID, message), nil
+}
+
+func (b *Bot) createReceiptTransaction(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	receipt, err := b.readReceipt(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(state.Raw), " ", 3)
+	if len(parts) < 2 {
+		return nil, errors.New("invalid message format, send \"<from> <to> [description]\"")
+	}
+
+	description := "Receipt of " + receipt.Time.Format(receiptTimeLayout)
+	if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+		description = strings.TrimSpace(parts[2])
+	}
+
+	transaction := entity.Transaction{
+		Date:        receipt.Time,
+		FromAccount: parts[0],
+		ToAccount:   parts[1],
+		Amount:      receipt.Sum,
+		Description: description,
+		Receipt:     &receipt,
+	}
+
+	reply, err := b.checkDuplicates(ctx, state, transaction)
+	if err != nil || reply != nil {
+		return reply, err
+	}
+
+	_, err = b.createTransactionUsecase.Execute(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	b.notifyReachedGoals(ctx, state.ChatID)
+
+	return transactionCreated(state), nil
+}
+
+// readReceipt parses the receipt QR code sent as text or recognizes it on the photo
+func (b *Bot) readReceipt(ctx context.Context, state entity.UserState) (entity.Receipt, error) {
+	qr := state.ReceiptQR
+	if qr == "" {
+		if state.FileID == "" {
+			return entity.Receipt{}, errors.New("send a receipt QR code or its photo first")
+		}
+
+		file, err := b.downloadFile(state.FileID)
+		if err != nil {
+			return entity.Receipt{}, err
+		}
+		defer file.Close()
+
+		qr, err = importer.DecodeQR(file)
+		if err != nil {
+			return entity.Receipt{}, err
+		}
+	}
+
+	return entity.ParseReceiptQR(qr)
+}
@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+const searchPageSize = 10
+
+func (b *Bot) searchTransactions(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	filter, err := entity.ParseTransactionFilter(state.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, total, err := b.searchTransactionsUsecase.Execute(ctx, filter, state.Page*searchPageSize, searchPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Search results for \"%s\":\n\n", state.Query)
+	keyboard := newInlineKeyboard(5)
+
+	if total == 0 {
+		message = fmt.Sprintf("Nothing found for \"%s\"", state.Query)
+	} else {
+		offset := state.Page * searchPageSize
+		for i, t := range transactions {
+			message += fmt.Sprintf("%d. %s %s -> %s %v %s: %s\n\n", offset+i+1, t.Date.Format("02.01.2006"), t.FromAccount, t.ToAccount, t.Amount, t.CurrencyCode(), t.Description)
+			keyboard.addButton(strconv.Itoa(offset+i+1), fmt.Sprintf("show %d", t.ID))
+		}
+		keyboard.fillLastRowWithEmptyButtons()
+
+		pages := (total + searchPageSize - 1) / searchPageSize
+		message += fmt.Sprintf("Page %d of %d, choose one to edit", state.Page+1, pages)
+
+		if state.Page > 0 {
+			keyboard.addButton("⬅️", fmt.Sprintf("search %d", state.Page-1))
+		}
+		if state.Page+1 < pages {
+			keyboard.addButton("➡️", fmt.Sprintf("search %d", state.Page+1))
+		}
+	}
+
+	if state.MessageID != nil {
+		reply := tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, message)
+		reply.ReplyMarkup = keyboard.markup()
+		return reply, nil
+	}
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply, nil
+}
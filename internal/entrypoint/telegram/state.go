@@ -1,7 +1,9 @@
 package telegram
 
 import (
+	"context"
 	"errors"
+	"strconv"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -12,10 +14,14 @@ import (
 type stateNode struct {
 	stateName string
 
-	handleIn  func(state entity.UserState) (tgbotapi.Chattable, error)
+	handleIn  func(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error)
 	handleOut func(state entity.UserState, update tgbotapi.Update) (entity.UserState, error)
 
 	transitionByText     transition
+	transitionByDocument transition
+	transitionByPhoto    transition
+	transitionByReceipt  transition
+	transitionByForward  transition
 	transitionByCommand  map[string]transition
 	transitionByCallback map[string]transition
 }
@@ -29,6 +35,24 @@ func (n *stateNode) addTransitionByText(next *stateNode, parser argsParser) {
 	n.transitionByText = transition{next, parser}
 }
 
+func (n *stateNode) addTransitionByDocument(next *stateNode, parser argsParser) {
+	n.transitionByDocument = transition{next, parser}
+}
+
+func (n *stateNode) addTransitionByPhoto(next *stateNode, parser argsParser) {
+	n.transitionByPhoto = transition{next, parser}
+}
+
+// addTransitionByReceipt handles texts which are receipt QR codes, they take precedence over other texts
+func (n *stateNode) addTransitionByReceipt(next *stateNode, parser argsParser) {
+	n.transitionByReceipt = transition{next, parser}
+}
+
+// addTransitionByForward handles forwarded texts, e.g. notifications of bank bots
+func (n *stateNode) addTransitionByForward(next *stateNode, parser argsParser) {
+	n.transitionByForward = transition{next, parser}
+}
+
 func (n *stateNode) addTransitionByCommand(command string, next *stateNode, parser argsParser) {
 	if n.transitionByCommand == nil {
 		n.transitionByCommand = make(map[string]transition)
@@ -43,16 +67,28 @@ func (n *stateNode) addTransitionByCallback(query string, next *stateNode, parse
 	n.transitionByCallback[query] = transition{next, parser}
 }
 
-func (n *stateNode) getTransitionWithArgs(update tgbotapi.Update) (transition, string, error) {
-	var t transition
-	var args string
-
+// getTransitionWithArgs finds the transition by the update, trigger names what the update is, e.g. a command
+func (n *stateNode) getTransitionWithArgs(update tgbotapi.Update) (t transition, trigger string, args string, err error) {
 	if update.Message != nil {
-		if update.Message.IsCommand() {
-			t = n.transitionByCommand[update.Message.Command()]
+		if update.Message.Document != nil {
+			t, trigger = n.transitionByDocument, "document"
+			args = update.Message.Document.FileID + " " + update.Message.Document.FileName
+		} else if len(update.Message.Photo) > 0 {
+			// photo sizes are ordered from the smallest, the largest one is best for recognition
+			t, trigger = n.transitionByPhoto, "photo"
+			args = update.Message.Photo[len(update.Message.Photo)-1].FileID
+		} else if update.Message.IsCommand() {
+			t, trigger = n.transitionByCommand[update.Message.Command()], "command"
 			args = update.Message.CommandArguments()
+		} else if entity.IsReceiptQR(update.Message.Text) {
+			t, trigger = n.transitionByReceipt, "receipt"
+			args = update.Message.Text
+		} else if isForwarded(update.Message) && update.Message.Text != "" {
+			// the forward date is when the original message was sent, the time of the purchase
+			t, trigger = n.transitionByForward, "forward"
+			args = strconv.Itoa(update.Message.ForwardDate) + " " + update.Message.Text
 		} else {
-			t = n.transitionByText
+			t, trigger = n.transitionByText, "text"
 			args = update.Message.Text
 		}
 	} else if update.CallbackQuery != nil {
@@ -61,21 +97,29 @@ func (n *stateNode) getTransitionWithArgs(update tgbotapi.Update) (transition, s
 		if len(split) > 1 {
 			args = split[1]
 		}
-		t = n.transitionByCallback[command]
+		t, trigger = n.transitionByCallback[command], "callback"
+	} else {
+		trigger = "other"
 	}
 
 	if t.node == nil {
-		return transition{}, "", errors.New("no transition")
+		return transition{}, trigger, "", errors.New("no transition")
 	}
 
-	return t, args, nil
+	return t, trigger, args, nil
+}
+
+func isForwarded(message *tgbotapi.Message) bool {
+	return message.ForwardFrom != nil || message.ForwardFromChat != nil || message.ForwardSenderName != ""
 }
 
 func (n *stateNode) getNextState(state entity.UserState, update tgbotapi.Update) (entity.UserState, error) {
-	t, args, err := n.getTransitionWithArgs(update)
+	t, trigger, args, err := n.getTransitionWithArgs(update)
 	if err != nil {
+		updatesTotal.Inc(n.stateName, trigger, "none")
 		return entity.UserState{}, err
 	}
+	updatesTotal.Inc(n.stateName, trigger, t.node.stateName)
 
 	if t.parser != nil {
 		state, err = t.parser(state, args)
@@ -92,12 +136,41 @@ func (n *stateNode) getNextState(state entity.UserState, update tgbotapi.Update)
 var stateNodes = make(map[string]*stateNode)
 
 func init() {
-	for _, stateName := range []string{
+	stateNames := []string{
 		entity.StartState,
 		entity.CreateTransactionState,
 		entity.ShowTransactionState,
 		entity.ListTransactionsState,
-	} {
+		entity.SearchTransactionsState,
+		entity.ListTagsState,
+		entity.ListGoalsState,
+		entity.CreateGoalState,
+		entity.ListAccountsState,
+		entity.SetAccountTypeState,
+		entity.NetWorthState,
+		entity.ForecastState,
+		entity.SetAccountFloorState,
+		entity.ImportFileState,
+		entity.ImportCSVState,
+		entity.MapCategoriesState,
+		entity.ImportQIFState,
+		entity.ExportState,
+		entity.ReceiptState,
+		entity.CreateReceiptTransactionState,
+		entity.NotificationState,
+		entity.CreateNotificationTransactionState,
+		entity.NotificationTemplateState,
+		entity.ListNotificationTemplatesState,
+		entity.BackupState,
+		entity.InspectBackupState,
+		entity.RestoreBackupState,
+		entity.CancelState,
+		entity.DuplicatesState,
+		entity.DeleteDuplicateState,
+		entity.DismissDuplicateState,
+	}
+
+	for _, stateName := range stateNames {
 		if _, ok := stateNodes[stateName]; !ok {
 			stateNodes[stateName] = &stateNode{
 				stateName: stateName,
@@ -106,18 +179,73 @@ func init() {
 		}
 	}
 
-	stateNodes[entity.StartState].addTransitionByCommand("start", stateNodes[entity.StartState], nil)
-	stateNodes[entity.StartState].addTransitionByCommand("create", stateNodes[entity.CreateTransactionState], nil)
-	stateNodes[entity.StartState].addTransitionByCommand("list", stateNodes[entity.ListTransactionsState], dateParser)
+	for _, stateName := range stateNames {
+		stateNodes[stateName].addTransitionByCommand("start", stateNodes[entity.StartState], nil)
+		stateNodes[stateName].addTransitionByCommand("create", stateNodes[entity.CreateTransactionState], createParser)
+		stateNodes[stateName].addTransitionByCommand("list", stateNodes[entity.ListTransactionsState], dateParser)
+		stateNodes[stateName].addTransitionByCommand("search", stateNodes[entity.SearchTransactionsState], searchParser)
+		stateNodes[stateName].addTransitionByCommand("tags", stateNodes[entity.ListTagsState], nil)
+		stateNodes[stateName].addTransitionByCommand("goals", stateNodes[entity.ListGoalsState], nil)
+		stateNodes[stateName].addTransitionByCommand("goal", stateNodes[entity.CreateGoalState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("accounts", stateNodes[entity.ListAccountsState], nil)
+		stateNodes[stateName].addTransitionByCommand("account", stateNodes[entity.SetAccountTypeState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("networth", stateNodes[entity.NetWorthState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("forecast", stateNodes[entity.ForecastState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("floor", stateNodes[entity.SetAccountFloorState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("mapping", stateNodes[entity.ImportCSVState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("map", stateNodes[entity.MapCategoriesState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("import", stateNodes[entity.ImportQIFState], nil)
+		stateNodes[stateName].addTransitionByCommand("export", stateNodes[entity.ExportState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("backup", stateNodes[entity.BackupState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("duplicates", stateNodes[entity.DuplicatesState], duplicatesParser)
+		stateNodes[stateName].addTransitionByCommand("notification", stateNodes[entity.NotificationState], notificationParser)
+		stateNodes[stateName].addTransitionByCommand("template", stateNodes[entity.NotificationTemplateState], rawParser)
+		stateNodes[stateName].addTransitionByCommand("templates", stateNodes[entity.ListNotificationTemplatesState], nil)
+		stateNodes[stateName].addTransitionByDocument(stateNodes[entity.ImportFileState], documentParser)
+		stateNodes[stateName].addTransitionByPhoto(stateNodes[entity.ReceiptState], photoParser)
+		stateNodes[stateName].addTransitionByReceipt(stateNodes[entity.ReceiptState], receiptParser)
+		stateNodes[stateName].addTransitionByForward(stateNodes[entity.NotificationState], forwardParser)
+	}
 
-	stateNodes[entity.ListTransactionsState].addTransitionByCommand("start", stateNodes[entity.StartState], nil)
-	stateNodes[entity.ListTransactionsState].addTransitionByCommand("create", stateNodes[entity.CreateTransactionState], nil)
-	stateNodes[entity.ListTransactionsState].addTransitionByCommand("list", stateNodes[entity.ListTransactionsState], dateParser)
 	stateNodes[entity.ListTransactionsState].addTransitionByCallback("list", stateNodes[entity.ListTransactionsState], dateParser)
 	stateNodes[entity.ListTransactionsState].addTransitionByCallback("show", stateNodes[entity.ShowTransactionState], transactionIDParser)
 
-	stateNodes[entity.ShowTransactionState].addTransitionByCommand("start", stateNodes[entity.StartState], nil)
-	stateNodes[entity.ShowTransactionState].addTransitionByCommand("create", stateNodes[entity.CreateTransactionState], nil)
-	stateNodes[entity.ShowTransactionState].addTransitionByCommand("list", stateNodes[entity.ListTransactionsState], dateParser)
+	stateNodes[entity.SearchTransactionsState].addTransitionByCallback("search", stateNodes[entity.SearchTransactionsState], pageParser)
+	stateNodes[entity.SearchTransactionsState].addTransitionByCallback("show", stateNodes[entity.ShowTransactionState], transactionIDParser)
+
+	stateNodes[entity.ListTagsState].addTransitionByCallback("tag", stateNodes[entity.SearchTransactionsState], tagParser)
+
+	stateNodes[entity.ForecastState].addTransitionByCallback("forecast", stateNodes[entity.ForecastState], rawParser)
+
+	stateNodes[entity.ImportFileState].addTransitionByCallback("profile", stateNodes[entity.ImportCSVState], rawParser)
+	stateNodes[entity.ImportFileState].addTransitionByText(stateNodes[entity.InspectBackupState], rawParser)
+	stateNodes[entity.ImportFileState].addTransitionByCallback("restore", stateNodes[entity.RestoreBackupState], nil)
+	stateNodes[entity.ImportFileState].addTransitionByCallback("cancel", stateNodes[entity.CancelState], nil)
+
+	stateNodes[entity.InspectBackupState].addTransitionByText(stateNodes[entity.InspectBackupState], rawParser)
+	stateNodes[entity.InspectBackupState].addTransitionByCallback("restore", stateNodes[entity.RestoreBackupState], nil)
+	stateNodes[entity.InspectBackupState].addTransitionByCallback("cancel", stateNodes[entity.CancelState], nil)
+
+	stateNodes[entity.ExportState].addTransitionByText(stateNodes[entity.ExportState], exportParser)
+	stateNodes[entity.ExportState].addTransitionByCallback("export", stateNodes[entity.ExportState], rawParser)
+
+	stateNodes[entity.ReceiptState].addTransitionByText(stateNodes[entity.CreateReceiptTransactionState], createParser)
+	stateNodes[entity.ReceiptState].addTransitionByCallback("show", stateNodes[entity.ShowTransactionState], transactionIDParser)
+
+	stateNodes[entity.NotificationState].addTransitionByText(stateNodes[entity.CreateNotificationTransactionState], createParser)
+	stateNodes[entity.NotificationState].addTransitionByCallback("save", stateNodes[entity.CreateNotificationTransactionState], saveNotificationParser)
+	stateNodes[entity.NotificationState].addTransitionByCallback("cancel", stateNodes[entity.CancelState], nil)
+
+	for _, stateName := range []string{entity.CreateTransactionState, entity.CreateReceiptTransactionState, entity.CreateNotificationTransactionState} {
+		stateNodes[stateName].addTransitionByCallback("save", stateNodes[stateName], saveAnywayParser)
+		stateNodes[stateName].addTransitionByCallback("cancel", stateNodes[entity.CancelState], nil)
+	}
+
+	for _, stateName := range []string{entity.DuplicatesState, entity.DeleteDuplicateState, entity.DismissDuplicateState} {
+		stateNodes[stateName].addTransitionByCallback("duplicates", stateNodes[entity.DuplicatesState], pageParser)
+		stateNodes[stateName].addTransitionByCallback("delete", stateNodes[entity.DeleteDuplicateState], transactionIDParser)
+		stateNodes[stateName].addTransitionByCallback("dismiss", stateNodes[entity.DismissDuplicateState], rawParser)
+	}
+
 	stateNodes[entity.ShowTransactionState].addTransitionByCallback("list", stateNodes[entity.ListTransactionsState], dateParser)
 }
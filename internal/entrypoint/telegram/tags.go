@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+func (b *Bot) listTags(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
+	tags, err := b.getTagsUsecase.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tags) == 0 {
+		return tgbotapi.NewMessage(state.ChatID, "No tags yet, add #hashtags to transaction descriptions"), nil
+	}
+
+	message := "Tags:\n\n"
+	keyboard := newInlineKeyboard(3)
+	buttons := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		message += fmt.Sprintf("#%s: %v %s in %d transactions\n", t.Tag, t.Total, t.Currency, t.Count)
+
+		// a tag used in several currencies is listed for each of them
+		if _, ok := buttons[t.Tag]; ok {
+			continue
+		}
+		buttons[t.Tag] = struct{}{}
+
+		data := fmt.Sprintf("tag %s", t.Tag)
+		if len(data) <= maxCallbackDataLength {
+			keyboard.addButton("#"+t.Tag, data)
+		}
+	}
+	message += "\nChoose one to see its transactions"
+
+	reply := tgbotapi.NewMessage(state.ChatID, message)
+	reply.ReplyMarkup = keyboard.markup()
+	return reply, nil
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"enigma/internal/entity"
+	"enigma/internal/logger"
 	"enigma/internal/usecase"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -18,6 +22,12 @@ type Bot struct {
 	api     *tgbotapi.BotAPI
 	adminID int64
 
+	// webAppURL is the public address of the Mini App editing transactions, the button is hidden if it is empty
+	webAppURL string
+
+	// reportErrors sends unexpected errors to the admin along with the fields of the update
+	reportErrors bool
+
 	idempotenceUsecase *usecase.Idempotence
 
 	getUserStateUsecase  *usecase.GetUserstate
@@ -26,36 +36,178 @@ type Bot struct {
 	createTransactionUsecase *usecase.CreateTransaction
 	getTransactionsByDate    *usecase.GetTransactionsByDate
 	getTransactionByID       *usecase.GetTransactionByID
+
+	searchTransactionsUsecase *usecase.SearchTransactions
+	getTagsUsecase            *usecase.GetTags
+
+	createGoalUsecase       *usecase.CreateGoal
+	getGoalsProgressUsecase *usecase.GetGoalsProgress
+	checkGoalsUsecase       *usecase.CheckGoals
+
+	getAccountsUsecase        *usecase.GetAccounts
+	setAccountTypeUsecase     *usecase.SetAccountType
+	getNetWorthHistoryUsecase *usecase.GetNetWorthHistory
+
+	forecastUsecase        *usecase.Forecast
+	setAccountFloorUsecase *usecase.SetAccountFloor
+
+	importTransactionsUsecase *usecase.ImportTransactions
+	saveImportProfileUsecase  *usecase.SaveImportProfile
+	getImportProfileUsecase   *usecase.GetImportProfile
+	getImportProfilesUsecase  *usecase.GetImportProfiles
+
+	saveCategoryMappingsUsecase *usecase.SaveCategoryMappings
+	getCategoryMappingsUsecase  *usecase.GetCategoryMappings
+	declareAccountsUsecase      *usecase.DeclareAccounts
+
+	exportTransactionsUsecase *usecase.ExportTransactions
+
+	getReceiptTransactionUsecase *usecase.GetReceiptTransaction
+
+	createBackupUsecase  *usecase.CreateBackup
+	inspectBackupUsecase *usecase.InspectBackup
+	restoreBackupUsecase *usecase.RestoreBackup
+
+	findDuplicatesUsecase    *usecase.FindDuplicates
+	getDuplicatesUsecase     *usecase.GetDuplicates
+	dismissDuplicateUsecase  *usecase.DismissDuplicate
+	deleteTransactionUsecase *usecase.DeleteTransaction
+
+	saveNotificationTemplateUsecase   *usecase.SaveNotificationTemplate
+	deleteNotificationTemplateUsecase *usecase.DeleteNotificationTemplate
+	getNotificationTemplatesUsecase   *usecase.GetNotificationTemplates
+
+	// stopping is closed by Shutdown, stopped once the workers handled the updates queued for them
+	stopping chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// cancelPoll interrupts the long poll of getUpdates, which otherwise holds shutting down for up to a minute
+	cancelPoll context.CancelFunc
 }
 
-func New(
-	token string,
-	adminID int64,
-	idempotenceUsecase *usecase.Idempotence,
-	getUserStateUsecase *usecase.GetUserstate,
-	saveUserStateUsecase *usecase.SaveUserstate,
-	createTransactionUsecase *usecase.CreateTransaction,
-	getTransactionsByDate *usecase.GetTransactionsByDate,
-	getTransactionByID *usecase.GetTransactionByID,
-) (*Bot, error) {
-
-	botApi, err := tgbotapi.NewBotAPI(token)
+// Usecases are the usecases the bot handles updates with
+type Usecases struct {
+	Idempotence *usecase.Idempotence
+
+	GetUserState  *usecase.GetUserstate
+	SaveUserState *usecase.SaveUserstate
+
+	CreateTransaction     *usecase.CreateTransaction
+	GetTransactionsByDate *usecase.GetTransactionsByDate
+	GetTransactionByID    *usecase.GetTransactionByID
+
+	SearchTransactions *usecase.SearchTransactions
+	GetTags            *usecase.GetTags
+
+	CreateGoal       *usecase.CreateGoal
+	GetGoalsProgress *usecase.GetGoalsProgress
+	CheckGoals       *usecase.CheckGoals
+
+	GetAccounts        *usecase.GetAccounts
+	SetAccountType     *usecase.SetAccountType
+	GetNetWorthHistory *usecase.GetNetWorthHistory
+
+	Forecast        *usecase.Forecast
+	SetAccountFloor *usecase.SetAccountFloor
+
+	ImportTransactions *usecase.ImportTransactions
+	SaveImportProfile  *usecase.SaveImportProfile
+	GetImportProfile   *usecase.GetImportProfile
+	GetImportProfiles  *usecase.GetImportProfiles
+
+	SaveCategoryMappings *usecase.SaveCategoryMappings
+	GetCategoryMappings  *usecase.GetCategoryMappings
+	DeclareAccounts      *usecase.DeclareAccounts
+
+	ExportTransactions *usecase.ExportTransactions
+
+	GetReceiptTransaction *usecase.GetReceiptTransaction
+
+	CreateBackup  *usecase.CreateBackup
+	InspectBackup *usecase.InspectBackup
+	RestoreBackup *usecase.RestoreBackup
+
+	FindDuplicates    *usecase.FindDuplicates
+	GetDuplicates     *usecase.GetDuplicates
+	DismissDuplicate  *usecase.DismissDuplicate
+	DeleteTransaction *usecase.DeleteTransaction
+
+	SaveNotificationTemplate   *usecase.SaveNotificationTemplate
+	DeleteNotificationTemplate *usecase.DeleteNotificationTemplate
+	GetNotificationTemplates   *usecase.GetNotificationTemplates
+}
+
+func New(token string, adminID int64, webAppURL string, reportErrors bool, usecases Usecases) (*Bot, error) {
+
+	pollCtx, cancelPoll := context.WithCancel(context.Background())
+	client := instrumentedClient{client: &http.Client{}, pollCtx: pollCtx}
+
+	botApi, err := tgbotapi.NewBotAPIWithClient(token, tgbotapi.APIEndpoint, client)
 	if err != nil {
+		cancelPoll()
 		return nil, err
 	}
 
 	b := &Bot{
-		api:     botApi,
-		adminID: adminID,
+		api:       botApi,
+		adminID:   adminID,
+		webAppURL: strings.TrimSuffix(webAppURL, "/"),
+
+		reportErrors: reportErrors,
 
-		idempotenceUsecase: idempotenceUsecase,
+		idempotenceUsecase: usecases.Idempotence,
 
-		getUserStateUsecase:  getUserStateUsecase,
-		saveUserStateUsecase: saveUserStateUsecase,
+		getUserStateUsecase:  usecases.GetUserState,
+		saveUserStateUsecase: usecases.SaveUserState,
 
-		createTransactionUsecase: createTransactionUsecase,
-		getTransactionsByDate:    getTransactionsByDate,
-		getTransactionByID:       getTransactionByID,
+		createTransactionUsecase: usecases.CreateTransaction,
+		getTransactionsByDate:    usecases.GetTransactionsByDate,
+		getTransactionByID:       usecases.GetTransactionByID,
+
+		searchTransactionsUsecase: usecases.SearchTransactions,
+		getTagsUsecase:            usecases.GetTags,
+
+		createGoalUsecase:       usecases.CreateGoal,
+		getGoalsProgressUsecase: usecases.GetGoalsProgress,
+		checkGoalsUsecase:       usecases.CheckGoals,
+
+		getAccountsUsecase:        usecases.GetAccounts,
+		setAccountTypeUsecase:     usecases.SetAccountType,
+		getNetWorthHistoryUsecase: usecases.GetNetWorthHistory,
+
+		forecastUsecase:        usecases.Forecast,
+		setAccountFloorUsecase: usecases.SetAccountFloor,
+
+		importTransactionsUsecase: usecases.ImportTransactions,
+		saveImportProfileUsecase:  usecases.SaveImportProfile,
+		getImportProfileUsecase:   usecases.GetImportProfile,
+		getImportProfilesUsecase:  usecases.GetImportProfiles,
+
+		saveCategoryMappingsUsecase: usecases.SaveCategoryMappings,
+		getCategoryMappingsUsecase:  usecases.GetCategoryMappings,
+		declareAccountsUsecase:      usecases.DeclareAccounts,
+
+		exportTransactionsUsecase: usecases.ExportTransactions,
+
+		getReceiptTransactionUsecase: usecases.GetReceiptTransaction,
+
+		createBackupUsecase:  usecases.CreateBackup,
+		inspectBackupUsecase: usecases.InspectBackup,
+		restoreBackupUsecase: usecases.RestoreBackup,
+
+		findDuplicatesUsecase:    usecases.FindDuplicates,
+		getDuplicatesUsecase:     usecases.GetDuplicates,
+		dismissDuplicateUsecase:  usecases.DismissDuplicate,
+		deleteTransactionUsecase: usecases.DeleteTransaction,
+
+		saveNotificationTemplateUsecase:   usecases.SaveNotificationTemplate,
+		deleteNotificationTemplateUsecase: usecases.DeleteNotificationTemplate,
+		getNotificationTemplatesUsecase:   usecases.GetNotificationTemplates,
+
+		stopping:   make(chan struct{}),
+		stopped:    make(chan struct{}),
+		cancelPoll: cancelPoll,
 	}
 
 	b.fillStateNodes()
@@ -63,6 +215,7 @@ func New(
 	return b, nil
 }
 
+// Start polls updates and handles them until Shutdown, handlers are cancelled when ctx is
 func (b *Bot) Start(ctx context.Context) {
 	config := tgbotapi.NewUpdate(0)
 	config.Timeout = 60
@@ -71,75 +224,161 @@ func (b *Bot) Start(ctx context.Context) {
 	go b.HandleUpdates(ctx, updates)
 }
 
-func (b *Bot) HandleUpdates(_ context.Context, updates tgbotapi.UpdatesChannel) {
-	for update := range updates {
-		user := update.SentFrom()
-		if user.ID != b.adminID {
-			continue
-		}
+// Shutdown stops polling and waits for the workers to handle the updates in their queues. Updates still in the
+// channel of polling are not taken, unless a later getUpdates confirmed them, they come again after the restart
+func (b *Bot) Shutdown(ctx context.Context) error {
+	b.stopOnce.Do(func() {
+		close(b.stopping)
+		b.api.StopReceivingUpdates()
+		b.cancelPoll()
+	})
+
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-		if ok, err := b.checkIfFirstHandle(update); err != nil {
-			fmt.Println(err)
-			continue
-		} else if !ok {
-			continue
-		}
+// Username is the username of the bot, the Telegram Login Widget is bound to it
+func (b *Bot) Username() string {
+	return b.api.Self.UserName
+}
 
-		state, err := b.getUserStateUsecase.Execute(user.ID)
-		if err != nil {
-			b.handleError(update.Message, err)
-			continue
-		}
+// IsAllowed reports whether the bot serves the user, other users are ignored
+func (b *Bot) IsAllowed(userID int64) bool {
+	return userID == b.adminID
+}
 
-		if state.Name == "" {
-			state.Name = entity.StartState
-		}
+// HandleUpdates passes updates to a pool of workers, chats are handled in parallel and updates of a chat in order
+func (b *Bot) HandleUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel) {
+	defer close(b.stopped)
 
-		state.ChatID = user.ID
-		if update.CallbackQuery != nil {
-			state.MessageID = &update.CallbackQuery.Message.MessageID
-		}
+	workerPool := newPool(workers, queueSize, b.handleUpdate)
+	workerPool.start(ctx)
+	defer workerPool.close()
 
-		state, err = stateNodes[state.Name].handleOut(state, update)
-		if err != nil {
-			b.handleError(update.Message, err)
-			continue
+	for {
+		// shutting down wins over updates waiting in the channel
+		select {
+		case <-b.stopping:
+			return
+		default:
 		}
 
-		err = b.saveUserStateUsecase.Execute(user.ID, state)
-		if err != nil {
-			b.handleError(update.Message, err)
-			continue
+		select {
+		case <-b.stopping:
+			return
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !workerPool.submit(ctx, b.stopping, update) {
+				return
+			}
 		}
+	}
+}
 
-		reply, err := stateNodes[state.Name].handleIn(state)
-		if err != nil {
-			b.handleError(update.Message, err)
-			continue
-		}
+// handleUpdate moves the user to the next state by the update and replies, log entries are tagged with the update
+func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	user := update.SentFrom()
+	if user == nil || !b.IsAllowed(user.ID) {
+		return
+	}
 
-		if reply != nil {
-			_, err = b.api.Send(reply)
-			if err != nil {
-				b.handleError(update.Message, err)
-				continue
-			}
+	start := time.Now()
+	traceID := logger.NewTraceID()
+	log := logger.Default().With("update_id", update.UpdateID, "user_id", user.ID, "trace_id", traceID)
+	ctx = logger.NewContext(ctx, log)
+
+	chatID := user.ID
+	if chat := update.FromChat(); chat != nil {
+		chatID = chat.ID
+	}
+
+	if ok, err := b.checkIfFirstHandle(ctx, update); err != nil {
+		log.Error("check if the update is new", "err", err)
+		return
+	} else if !ok {
+		log.Debug("skip the update handled before")
+		return
+	}
+
+	state, err := b.getUserStateUsecase.Execute(ctx, user.ID)
+	if err != nil {
+		b.handleError(log, traceID, chatID, err)
+		return
+	}
+
+	if state.Name == "" {
+		state.Name = entity.StartState
+	}
+	log = log.With("state", state.Name)
+	ctx = logger.NewContext(ctx, log)
+
+	state.ChatID = user.ID
+	if update.CallbackQuery != nil {
+		state.MessageID = &update.CallbackQuery.Message.MessageID
+	}
+
+	state, err = stateNodes[state.Name].handleOut(state, update)
+	if err != nil {
+		b.handleError(log, traceID, chatID, err)
+		return
+	}
+	log = log.With("next", state.Name)
+	ctx = logger.NewContext(ctx, log)
+
+	err = b.saveUserStateUsecase.Execute(ctx, user.ID, state)
+	if err != nil {
+		b.handleError(log, traceID, chatID, err)
+		return
+	}
+
+	err = b.handle(ctx, state)
+	if err != nil {
+		handlerErrors.Inc(state.Name)
+		b.handleError(log, traceID, chatID, err)
+		return
+	}
+
+	log.Debug("update handled", "duration", time.Since(start))
+}
+
+// handle runs the handler of the state the update led to and sends its reply
+func (b *Bot) handle(ctx context.Context, state entity.UserState) error {
+	defer handlerDuration.Since(time.Now(), state.Name)
+
+	reply, err := stateNodes[state.Name].handleIn(ctx, state)
+	if err != nil {
+		return err
+	}
+
+	if reply != nil {
+		_, err = b.api.Send(reply)
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-func (b *Bot) checkIfFirstHandle(update tgbotapi.Update) (bool, error) {
+func (b *Bot) checkIfFirstHandle(ctx context.Context, update tgbotapi.Update) (bool, error) {
 	id := "telegram"
 	if update.Message != nil {
 		id += strconv.FormatInt(update.Message.Chat.ID, 10) + strconv.Itoa(update.Message.MessageID)
 	} else if update.CallbackQuery != nil {
 		id += strconv.FormatInt(update.CallbackQuery.Message.Chat.ID, 10) + update.CallbackQuery.ID
 	}
-	return b.idempotenceUsecase.Execute(id)
+	return b.idempotenceUsecase.Execute(ctx, id)
 }
 
 func (b *Bot) fillStateNodes() {
-	stateNodes[entity.StartState].handleIn = func(state entity.UserState) (tgbotapi.Chattable, error) {
+	stateNodes[entity.StartState].handleIn = func(_ context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
 		return tgbotapi.NewMessage(state.ChatID, "Welcome to Enigma"), nil
 	}
 
@@ -148,20 +387,67 @@ func (b *Bot) fillStateNodes() {
 	stateNodes[entity.ListTransactionsState].handleIn = b.listTransactions
 
 	stateNodes[entity.ShowTransactionState].handleIn = b.showTransaction
+
+	stateNodes[entity.SearchTransactionsState].handleIn = b.searchTransactions
+
+	stateNodes[entity.ListTagsState].handleIn = b.listTags
+
+	stateNodes[entity.ListGoalsState].handleIn = b.listGoals
+	stateNodes[entity.CreateGoalState].handleIn = b.createGoal
+
+	stateNodes[entity.ListAccountsState].handleIn = b.listAccounts
+	stateNodes[entity.SetAccountTypeState].handleIn = b.setAccountType
+
+	stateNodes[entity.NetWorthState].handleIn = b.netWorth
+
+	stateNodes[entity.ForecastState].handleIn = b.forecast
+	stateNodes[entity.SetAccountFloorState].handleIn = b.setAccountFloor
+
+	stateNodes[entity.ImportFileState].handleIn = b.importFile
+	stateNodes[entity.ImportCSVState].handleIn = b.importCSV
+	stateNodes[entity.MapCategoriesState].handleIn = b.mapCategories
+	stateNodes[entity.ImportQIFState].handleIn = b.importQIF
+
+	stateNodes[entity.ExportState].handleIn = b.export
+
+	stateNodes[entity.ReceiptState].handleIn = b.receipt
+	stateNodes[entity.CreateReceiptTransactionState].handleIn = b.createReceiptTransaction
+
+	stateNodes[entity.NotificationState].handleIn = b.notification
+	stateNodes[entity.CreateNotificationTransactionState].handleIn = b.createNotificationTransaction
+	stateNodes[entity.NotificationTemplateState].handleIn = b.notificationTemplate
+	stateNodes[entity.ListNotificationTemplatesState].handleIn = b.listNotificationTemplates
+
+	stateNodes[entity.BackupState].handleIn = b.backup
+	stateNodes[entity.InspectBackupState].handleIn = b.inspectBackup
+	stateNodes[entity.RestoreBackupState].handleIn = b.restoreBackup
+
+	stateNodes[entity.CancelState].handleIn = b.cancel
+
+	stateNodes[entity.DuplicatesState].handleIn = b.duplicates
+	stateNodes[entity.DeleteDuplicateState].handleIn = b.deleteDuplicate
+	stateNodes[entity.DismissDuplicateState].handleIn = b.dismissDuplicate
 }
 
-func (b *Bot) createTransaction(state entity.UserState) (tgbotapi.Chattable, error) {
+func (b *Bot) createTransaction(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
 	transaction, err := makeTransactionFromArgs(state.Raw)
 	if err != nil {
 		return nil, err
 	}
 
-	err = b.createTransactionUsecase.Execute(transaction)
+	reply, err := b.checkDuplicates(ctx, state, transaction)
+	if err != nil || reply != nil {
+		return reply, err
+	}
+
+	_, err = b.createTransactionUsecase.Execute(ctx, transaction)
 	if err != nil {
 		return nil, err
 	}
 
-	return tgbotapi.NewMessage(state.ChatID, "Transaction created"), nil
+	b.notifyReachedGoals(ctx, state.ChatID)
+
+	return transactionCreated(state), nil
 }
 
 func makeTransactionFromArgs(args string) (entity.Transaction, error) {
@@ -186,36 +472,67 @@ func makeTransactionFromArgs(args string) (entity.Transaction, error) {
 	return transaction, nil
 }
 
-func (b *Bot) handleError(message *tgbotapi.Message, err error) {
-	if message == nil {
-		fmt.Println(err)
+// handleError tells the user what went wrong, unexpected errors are shown only by their trace ID,
+// their details are logged and sent to the admin if error reports are on
+func (b *Bot) handleError(log *logger.Logger, traceID string, chatID int64, err error) {
+	if !isUnexpected(err) {
+		log.Info("update rejected", "err", err)
+		b.sendError(log, chatID, err.Error())
 		return
 	}
 
-	_, err = b.api.Send(tgbotapi.NewMessage(message.Chat.ID, err.Error()))
+	log.Error("update failed", "err", err)
+
+	if b.reportErrors {
+		report := fmt.Sprintf("⚠️ Unexpected error, trace ID %s\n\n%v\n\n%s", traceID, err, log.Fields())
+		b.sendError(log, b.adminID, report)
+		if chatID == b.adminID {
+			return
+		}
+	}
+
+	b.sendError(log, chatID, "Something went wrong, trace ID "+traceID)
+}
+
+func (b *Bot) sendError(log *logger.Logger, chatID int64, text string) {
+	_, err := b.api.Send(tgbotapi.NewMessage(chatID, text))
 	if err != nil {
-		fmt.Println(err)
+		log.Error("send the error", "chat_id", chatID, "err", err)
 	}
 }
 
-func (b *Bot) listTransactions(state entity.UserState) (tgbotapi.Chattable, error) {
+// isUnexpected tells failures of the database, Telegram or the network from mistakes of the user, which are expected
+func isUnexpected(err error) bool {
+	var storageErr entity.StorageError
+	var apiErr *tgbotapi.Error
+	var netErr net.Error
+	return errors.As(err, &storageErr) || errors.As(err, &apiErr) || errors.As(err, &netErr)
+}
+
+func (b *Bot) listTransactions(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
 	if state.Date == nil {
 		return nil, errors.New("date is required")
 	}
 
-	transactions, err := b.getTransactionsByDate.Execute(*state.Date)
+	transactions, err := b.getTransactionsByDate.Execute(ctx, *state.Date, state.Tags)
 	if err != nil {
 		return nil, err
 	}
 
-	message := fmt.Sprintf("Transactions for %s:\n\n", state.Date.Format("02.01.2006"))
+	period := state.Date.Format("02.01.2006")
+	tagsSuffix := ""
+	for _, tag := range state.Tags {
+		tagsSuffix += " #" + tag
+	}
+
+	message := fmt.Sprintf("Transactions for %s%s:\n\n", period, tagsSuffix)
 	keyboard := newInlineKeyboard(5)
 
 	if len(transactions) == 0 {
-		message = "No transactions for " + state.Date.Format("02.01.2006")
+		message = "No transactions for " + period + tagsSuffix
 	} else {
 		for i, t := range transactions {
-			message += fmt.Sprintf("%d. %s -> %s %v RUB: %s\n\n", i+1, t.FromAccount, t.ToAccount, t.Amount, t.Description)
+			message += fmt.Sprintf("%d. %s -> %s %v %s: %s\n\n", i+1, t.FromAccount, t.ToAccount, t.Amount, t.CurrencyCode(), t.Description)
 			keyboard.addButton(strconv.Itoa(i+1), fmt.Sprintf("show %d", t.ID))
 		}
 		keyboard.fillLastRowWithEmptyButtons()
@@ -223,8 +540,8 @@ func (b *Bot) listTransactions(state entity.UserState) (tgbotapi.Chattable, erro
 		message += "Choose one to edit"
 	}
 
-	keyboard.addButton("⬅️", fmt.Sprintf("list %s", state.Date.AddDate(0, 0, -1).Format("02.01.2006")))
-	keyboard.addButton("➡️", fmt.Sprintf("list %s", state.Date.AddDate(0, 0, 1).Format("02.01.2006")))
+	keyboard.addButton("⬅️", fmt.Sprintf("list %s%s", state.Date.AddDate(0, 0, -1).Format("02.01.2006"), tagsSuffix))
+	keyboard.addButton("➡️", fmt.Sprintf("list %s%s", state.Date.AddDate(0, 0, 1).Format("02.01.2006"), tagsSuffix))
 
 	if state.MessageID != nil {
 		reply := tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, message)
@@ -237,14 +554,14 @@ func (b *Bot) listTransactions(state entity.UserState) (tgbotapi.Chattable, erro
 	return reply, nil
 }
 
-func (b *Bot) showTransaction(state entity.UserState) (tgbotapi.Chattable, error) {
+func (b *Bot) showTransaction(ctx context.Context, state entity.UserState) (tgbotapi.Chattable, error) {
 	if state.TransactionID == nil {
 		return nil, errors.New("transaction id is required")
 	}
 
 	transactionID := *state.TransactionID
 
-	transaction, err := b.getTransactionByID.Execute(transactionID)
+	transaction, err := b.getTransactionByID.Execute(ctx, transactionID)
 	if err != nil {
 		return nil, err
 	}
@@ -253,8 +570,11 @@ func (b *Bot) showTransaction(state entity.UserState) (tgbotapi.Chattable, error
 	message += fmt.Sprintf("Date: %s\n", transaction.Date.Format("02.01.2006"))
 	message += fmt.Sprintf("From: %s\n", transaction.FromAccount)
 	message += fmt.Sprintf("To: %s\n", transaction.ToAccount)
-	message += fmt.Sprintf("Amount: %v RUB\n", transaction.Amount)
+	message += fmt.Sprintf("Amount: %v %s\n", transaction.Amount, transaction.CurrencyCode())
 	message += fmt.Sprintf("Description: %s", transaction.Description)
+	if r := transaction.Receipt; r != nil {
+		message += fmt.Sprintf("\nReceipt: %s, FN %s, FD %s, FP %s", r.Time.Format(receiptTimeLayout), r.FN, r.FD, r.FP)
+	}
 
 	keyboard := newInlineKeyboard(3)
 	for _, t := range []string{"Date", "From", "To", "Amount", "Description"} {
@@ -264,6 +584,10 @@ func (b *Bot) showTransaction(state entity.UserState) (tgbotapi.Chattable, error
 	keyboard.addRow()
 	keyboard.addButton("↩", fmt.Sprintf("list %s", transaction.Date.Format("02.01.2006")))
 
+	if b.webAppURL != "" {
+		return nil, b.sendWithWebAppButton(state.ChatID, state.MessageID, message, keyboard, transaction.ID)
+	}
+
 	if state.MessageID != nil {
 		reply := tgbotapi.NewEditMessageText(state.ChatID, *state.MessageID, message)
 		reply.ReplyMarkup = keyboard.markup()
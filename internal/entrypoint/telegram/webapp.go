@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"enigma/internal/entity"
+)
+
+// webAppButton is an inline button opening the Mini App, the library has no such buttons yet
+type webAppButton struct {
+	Text   string `json:"text"`
+	WebApp struct {
+		URL string `json:"url"`
+	} `json:"web_app"`
+}
+
+// sendWithWebAppButton sends or edits the message of the transaction with a button opening it in the Mini App,
+// the Mini App refreshes the message once the transaction is saved, so a new message is sent first to learn its ID
+func (b *Bot) sendWithWebAppButton(chatID int64, messageID *int, text string, keyboard *inlineKeyboard, transactionID uint64) error {
+	params := tgbotapi.Params{}
+	params.AddFirstValid("chat_id", chatID)
+
+	if messageID == nil {
+		params["text"] = text
+		response, err := b.api.MakeRequest("sendMessage", params)
+		if err != nil {
+			return err
+		}
+
+		var message tgbotapi.Message
+		err = json.Unmarshal(response.Result, &message)
+		if err != nil {
+			return err
+		}
+
+		params = tgbotapi.Params{}
+		params.AddFirstValid("chat_id", chatID)
+		params.AddNonZero("message_id", message.MessageID)
+		return b.editReplyMarkup(params, keyboard, transactionID, message.MessageID)
+	}
+
+	params.AddNonZero("message_id", *messageID)
+	params["text"] = text
+	return b.editReplyMarkup(params, keyboard, transactionID, *messageID)
+}
+
+// editReplyMarkup edits the message with the keyboard following the button of the Mini App
+func (b *Bot) editReplyMarkup(params tgbotapi.Params, keyboard *inlineKeyboard, transactionID uint64, messageID int) error {
+	query := url.Values{}
+	query.Set("id", strconv.FormatUint(transactionID, 10))
+	query.Set("message", strconv.Itoa(messageID))
+
+	button := webAppButton{Text: "✏️ Edit in app"}
+	button.WebApp.URL = b.webAppURL + "/app/transaction?" + query.Encode()
+
+	rows := [][]interface{}{{button}}
+	for _, row := range keyboard.rows {
+		if len(row) == 0 {
+			continue
+		}
+
+		buttons := make([]interface{}, 0, len(row))
+		for _, button := range row {
+			buttons = append(buttons, button)
+		}
+		rows = append(rows, buttons)
+	}
+
+	err := params.AddInterface("reply_markup", map[string]interface{}{"inline_keyboard": rows})
+	if err != nil {
+		return err
+	}
+
+	method := "editMessageReplyMarkup"
+	if _, ok := params["text"]; ok {
+		method = "editMessageText"
+	}
+
+	_, err = b.api.MakeRequest(method, params)
+	return err
+}
+
+// RefreshTransaction shows the transaction again in the message, e.g. after it is edited in the Mini App
+func (b *Bot) RefreshTransaction(ctx context.Context, chatID int64, messageID int, transactionID uint64) error {
+	reply, err := b.showTransaction(ctx, entity.UserState{
+		ChatID:        chatID,
+		MessageID:     &messageID,
+		TransactionID: &transactionID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if reply != nil {
+		_, err = b.api.Send(reply)
+	}
+	return err
+}
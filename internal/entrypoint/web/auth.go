@@ -0,0 +1,179 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sessionCookie = "enigma_session"
+	sessionMaxAge = 30 * 24 * time.Hour
+
+	// loginMaxAge limits replaying a login link taken from the browser history
+	loginMaxAge = 24 * time.Hour
+)
+
+var (
+	invalidLoginErr   = errors.New("invalid Telegram login")
+	expiredLoginErr   = errors.New("the Telegram login has expired, sign in again")
+	forbiddenLoginErr = errors.New("this Telegram account has no access")
+)
+
+// checkLogin verifies data the Telegram Login Widget redirects with and returns the ID of the user,
+// the hash is HMAC-SHA256 of the sorted fields keyed with SHA256 of the bot token,
+// see https://core.telegram.org/widgets/login#checking-authorization
+func (s *Server) checkLogin(query url.Values, now time.Time) (int64, error) {
+	hash, err := hex.DecodeString(query.Get("hash"))
+	if err != nil || len(hash) == 0 {
+		return 0, invalidLoginErr
+	}
+
+	fields := make([]string, 0, len(query))
+	for key := range query {
+		if key != "hash" {
+			fields = append(fields, key+"="+query.Get(key))
+		}
+	}
+	sort.Strings(fields)
+
+	secret := sha256.Sum256([]byte(s.botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	if !hmac.Equal(mac.Sum(nil), hash) {
+		return 0, invalidLoginErr
+	}
+
+	authDate, err := strconv.ParseInt(query.Get("auth_date"), 10, 64)
+	if err != nil {
+		return 0, invalidLoginErr
+	}
+	if now.Sub(time.Unix(authDate, 0)) > loginMaxAge {
+		return 0, expiredLoginErr
+	}
+
+	id, err := strconv.ParseInt(query.Get("id"), 10, 64)
+	if err != nil {
+		return 0, invalidLoginErr
+	}
+	if !s.isAllowed(id) {
+		return 0, forbiddenLoginErr
+	}
+
+	return id, nil
+}
+
+// newSession returns the value of the session cookie of the user, "<user id>.<expiry unix time>.<hex signature>"
+func (s *Server) newSession(userID int64, expires time.Time) string {
+	payload := strconv.FormatInt(userID, 10) + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + hex.EncodeToString(s.signSession(payload))
+}
+
+// sessionUser returns the user of a valid session cookie value
+func (s *Server) sessionUser(value string, now time.Time) (int64, bool) {
+	i := strings.LastIndex(value, ".")
+	if i < 0 {
+		return 0, false
+	}
+
+	payload := value[:i]
+	signature, err := hex.DecodeString(value[i+1:])
+	if err != nil || !hmac.Equal(s.signSession(payload), signature) {
+		return 0, false
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.After(time.Unix(expires, 0)) {
+		return 0, false
+	}
+
+	// the admin may have changed since the session started
+	return userID, s.isAllowed(userID)
+}
+
+// signSession signs with a key derived from the bot token, so sessions outlive restarts and end when the token is revoked
+func (s *Server) signSession(payload string) []byte {
+	key := hmac.New(sha256.New, []byte(s.botToken))
+	key.Write([]byte("enigma web session"))
+
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err == nil {
+			if _, ok := s.sessionUser(cookie.Value, time.Now()); ok {
+				next(w, r)
+				return
+			}
+		}
+
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	}
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	s.render(w, "login.html", struct {
+		BotUsername string
+		Error       string
+	}{s.botUsername, r.URL.Query().Get("error")})
+}
+
+// telegramAuth is where the Login Widget redirects with the signed user data
+func (s *Server) telegramAuth(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	userID, err := s.checkLogin(r.URL.Query(), now)
+	if err != nil {
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	expires := now.Add(sessionMaxAge)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    s.newSession(userID, expires),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// isHTTPS also trusts the header of a reverse proxy, the Login Widget only works on a public domain which is usually behind one
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
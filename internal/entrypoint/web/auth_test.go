@@ -0,0 +1,114 @@
+package web
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBotToken = "123456:TEST-token"
+
+// authDate is auth_date of the vectors, they are signed with testBotToken outside of this package
+var authDate = time.Unix(1700000000, 0)
+
+func newTestServer() *Server {
+	return &Server{
+		botToken:  testBotToken,
+		isAllowed: func(userID int64) bool { return userID == 42 },
+	}
+}
+
+func loginQuery(hash string, override ...string) url.Values {
+	query := url.Values{
+		"id":         {"42"},
+		"first_name": {"Ann"},
+		"username":   {"ann"},
+		"auth_date":  {"1700000000"},
+		"hash":       {hash},
+	}
+	for i := 0; i+1 < len(override); i += 2 {
+		query.Set(override[i], override[i+1])
+	}
+	return query
+}
+
+const loginHash = "1f8b8b478e653c79711d0e4b73187c8831312f701d1f5cb5c52a11bd0e3188c0"
+
+func TestCheckLogin(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		now   time.Time
+		want  int64
+		err   error
+	}{
+		{"valid", loginQuery(loginHash), authDate.Add(time.Minute), 42, nil},
+		{"tampered hash", loginQuery("0f8b8b478e653c79711d0e4b73187c8831312f701d1f5cb5c52a11bd0e3188c0"), authDate, 0, invalidLoginErr},
+		{"tampered field", loginQuery(loginHash, "id", "43"), authDate, 0, invalidLoginErr},
+		{"added field", loginQuery(loginHash, "photo_url", "https://t.me/i/userpic/ann.jpg"), authDate, 0, invalidLoginErr},
+		{"hash is not hex", loginQuery("not-a-hash"), authDate, 0, invalidLoginErr},
+		{"no hash", loginQuery(""), authDate, 0, invalidLoginErr},
+		{"expired", loginQuery(loginHash), authDate.Add(loginMaxAge + time.Second), 0, expiredLoginErr},
+	}
+
+	s := newTestServer()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := s.checkLogin(test.query, test.now)
+			if err != test.err || id != test.want {
+				t.Errorf("checkLogin() = %d, %v, want %d, %v", id, err, test.want, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckLoginOfForbiddenUser(t *testing.T) {
+	s := newTestServer()
+	s.isAllowed = func(int64) bool { return false }
+
+	_, err := s.checkLogin(loginQuery(loginHash), authDate)
+	if err != forbiddenLoginErr {
+		t.Errorf("checkLogin() error %v, want %v", err, forbiddenLoginErr)
+	}
+}
+
+func TestSessionUser(t *testing.T) {
+	s := newTestServer()
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(sessionMaxAge)
+
+	valid := s.newSession(42, expires)
+	payload := valid[:strings.LastIndex(valid, ".")]
+	signature := valid[strings.LastIndex(valid, ".")+1:]
+
+	other := newTestServer()
+	other.botToken = "654321:OTHER-token"
+
+	tests := []struct {
+		name   string
+		value  string
+		now    time.Time
+		want   int64
+		wantOK bool
+	}{
+		{"valid", valid, now, 42, true},
+		{"expired", valid, expires.Add(time.Second), 0, false},
+		{"forged user", "1." + strings.TrimPrefix(payload, "42.") + "." + signature, now, 0, false},
+		{"extended expiry", "42." + "9999999999." + signature, now, 0, false},
+		{"signed with another token", other.newSession(42, expires), now, 0, false},
+		{"not allowed user", s.newSession(7, expires), now, 0, false},
+		{"no signature", payload, now, 0, false},
+		{"signature is not hex", payload + ".zz", now, 0, false},
+		{"empty", "", now, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, ok := s.sessionUser(test.value, test.now)
+			if ok != test.wantOK || (ok && id != test.want) {
+				t.Errorf("sessionUser() = %d, %t, want %d, %t", id, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// charts are drawn as inline SVG on the server, so the dashboard needs no scripts
+const (
+	chartWidth   = 720
+	chartHeight  = 240
+	chartPadding = 32
+)
+
+type chartLabel struct {
+	X, Y float64
+	Text string
+}
+
+type lineChart struct {
+	Width, Height int
+
+	// Points is the polyline of values, empty if there is nothing to draw
+	Points string
+	Dots   []chartLabel
+	Labels []chartLabel
+
+	// ZeroY is the position of the zero line if it is within the range of values
+	ZeroY *float64
+
+	Min, Max float64
+}
+
+func newLineChart(labels []string, values []float64) lineChart {
+	chart := lineChart{Width: chartWidth, Height: chartHeight}
+	if len(values) == 0 {
+		return chart
+	}
+
+	chart.Min, chart.Max = values[0], values[0]
+	for _, v := range values {
+		chart.Min = math.Min(chart.Min, v)
+		chart.Max = math.Max(chart.Max, v)
+	}
+
+	span := chart.Max - chart.Min
+	if span == 0 {
+		span = 1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartPadding)
+	plotHeight := float64(chartHeight - 2*chartPadding)
+
+	x := func(i int) float64 {
+		if len(values) == 1 {
+			return chartWidth / 2
+		}
+		return round(chartPadding + plotWidth*float64(i)/float64(len(values)-1))
+	}
+	y := func(v float64) float64 {
+		return round(chartPadding + plotHeight*(chart.Max-v)/span)
+	}
+
+	points := make([]string, 0, len(values))
+	for i, v := range values {
+		points = append(points, fmt.Sprintf("%v,%v", x(i), y(v)))
+		chart.Dots = append(chart.Dots, chartLabel{X: x(i), Y: y(v), Text: labels[i] + ": " + formatMoney(v)})
+		chart.Labels = append(chart.Labels, chartLabel{X: x(i), Y: chartHeight - chartPadding/3, Text: labels[i]})
+	}
+	chart.Points = strings.Join(points, " ")
+
+	if chart.Min < 0 && chart.Max > 0 {
+		zero := y(0)
+		chart.ZeroY = &zero
+	}
+
+	return chart
+}
+
+type bar struct {
+	Label string
+	Value float64
+
+	// Percent is the length of the bar relative to the longest one
+	Percent float64
+}
+
+func newBars(labels []string, values []float64) []bar {
+	longest := 0.0
+	for _, v := range values {
+		longest = math.Max(longest, math.Abs(v))
+	}
+
+	bars := make([]bar, 0, len(values))
+	for i, v := range values {
+		percent := 0.0
+		if longest > 0 {
+			percent = 100 * math.Abs(v) / longest
+		}
+		bars = append(bars, bar{Label: labels[i], Value: v, Percent: percent})
+	}
+	return bars
+}
+
+// round keeps a tenth of a pixel, more only bloats the page
+func round(f float64) float64 {
+	return math.Round(f*10) / 10
+}
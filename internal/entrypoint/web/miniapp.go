@@ -0,0 +1,279 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/logger"
+)
+
+// requests of the Mini App are authenticated with the initData Telegram signs instead of a dashboard session
+const (
+	initDataScheme = "tma "
+	initDataMaxAge = 24 * time.Hour
+
+	maxParts = 20
+)
+
+var (
+	invalidInitDataErr = errors.New("invalid Mini App init data")
+	expiredInitDataErr = errors.New("the Mini App has been open too long, open it from the bot again")
+)
+
+// checkInitData verifies initData of the Mini App and returns the ID of the user,
+// see https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func (s *Server) checkInitData(initData string, now time.Time) (int64, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return 0, invalidInitDataErr
+	}
+
+	hash, err := hex.DecodeString(values.Get("hash"))
+	if err != nil || len(hash) == 0 {
+		return 0, invalidInitDataErr
+	}
+
+	fields := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			fields = append(fields, key+"="+values.Get(key))
+		}
+	}
+	sort.Strings(fields)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(s.botToken))
+
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	if !hmac.Equal(mac.Sum(nil), hash) {
+		return 0, invalidInitDataErr
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return 0, invalidInitDataErr
+	}
+	if now.Sub(time.Unix(authDate, 0)) > initDataMaxAge {
+		return 0, expiredInitDataErr
+	}
+
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	err = json.Unmarshal([]byte(values.Get("user")), &user)
+	if err != nil || user.ID == 0 {
+		return 0, invalidInitDataErr
+	}
+	if !s.isAllowed(user.ID) {
+		return 0, forbiddenLoginErr
+	}
+
+	return user.ID, nil
+}
+
+type miniAppTransaction struct {
+	ID          uint64  `json:"id"`
+	Date        string  `json:"date"`
+	FromAccount string  `json:"from_account"`
+	ToAccount   string  `json:"to_account"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Description string  `json:"description"`
+	HasReceipt  bool    `json:"has_receipt"`
+}
+
+func newMiniAppTransaction(t entity.Transaction) miniAppTransaction {
+	return miniAppTransaction{
+		ID:          t.ID,
+		Date:        t.Date.Format("2006-01-02"),
+		FromAccount: t.FromAccount,
+		ToAccount:   t.ToAccount,
+		Amount:      t.Amount,
+		Currency:    t.CurrencyCode(),
+		Description: t.Description,
+		HasReceipt:  t.Receipt != nil,
+	}
+}
+
+type miniAppPart struct {
+	ToAccount   string  `json:"to_account"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+// miniAppRequest is the edited transaction, more than one part splits it into several transactions
+type miniAppRequest struct {
+	Date        string        `json:"date"`
+	FromAccount string        `json:"from_account"`
+	Currency    string        `json:"currency"`
+	Parts       []miniAppPart `json:"parts"`
+
+	// MessageID is the message the Mini App was opened from, it is refreshed after saving
+	MessageID int `json:"message_id"`
+}
+
+// transactions validates the request and returns the parts as transactions, the date is kept if it is the same day
+func (r miniAppRequest) transactions(previous entity.Transaction) ([]entity.Transaction, error) {
+	date := previous.Date
+	if r.Date != date.Format("2006-01-02") {
+		var err error
+		date, err = time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s", r.Date)
+		}
+	}
+
+	from := strings.TrimSpace(r.FromAccount)
+	if from == "" {
+		return nil, errors.New("from account is required")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(r.Currency))
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, fmt.Errorf("invalid currency %s", r.Currency)
+	}
+	if currency == entity.DefaultCurrency {
+		currency = ""
+	}
+
+	if len(r.Parts) == 0 || len(r.Parts) > maxParts {
+		return nil, fmt.Errorf("from 1 to %d parts are expected", maxParts)
+	}
+
+	transactions := make([]entity.Transaction, 0, len(r.Parts))
+	for i, part := range r.Parts {
+		to := strings.TrimSpace(part.ToAccount)
+		if to == "" {
+			return nil, fmt.Errorf("to account of part %d is required", i+1)
+		}
+		if math.IsNaN(part.Amount) || math.IsInf(part.Amount, 0) || part.Amount <= 0 {
+			return nil, fmt.Errorf("amount of part %d must be a positive number", i+1)
+		}
+
+		transactions = append(transactions, entity.Transaction{
+			Date:        date,
+			FromAccount: from,
+			ToAccount:   to,
+			Amount:      part.Amount,
+			Currency:    currency,
+			Description: strings.TrimSpace(part.Description),
+		})
+	}
+	transactions[0].ID = previous.ID
+
+	return transactions, nil
+}
+
+func (s *Server) miniApp(w http.ResponseWriter, r *http.Request) {
+	file := strings.TrimPrefix(r.URL.Path, "/app/")
+	contentType := map[string]string{
+		"transaction": "text/html; charset=utf-8",
+		"miniapp.js":  "text/javascript; charset=utf-8",
+	}[file]
+	if contentType == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if file == "transaction" {
+		file = "miniapp.html"
+	}
+
+	content, err := staticFiles.ReadFile("static/" + file)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(content)
+}
+
+// miniAppTransaction reads and saves the transaction edited in the Mini App
+func (s *Server) miniAppTransaction(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.checkInitData(strings.TrimPrefix(r.Header.Get("Authorization"), initDataScheme), time.Now())
+	if err != nil {
+		writeMiniAppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/app/api/transactions/"), 10, 64)
+	if err != nil {
+		writeMiniAppError(w, http.StatusNotFound, entity.TransactionNotFoundErr)
+		return
+	}
+
+	previous, err := s.getTransactionByID.Execute(r.Context(), id)
+	if errors.Is(err, entity.TransactionNotFoundErr) {
+		writeMiniAppError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		writeMiniAppJSON(w, newMiniAppTransaction(previous))
+		return
+	}
+
+	var request miniAppRequest
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&request)
+	if err != nil {
+		writeMiniAppError(w, http.StatusBadRequest, errors.New("invalid JSON"))
+		return
+	}
+
+	transactions, err := request.transactions(previous)
+	if err != nil {
+		writeMiniAppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	saved, err := s.splitTransactionUsecase.Execute(r.Context(), transactions)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	if request.MessageID != 0 {
+		refreshErr := s.refreshTransaction(r.Context(), userID, request.MessageID, saved[0].ID)
+		if refreshErr != nil {
+			logger.Default().Error("refresh the message of the transaction", "transaction_id", saved[0].ID, "err", refreshErr)
+		}
+	}
+
+	responses := make([]miniAppTransaction, 0, len(saved))
+	for _, t := range saved {
+		responses = append(responses, newMiniAppTransaction(t))
+	}
+	writeMiniAppJSON(w, struct {
+		Transactions []miniAppTransaction `json:"transactions"`
+	}{responses})
+}
+
+func writeMiniAppJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeMiniAppError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
@@ -0,0 +1,50 @@
+package web
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// initData is signed with testBotToken and the "WebAppData" key outside of this package
+const initData = "auth_date=1700000000&query_id=AAHdF6IQAAAAAN0XohDhrOrc" +
+	"&user=%7B%22id%22%3A42%2C%22first_name%22%3A%22Ann%22%7D" +
+	"&hash=52407c82c620cc43263aa26aaad1a36a526424d10a760a4adb02c8b5caefe70d"
+
+func TestCheckInitData(t *testing.T) {
+	tests := []struct {
+		name     string
+		initData string
+		now      time.Time
+		want     int64
+		err      error
+	}{
+		{"valid", initData, authDate.Add(time.Hour), 42, nil},
+		{"tampered user", strings.Replace(initData, "%3A42", "%3A43", 1), authDate, 0, invalidInitDataErr},
+		{"tampered hash", strings.Replace(initData, "hash=5", "hash=6", 1), authDate, 0, invalidInitDataErr},
+		{"added field", initData + "&start_param=x", authDate, 0, invalidInitDataErr},
+		{"no hash", initData[:strings.Index(initData, "&hash=")], authDate, 0, invalidInitDataErr},
+		{"not a query", "%zz", authDate, 0, invalidInitDataErr},
+		{"expired", initData, authDate.Add(initDataMaxAge + time.Second), 0, expiredInitDataErr},
+	}
+
+	s := newTestServer()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := s.checkInitData(test.initData, test.now)
+			if err != test.err || id != test.want {
+				t.Errorf("checkInitData() = %d, %v, want %d, %v", id, err, test.want, test.err)
+			}
+		})
+	}
+}
+
+// TestCheckInitDataIsNotLoginWidgetHash makes sure data signed the Login Widget way is not accepted by the Mini App
+func TestCheckInitDataIsNotLoginWidgetHash(t *testing.T) {
+	s := newTestServer()
+
+	_, err := s.checkInitData("auth_date=1700000000&first_name=Ann&id=42&username=ann&hash="+loginHash, authDate)
+	if err != invalidInitDataErr {
+		t.Errorf("checkInitData() error %v, want %v", err, invalidInitDataErr)
+	}
+}
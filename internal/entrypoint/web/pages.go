@@ -0,0 +1,224 @@
+package web
+
+import (
+	"html/template"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+const (
+	pageSize = 50
+
+	netWorthMonths = 12
+	topTags        = 10
+)
+
+var templateFuncs = template.FuncMap{
+	"money": formatMoney,
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+}
+
+// formatMoney groups thousands with thin spaces, e.g. 1 234 567.89
+func formatMoney(amount float64) string {
+	s := strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)
+	whole, fraction := s[:len(s)-3], s[len(s)-3:]
+
+	var b strings.Builder
+	if amount <= -0.005 {
+		b.WriteString("−")
+	}
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(" ")
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(fraction)
+	return b.String()
+}
+
+type dashboardPage struct {
+	Today    time.Time
+	Balances []entity.AccountBalance
+	NetWorth lineChart
+	Tags     []bar
+}
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	today := time.Now().UTC()
+	balances, err := s.getBalancesUsecase.Execute(r.Context(), today)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	points, err := s.getNetWorthHistoryUsecase.Execute(r.Context(), netWorthMonths)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	tags, err := s.getTagsUsecase.Execute(r.Context())
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	s.render(w, "dashboard.html", dashboardPage{
+		Today:    today,
+		Balances: balances,
+		NetWorth: newNetWorthChart(points),
+		Tags:     newTagBars(tags),
+	})
+}
+
+type transactionsPage struct {
+	Query  string
+	Period string
+	From   string
+	To     string
+	Error  string
+
+	Transactions []entity.Transaction
+	Total        int
+	First        int
+	Last         int
+	PrevURL      string
+	NextURL      string
+}
+
+// transactions shows transactions matching the filters newest first, page by page
+func (s *Server) transactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := transactionsPage{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Period: strings.TrimSpace(query.Get("period")),
+		From:   strings.TrimSpace(query.Get("from")),
+		To:     strings.TrimSpace(query.Get("to")),
+	}
+
+	offset, err := strconv.Atoi(query.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	filter, err := page.filter()
+	if err != nil {
+		page.Error = err.Error()
+		s.render(w, "transactions.html", page)
+		return
+	}
+
+	page.Transactions, page.Total, err = s.searchTransactionsUsecase.Execute(r.Context(), filter, offset, pageSize)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	if len(page.Transactions) > 0 {
+		page.First, page.Last = offset+1, offset+len(page.Transactions)
+	}
+	if offset > 0 {
+		prev := offset - pageSize
+		if prev < 0 {
+			prev = 0
+		}
+		page.PrevURL = page.url(prev)
+	}
+	if offset+pageSize < page.Total {
+		page.NextURL = page.url(offset + pageSize)
+	}
+
+	s.render(w, "transactions.html", page)
+}
+
+// filter combines the search query with the other fields, every transaction is shown without any
+func (p transactionsPage) filter() (entity.TransactionFilter, error) {
+	filter, err := entity.ParseTransactionFilter(p.Query)
+	if err != nil {
+		return entity.TransactionFilter{}, err
+	}
+
+	if p.From != "" {
+		filter.FromAccount = p.From
+	}
+	if p.To != "" {
+		filter.ToAccount = p.To
+	}
+
+	period := p.Period
+	if period == "" && filter.Since == nil {
+		period = "all"
+	}
+	if period != "" {
+		since, until, err := entity.ParsePeriod(period)
+		if err != nil {
+			return entity.TransactionFilter{}, err
+		}
+		filter.Since, filter.Until = &since, &until
+	}
+
+	return filter, nil
+}
+
+func (p transactionsPage) url(offset int) string {
+	values := url.Values{}
+	for key, value := range map[string]string{"q": p.Query, "period": p.Period, "from": p.From, "to": p.To} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if offset > 0 {
+		values.Set("offset", strconv.Itoa(offset))
+	}
+
+	if len(values) == 0 {
+		return "/transactions"
+	}
+	return "/transactions?" + values.Encode()
+}
+
+// newTagBars compares top tags in the default currency, totals in other currencies can't be drawn on the same scale
+func newTagBars(tags []entity.TagSummary) []bar {
+	values := make([]float64, 0, topTags)
+	labels := make([]string, 0, topTags)
+	for _, tag := range tags {
+		if tag.Currency != entity.DefaultCurrency {
+			continue
+		}
+		if len(values) == topTags {
+			break
+		}
+		values = append(values, tag.Total)
+		labels = append(labels, "#"+tag.Tag)
+	}
+	return newBars(labels, values)
+}
+
+func newNetWorthChart(points []entity.NetWorthPoint) lineChart {
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Month.Before(points[j].Month)
+	})
+
+	values := make([]float64, 0, len(points))
+	labels := make([]string, 0, len(points))
+	for _, p := range points {
+		values = append(values, p.NetWorth())
+		labels = append(labels, p.Month.Format("Jan 06"))
+	}
+	return newLineChart(labels, values)
+}
@@ -0,0 +1,160 @@
+// Package web serves a read-only dashboard of the ledger, users sign in with their Telegram account,
+// and the Mini App editing transactions from the bot
+package web
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"enigma/internal/httpserver"
+	"enigma/internal/logger"
+	"enigma/internal/usecase"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+//go:embed static
+var staticFiles embed.FS
+
+// contentSecurityPolicy allows nothing from other origins except the Telegram Login Widget
+const contentSecurityPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https://t.me https://telegram.org; " +
+	"script-src https://telegram.org; frame-src https://oauth.telegram.org; frame-ancestors 'none'; form-action 'self'"
+
+// miniAppSecurityPolicy allows the script of Telegram Mini Apps and Telegram Web showing the Mini App in a frame
+const miniAppSecurityPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'; script-src 'self' https://telegram.org; " +
+	"frame-ancestors https://web.telegram.org"
+
+type Server struct {
+	*httpserver.Server
+	pages map[string]*template.Template
+
+	botToken    string
+	botUsername string
+
+	// isAllowed is the check of the bot, so the dashboard is open to the same users the bot answers
+	isAllowed func(userID int64) bool
+
+	// refreshTransaction shows the transaction saved in the Mini App in the message of the bot it was opened from
+	refreshTransaction func(ctx context.Context, chatID int64, messageID int, transactionID uint64) error
+
+	getTransactionByID        *usecase.GetTransactionByID
+	splitTransactionUsecase   *usecase.SplitTransaction
+	searchTransactionsUsecase *usecase.SearchTransactions
+	getBalancesUsecase        *usecase.GetBalances
+	getTagsUsecase            *usecase.GetTags
+	getNetWorthHistoryUsecase *usecase.GetNetWorthHistory
+}
+
+func New(
+	addr string,
+	botToken string,
+	botUsername string,
+	isAllowed func(userID int64) bool,
+	refreshTransaction func(ctx context.Context, chatID int64, messageID int, transactionID uint64) error,
+	getTransactionByID *usecase.GetTransactionByID,
+	splitTransactionUsecase *usecase.SplitTransaction,
+	searchTransactionsUsecase *usecase.SearchTransactions,
+	getBalancesUsecase *usecase.GetBalances,
+	getTagsUsecase *usecase.GetTags,
+	getNetWorthHistoryUsecase *usecase.GetNetWorthHistory,
+) (*Server, error) {
+	if botToken == "" || botUsername == "" {
+		return nil, errors.New("the dashboard requires the bot to sign in with Telegram")
+	}
+
+	s := &Server{
+		pages: make(map[string]*template.Template),
+
+		botToken:    botToken,
+		botUsername: botUsername,
+		isAllowed:   isAllowed,
+
+		refreshTransaction: refreshTransaction,
+
+		getTransactionByID:        getTransactionByID,
+		splitTransactionUsecase:   splitTransactionUsecase,
+		searchTransactionsUsecase: searchTransactionsUsecase,
+		getBalancesUsecase:        getBalancesUsecase,
+		getTagsUsecase:            getTagsUsecase,
+		getNetWorthHistoryUsecase: getNetWorthHistoryUsecase,
+	}
+
+	// every page is parsed with the layout separately, so their "content" templates don't clash
+	for _, page := range []string{"login.html", "dashboard.html", "transactions.html"} {
+		t, err := template.New(page).Funcs(templateFuncs).ParseFS(templateFiles, "templates/layout.html", "templates/"+page)
+		if err != nil {
+			return nil, err
+		}
+		s.pages[page] = t
+	}
+
+	s.Server = httpserver.New("the dashboard", addr, s.Handler())
+
+	return s, nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", onlyMethod(http.MethodGet, s.login))
+	mux.HandleFunc("/auth/telegram", onlyMethod(http.MethodGet, s.telegramAuth))
+	mux.HandleFunc("/logout", onlyMethod(http.MethodPost, s.logout))
+	mux.HandleFunc("/transactions", onlyMethod(http.MethodGet, s.authenticate(s.transactions)))
+	mux.HandleFunc("/", onlyMethod(http.MethodGet, s.authenticate(s.dashboard)))
+
+	mux.HandleFunc("/app/", onlyMethod(http.MethodGet, s.miniApp))
+	mux.HandleFunc("/app/api/transactions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.miniAppTransaction(w, r)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := contentSecurityPolicy
+		if strings.HasPrefix(r.URL.Path, "/app/") {
+			policy = miniAppSecurityPolicy
+		}
+
+		w.Header().Set("Content-Security-Policy", policy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "same-origin")
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func onlyMethod(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// render executes the page into a buffer first, so an error doesn't leave a half written page
+func (s *Server) render(w http.ResponseWriter, page string, data interface{}) {
+	var buf bytes.Buffer
+	err := s.pages[page].ExecuteTemplate(&buf, "layout", data)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+func (s *Server) internalError(w http.ResponseWriter, err error) {
+	logger.Default().Error("dashboard request failed", "err", err)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
@@ -0,0 +1,114 @@
+// The form of the Mini App editing a transaction, the bot opens it with the transaction and its message in the URL
+(function () {
+  "use strict";
+
+  var app = window.Telegram.WebApp;
+  var query = new URLSearchParams(location.search);
+  var id = query.get("id");
+  var messageID = parseInt(query.get("message"), 10) || 0;
+
+  var form = document.getElementById("form");
+  var parts = document.getElementById("parts");
+
+  function request(method, body) {
+    return fetch("/app/api/transactions/" + encodeURIComponent(id), {
+      method: method,
+      headers: { "Authorization": "tma " + app.initData, "Content-Type": "application/json" },
+      body: body ? JSON.stringify(body) : undefined
+    }).then(function (response) {
+      return response.json().then(function (data) {
+        if (!response.ok) {
+          throw new Error(data.error || response.statusText);
+        }
+        return data;
+      });
+    });
+  }
+
+  function showError(err) {
+    var error = document.getElementById("error");
+    error.textContent = err.message;
+    error.hidden = false;
+  }
+
+  function addPart(part) {
+    var fieldset = document.getElementById("part").content.firstElementChild.cloneNode(true);
+    fieldset.querySelector("[name=to_account]").value = part.to_account || "";
+    fieldset.querySelector("[name=amount]").value = part.amount || "";
+    fieldset.querySelector("[name=description]").value = part.description || "";
+    fieldset.querySelector(".remove").addEventListener("click", function () {
+      fieldset.remove();
+      update();
+    });
+    parts.appendChild(fieldset);
+    update();
+  }
+
+  // update numbers parts, the first part can't be removed as it keeps the transaction
+  function update() {
+    var total = 0;
+    Array.prototype.forEach.call(parts.children, function (fieldset, i) {
+      fieldset.querySelector(".number").textContent = i + 1;
+      fieldset.querySelector(".remove").hidden = i === 0;
+      total += parseFloat(fieldset.querySelector("[name=amount]").value) || 0;
+    });
+    document.getElementById("total").textContent = total.toFixed(2) + " " + form.currency.value.toUpperCase();
+  }
+
+  function read() {
+    return {
+      date: form.date.value,
+      from_account: form.from_account.value,
+      currency: form.currency.value,
+      message_id: messageID,
+      parts: Array.prototype.map.call(parts.children, function (fieldset) {
+        return {
+          to_account: fieldset.querySelector("[name=to_account]").value,
+          amount: parseFloat(fieldset.querySelector("[name=amount]").value),
+          description: fieldset.querySelector("[name=description]").value
+        };
+      })
+    };
+  }
+
+  // split moves half of the last part to a new one, so the total stays the same
+  document.getElementById("split").addEventListener("click", function () {
+    var last = parts.lastElementChild.querySelector("[name=amount]");
+    var amount = parseFloat(last.value) || 0;
+    var half = Math.floor(amount * 50) / 100;
+    last.value = (amount - half).toFixed(2);
+    addPart({ amount: half.toFixed(2) });
+  });
+
+  form.addEventListener("input", update);
+
+  app.MainButton.setText("Save");
+  app.MainButton.onClick(function () {
+    if (!form.reportValidity()) {
+      return;
+    }
+
+    app.MainButton.showProgress();
+    request("PUT", read()).then(function () {
+      app.close();
+    }).catch(function (err) {
+      app.MainButton.hideProgress();
+      showError(err);
+    });
+  });
+
+  request("GET").then(function (t) {
+    document.getElementById("title").textContent = "Transaction #" + t.id;
+    form.date.value = t.date;
+    form.currency.value = t.currency;
+    form.from_account.value = t.from_account;
+    document.getElementById("receipt").hidden = !t.has_receipt;
+    addPart(t);
+
+    form.hidden = false;
+    app.MainButton.show();
+  }).catch(showError);
+
+  app.ready();
+  app.expand();
+})();
@@ -0,0 +1,136 @@
+// Package exporter writes transactions to files for spreadsheets and other finance software
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"enigma/internal/entity"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Writer writes transactions one by one, Flush must be called after the last one
+type Writer interface {
+	Write(entity.Transaction) error
+	Flush() error
+}
+
+// CSVOptions configure the CSV dialect, spreadsheets of locales with decimal comma expect ";" as a delimiter
+type CSVOptions struct {
+	Delimiter        rune
+	DecimalSeparator string
+}
+
+type csvWriter struct {
+	w         *csv.Writer
+	decimal   string
+	hasHeader bool
+}
+
+var csvHeader = []string{"id", "date", "from", "to", "amount", "currency", "description"}
+
+func NewCSV(w io.Writer, options CSVOptions) (Writer, error) {
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	if options.DecimalSeparator == "" {
+		options.DecimalSeparator = "."
+	}
+	if options.DecimalSeparator != "." && options.DecimalSeparator != "," {
+		return nil, fmt.Errorf("unsupported decimal separator %s", options.DecimalSeparator)
+	}
+	if string(options.Delimiter) == options.DecimalSeparator {
+		return nil, errors.New("delimiter and decimal separator must differ")
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = options.Delimiter
+
+	return &csvWriter{w: writer, decimal: options.DecimalSeparator}, nil
+}
+
+func (c *csvWriter) Write(t entity.Transaction) error {
+	if !c.hasHeader {
+		err := c.w.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+		c.hasHeader = true
+	}
+
+	amount := strconv.FormatFloat(t.Amount, 'f', -1, 64)
+	if c.decimal != "." {
+		amount = strings.Replace(amount, ".", c.decimal, 1)
+	}
+
+	return c.w.Write([]string{
+		strconv.FormatUint(t.ID, 10),
+		t.Date.Format("2006-01-02"),
+		t.FromAccount,
+		t.ToAccount,
+		amount,
+		t.CurrencyCode(),
+		t.Description,
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	if !c.hasHeader {
+		err := c.w.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+		c.hasHeader = true
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONL writes a transaction per line in the format read back by importer.ParseJSONL
+func NewJSONL(w io.Writer) Writer {
+	buffered := bufio.NewWriter(w)
+	return &jsonlWriter{w: buffered, enc: json.NewEncoder(buffered)}
+}
+
+func (j *jsonlWriter) Write(t entity.Transaction) error {
+	return j.enc.Encode(t)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// ParseDelimiter accepts a delimiter character or its name, e.g. "tab" or "semicolon"
+func ParseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "", "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %s", s)
+	}
+	return r, nil
+}
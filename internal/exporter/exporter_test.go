@@ -0,0 +1,163 @@
+package exporter_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/exporter"
+	"enigma/internal/importer"
+)
+
+func day(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+var transactions = []entity.Transaction{
+	{ID: 1, Date: day("2022-05-01"), FromAccount: "card", ToAccount: "pharmacy", Amount: 1250.5, Description: `Аптека "36,6"`, Tags: []string{"health"}},
+	{ID: 2, Date: day("2022-05-03"), FromAccount: "cash:eur", ToAccount: "cafe", Amount: 12, Currency: "EUR", Description: "coffee; cake\nand tea"},
+}
+
+func write(t *testing.T, w exporter.Writer, transactions []entity.Transaction) {
+	t.Helper()
+
+	for _, transaction := range transactions {
+		err := w.Write(transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := w.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		options exporter.CSVOptions
+		want    string
+	}{
+		{exporter.CSVOptions{}, "id,date,from,to,amount,currency,description\n" +
+			"1,2022-05-01,card,pharmacy,1250.5,RUB,\"Аптека \"\"36,6\"\"\"\n" +
+			"2,2022-05-03,cash:eur,cafe,12,EUR,\"coffee; cake\nand tea\"\n"},
+		{exporter.CSVOptions{Delimiter: ';', DecimalSeparator: ","}, "id;date;from;to;amount;currency;description\n" +
+			"1;2022-05-01;card;pharmacy;1250,5;RUB;\"Аптека \"\"36,6\"\"\"\n" +
+			"2;2022-05-03;cash:eur;cafe;12;EUR;\"coffee; cake\nand tea\"\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := exporter.NewCSV(&buf, tt.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(t, w, transactions)
+
+		if buf.String() != tt.want {
+			t.Errorf("%+v: exported\n%s\nwant\n%s", tt.options, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestCSVWithoutTransactionsHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := exporter.NewCSV(&buf, exporter.CSVOptions{Delimiter: '\t'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, nil)
+
+	if want := "id\tdate\tfrom\tto\tamount\tcurrency\tdescription\n"; buf.String() != want {
+		t.Errorf("exported %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNewCSVRejectsOptions(t *testing.T) {
+	for _, options := range []exporter.CSVOptions{
+		{DecimalSeparator: "'"},
+		{Delimiter: ',', DecimalSeparator: ","},
+	} {
+		_, err := exporter.NewCSV(&bytes.Buffer{}, options)
+		if err == nil {
+			t.Errorf("%+v are accepted", options)
+		}
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := map[string]rune{
+		"":          ',',
+		"comma":     ',',
+		"Semicolon": ';',
+		"tab":       '\t',
+		`\t`:        '\t',
+		"|":         '|',
+		"¦":         '¦',
+	}
+	for s, want := range tests {
+		got, err := exporter.ParseDelimiter(s)
+		if err != nil || got != want {
+			t.Errorf("ParseDelimiter(%q) = %q, %v, want %q", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{`"`, "\n", "ab", "\xff"} {
+		_, err := exporter.ParseDelimiter(s)
+		if err == nil {
+			t.Errorf("delimiter %q is accepted", s)
+		}
+	}
+}
+
+func TestJSONLRoundTrip(t *testing.T) {
+	// the same purchase twice a day is two transactions
+	exported := append(transactions, transactions[1])
+	exported[2].ID = 3
+
+	var buf bytes.Buffer
+	write(t, exporter.NewJSONL(&buf), exported)
+
+	imported, err := importer.ParseJSONL(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != len(exported) {
+		t.Fatalf("%d transactions are imported, want %d", len(imported), len(exported))
+	}
+
+	keys := make(map[string]bool)
+	for i, got := range imported {
+		want := exported[i]
+		// IDs are assigned again and tags are extracted again on import
+		want.ID = 0
+		want.Tags = nil
+
+		g := got.Transaction
+		if !g.Date.Equal(want.Date) || g.FromAccount != want.FromAccount || g.ToAccount != want.ToAccount ||
+			g.Amount != want.Amount || g.Currency != want.Currency || g.Description != want.Description || g.ID != 0 || g.Tags != nil {
+			t.Errorf("transaction %d\n got %+v\nwant %+v", i, g, want)
+		}
+
+		if keys[got.Key] {
+			t.Errorf("transaction %d has the key of another one", i)
+		}
+		keys[got.Key] = true
+	}
+
+	// importing the same export again gives the same keys, so it creates nothing
+	again, err := importer.ParseJSONL(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range again {
+		if again[i].Key != imported[i].Key {
+			t.Errorf("transaction %d has key %s on the second import, %s on the first", i, again[i].Key, imported[i].Key)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"enigma/internal/entity"
+)
+
+const (
+	FormatLedger    = "ledger"
+	FormatHledger   = "hledger"
+	FormatBeancount = "beancount"
+)
+
+// journalDialect holds everything which differs between plain-text accounting formats
+type journalDialect struct {
+	dateLayout string
+
+	accountName func(entity.Account) string
+	declare     func(w *bufio.Writer, name string, account entity.Account, opened time.Time)
+	tags        func(w *bufio.Writer, t entity.Transaction)
+	description func(entity.Transaction) string
+	amount      func(amount float64, currency string) string
+}
+
+// journalWriter writes a journal of transactions passed oldest first, accounts are declared
+// in the header sorted by name, so exports of the same data are identical
+type journalWriter struct {
+	w       *bufio.Writer
+	dialect journalDialect
+
+	accounts []entity.Account
+	names    map[string]string
+
+	hasHeader bool
+}
+
+// NewJournal writes a journal in one of the plain-text accounting formats, accounts are declared
+// at the start of the journal, accounts of transactions which are not among them are declared untyped
+func NewJournal(w io.Writer, format string, accounts []entity.Account) (Writer, error) {
+	var dialect journalDialect
+	switch format {
+	case FormatLedger:
+		dialect = ledgerDialect
+	case FormatHledger:
+		dialect = hledgerDialect
+	case FormatBeancount:
+		dialect = beancountDialect
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+
+	sorted := make([]entity.Account, len(accounts))
+	copy(sorted, accounts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return &journalWriter{
+		w:        bufio.NewWriter(w),
+		dialect:  dialect,
+		accounts: sorted,
+		names:    journalAccountNames(sorted, dialect.accountName),
+	}, nil
+}
+
+// journalAccountNames escapes account names, names which become equal after escaping get a numeric suffix
+func journalAccountNames(accounts []entity.Account, escape func(entity.Account) string) map[string]string {
+	names := make(map[string]string, len(accounts))
+	used := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		name := escape(account)
+		for i := 2; used[name]; i++ {
+			name = escape(account) + "-" + strconv.Itoa(i)
+		}
+		used[name] = true
+		names[account.Name] = name
+	}
+	return names
+}
+
+func (j *journalWriter) Write(t entity.Transaction) error {
+	if !j.hasHeader {
+		j.writeHeader(t.Date)
+	}
+
+	j.w.WriteString(t.Date.Format(j.dialect.dateLayout))
+	j.w.WriteString(" * ")
+	j.w.WriteString(j.dialect.description(t))
+	j.w.WriteString("\n")
+
+	j.dialect.tags(j.w, t)
+
+	currency := t.CurrencyCode()
+	j.writePosting(t.ToAccount, j.dialect.amount(t.Amount, currency))
+	j.writePosting(t.FromAccount, j.dialect.amount(-t.Amount, currency))
+
+	_, err := j.w.WriteString("\n")
+	return err
+}
+
+func (j *journalWriter) writePosting(account, amount string) {
+	j.w.WriteString("    ")
+	j.w.WriteString(j.accountName(account))
+	j.w.WriteString("  ")
+	j.w.WriteString(amount)
+	j.w.WriteString("\n")
+}
+
+func (j *journalWriter) accountName(name string) string {
+	if escaped, ok := j.names[name]; ok {
+		return escaped
+	}
+	return j.dialect.accountName(entity.Account{Name: name, Type: entity.InferAccountType(name)})
+}
+
+// writeHeader declares accounts opened at the date of the first transaction, which is the oldest one
+func (j *journalWriter) writeHeader(opened time.Time) {
+	for _, account := range j.accounts {
+		j.dialect.declare(j.w, j.names[account.Name], account, opened)
+	}
+	if len(j.accounts) > 0 {
+		j.w.WriteString("\n")
+	}
+	j.hasHeader = true
+}
+
+func (j *journalWriter) Flush() error {
+	if !j.hasHeader {
+		j.writeHeader(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))
+	}
+	return j.w.Flush()
+}
+
+var ledgerDialect = journalDialect{
+	dateLayout:  "2006/01/02",
+	accountName: ledgerAccountName,
+	declare: func(w *bufio.Writer, name string, _ entity.Account, _ time.Time) {
+		w.WriteString("account " + name + "\n")
+	},
+	tags:        ledgerTags,
+	description: ledgerDescription,
+	amount:      ledgerAmount,
+}
+
+var hledgerDialect = journalDialect{
+	dateLayout:  "2006-01-02",
+	accountName: ledgerAccountName,
+	declare: func(w *bufio.Writer, name string, account entity.Account, _ time.Time) {
+		w.WriteString("account " + name)
+		if t := hledgerAccountTypes[account.Type]; t != "" {
+			w.WriteString("  ; type: " + t)
+		}
+		w.WriteString("\n")
+	},
+	tags:        hledgerTags,
+	description: ledgerDescription,
+	amount:      ledgerAmount,
+}
+
+var hledgerAccountTypes = map[entity.AccountType]string{
+	entity.AssetAccount:     "A",
+	entity.LiabilityAccount: "L",
+	entity.EquityAccount:    "E",
+	entity.IncomeAccount:    "R",
+	entity.ExpenseAccount:   "X",
+}
+
+// ledgerAccountName collapses whitespace, since two spaces end an account name in a posting,
+// and brackets around the name, which make a posting virtual
+func ledgerAccountName(account entity.Account) string {
+	name := strings.Join(strings.Fields(account.Name), " ")
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '(', ')', '[', ']', ';':
+			return '-'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// ledgerDescription keeps the description on one line, out of the code "(...)" and status "!" positions,
+// and without ";" which starts a comment in hledger
+func ledgerDescription(t entity.Transaction) string {
+	description := strings.Join(strings.Fields(t.Description), " ")
+	description = strings.ReplaceAll(description, ";", ",")
+	if strings.HasPrefix(description, "(") || strings.HasPrefix(description, "!") || strings.HasPrefix(description, "*") {
+		description = "- " + description
+	}
+	return description
+}
+
+func ledgerTags(w *bufio.Writer, t entity.Transaction) {
+	if len(t.Tags) == 0 {
+		return
+	}
+	w.WriteString("    ; :" + strings.Join(t.Tags, ":") + ":\n")
+}
+
+func hledgerTags(w *bufio.Writer, t entity.Transaction) {
+	if len(t.Tags) == 0 {
+		return
+	}
+	w.WriteString("    ; " + strings.Join(t.Tags, ":, ") + ":\n")
+}
+
+func ledgerAmount(amount float64, currency string) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64) + " " + currency
+}
+
+var beancountDialect = journalDialect{
+	dateLayout:  "2006-01-02",
+	accountName: beancountAccountName,
+	declare: func(w *bufio.Writer, name string, _ entity.Account, opened time.Time) {
+		w.WriteString(opened.Format("2006-01-02") + " open " + name + "\n")
+	},
+	// beancount tags are written after the narration
+	tags: func(*bufio.Writer, entity.Transaction) {},
+	description: func(t entity.Transaction) string {
+		description := beancountString(strings.Join(strings.Fields(t.Description), " "))
+		for _, tag := range t.Tags {
+			if tag := beancountTag(tag); tag != "" {
+				description += " #" + tag
+			}
+		}
+		return description
+	},
+	amount: ledgerAmount,
+}
+
+var beancountRoots = map[entity.AccountType]string{
+	entity.AssetAccount:     "Assets",
+	entity.LiabilityAccount: "Liabilities",
+	entity.EquityAccount:    "Equity",
+	entity.IncomeAccount:    "Income",
+	entity.ExpenseAccount:   "Expenses",
+}
+
+// beancountAccountName puts the account under the root of its type, untyped accounts go to Assets,
+// every component must start with a capital letter or a digit and hold only letters, digits and dashes
+func beancountAccountName(account entity.Account) string {
+	root, ok := beancountRoots[account.Type]
+	if !ok {
+		root = beancountRoots[entity.AssetAccount]
+	}
+
+	components := strings.Split(account.Name, ":")
+	if account.Type != entity.UnknownAccount && account.Type == entity.InferAccountType(account.Name) {
+		components = components[1:]
+	}
+
+	name := root
+	for _, component := range components {
+		component = strings.Map(func(r rune) rune {
+			if r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '-'
+		}, strings.TrimSpace(component))
+		if component == "" {
+			continue
+		}
+
+		runes := []rune(component)
+		runes[0] = unicode.ToUpper(runes[0])
+		if !unicode.IsUpper(runes[0]) && !unicode.IsDigit(runes[0]) {
+			runes = append([]rune{'X'}, runes...)
+		}
+		name += ":" + string(runes)
+	}
+	return name
+}
+
+func beancountString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// beancountTag returns tag if beancount accepts it, tags in other scripts stay in the narration only
+func beancountTag(tag string) string {
+	for _, r := range tag {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_/.", r)) {
+			return ""
+		}
+	}
+	return tag
+}
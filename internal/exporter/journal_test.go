@@ -0,0 +1,125 @@
+package exporter_test
+
+import (
+	"bytes"
+	"testing"
+
+	"enigma/internal/entity"
+	"enigma/internal/exporter"
+)
+
+var accounts = []entity.Account{
+	{Name: "pharmacy", Type: entity.ExpenseAccount},
+	{Name: "card", Type: entity.AssetAccount},
+	{Name: "cafe", Type: entity.ExpenseAccount},
+	{Name: "cash:eur"},
+}
+
+func TestJournal(t *testing.T) {
+	tests := map[string]string{
+		exporter.FormatLedger: `account cafe
+account card
+account cash:eur
+account pharmacy
+
+2022/05/01 * Аптека "36,6"
+    ; :health:
+    pharmacy  1250.5 RUB
+    card  -1250.5 RUB
+
+2022/05/03 * coffee, cake and tea
+    cafe  12 EUR
+    cash:eur  -12 EUR
+
+`,
+		exporter.FormatHledger: `account cafe  ; type: X
+account card  ; type: A
+account cash:eur
+account pharmacy  ; type: X
+
+2022-05-01 * Аптека "36,6"
+    ; health:
+    pharmacy  1250.5 RUB
+    card  -1250.5 RUB
+
+2022-05-03 * coffee, cake and tea
+    cafe  12 EUR
+    cash:eur  -12 EUR
+
+`,
+		exporter.FormatBeancount: `2022-05-01 open Expenses:Cafe
+2022-05-01 open Assets:Card
+2022-05-01 open Assets:Cash:Eur
+2022-05-01 open Expenses:Pharmacy
+
+2022-05-01 * "Аптека \"36,6\"" #health
+    Expenses:Pharmacy  1250.5 RUB
+    Assets:Card  -1250.5 RUB
+
+2022-05-03 * "coffee; cake and tea"
+    Expenses:Cafe  12 EUR
+    Assets:Cash:Eur  -12 EUR
+
+`,
+	}
+
+	for format, want := range tests {
+		// the same data is exported identically every time
+		for i := 0; i < 2; i++ {
+			var buf bytes.Buffer
+			w, err := exporter.NewJournal(&buf, format, accounts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			write(t, w, transactions)
+
+			if buf.String() != want {
+				t.Errorf("%s: exported\n%s\nwant\n%s", format, buf.String(), want)
+			}
+		}
+	}
+}
+
+func TestJournalEscapesBeancountAccountNames(t *testing.T) {
+	tests := map[entity.Account]string{
+		{Name: "expenses:food:café", Type: entity.ExpenseAccount}: "Expenses:Food:Café",
+		{Name: "credit card", Type: entity.LiabilityAccount}:      "Liabilities:Credit-card",
+		{Name: "2022 trip"}: "Assets:2022-trip",
+		{Name: "_savings"}:  "Assets:X-savings",
+		{Name: "wallet:$"}:  "Assets:Wallet:X-",
+		{Name: "salary (pt)", Type: entity.IncomeAccount}: "Income:Salary--pt-",
+	}
+
+	for account, want := range tests {
+		var buf bytes.Buffer
+		w, err := exporter.NewJournal(&buf, exporter.FormatBeancount, []entity.Account{account})
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(t, w, nil)
+
+		if got := buf.String(); got != "1970-01-01 open "+want+"\n\n" {
+			t.Errorf("%+v is declared as %q, want %s", account, got, want)
+		}
+	}
+}
+
+func TestJournalSuffixesEqualEscapedNames(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := exporter.NewJournal(&buf, exporter.FormatLedger, []entity.Account{{Name: "cash (usd)"}, {Name: "cash [usd]"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, w, nil)
+
+	if want := "account cash -usd-\naccount cash -usd--2\n\n"; buf.String() != want {
+		t.Errorf("exported %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNewJournalRejectsUnknownFormat(t *testing.T) {
+	_, err := exporter.NewJournal(&bytes.Buffer{}, "gnucash", nil)
+	if err == nil {
+		t.Error("unknown format is accepted")
+	}
+}
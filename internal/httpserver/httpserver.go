@@ -0,0 +1,66 @@
+// Package httpserver runs the HTTP servers of the entrypoints, the API, the dashboard and monitoring, the same way
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"enigma/internal/logger"
+)
+
+type Server struct {
+	// name tells servers apart in logs, e.g. "the API"
+	name   string
+	server *http.Server
+
+	listener net.Listener
+}
+
+func New(name, addr string, handler http.Handler) *Server {
+	return &Server{
+		name: name,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start listens on the address and serves in the background, an error of listening is returned,
+// errors of serving are logged
+func (s *Server) Start(ctx context.Context) error {
+	// requests are cancelled along with ctx, e.g. when they outlast shutting down
+	s.server.BaseContext = func(net.Listener) context.Context {
+		return ctx
+	}
+
+	// the address is listened on right away, so the server is accepting connections once Start returns
+	addr := s.server.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	var err error
+	s.listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("serve %s: %w", s.name, err)
+	}
+
+	go func() {
+		err := s.server.Serve(s.listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Default().Error("serve "+s.name, "addr", s.server.Addr, "err", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for the requests being served, until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,87 @@
+package httpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForRequests(t *testing.T) {
+	served := make(chan struct{})
+	s := New("test", "127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(served)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	}))
+	err := s.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := make(chan string, 1)
+	go func() {
+		r, err := http.Get("http://" + s.listener.Addr().String())
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer r.Body.Close()
+		body, _ := io.ReadAll(r.Body)
+		response <- string(body)
+	}()
+
+	<-served
+	err = s.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := <-response; body != "done" {
+		t.Errorf("request being served is answered with %q", body)
+	}
+}
+
+func TestRequestsAreCancelledWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := New("test", "127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		select {
+		case <-r.Context().Done():
+			io.WriteString(w, "cancelled")
+		case <-time.After(time.Second):
+			io.WriteString(w, "not cancelled")
+		}
+	}))
+	err := s.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	r, err := http.Get("http://" + s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	body, _ := io.ReadAll(r.Body)
+	if string(body) != "cancelled" {
+		t.Errorf("request is %s along with the context of the server", body)
+	}
+}
+
+func TestStartFailsOnAddressInUse(t *testing.T) {
+	first := New("first", "127.0.0.1:0", http.NotFoundHandler())
+	err := first.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Shutdown(context.Background())
+
+	second := New("second", first.listener.Addr().String(), http.NotFoundHandler())
+	err = second.Start(context.Background())
+	if err == nil {
+		second.Shutdown(context.Background())
+		t.Fatal("the second server started on the address of the first one")
+	}
+}
@@ -0,0 +1,290 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+// camtAmount is an amount with its currency, e.g. <Amt Ccy="EUR">12.50</Amt>
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.PartyName
+}
+
+type camtAccount struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtTxDetails struct {
+	AccountServicerRef string      `xml:"Refs>AcctSvcrRef"`
+	EndToEndID         string      `xml:"Refs>EndToEndId"`
+	Amount             *camtAmount `xml:"Amt"`
+	TxAmount           *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	CreditDebit        string      `xml:"CdtDbtInd"`
+	Debtor             camtParty   `xml:"RltdPties>Dbtr"`
+	Creditor           camtParty   `xml:"RltdPties>Cdtr"`
+	Unstructured       []string    `xml:"RmtInf>Ustrd"`
+	CreditorReference  string      `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	AdditionalInfo     string      `xml:"AddtlTxInf"`
+}
+
+// camtStatus is a plain code before camt.053.001.08 and a <Cd> element since
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtEntry struct {
+	Ref                string          `xml:"NtryRef"`
+	Amount             camtAmount      `xml:"Amt"`
+	CreditDebit        string          `xml:"CdtDbtInd"`
+	Status             camtStatus      `xml:"Sts"`
+	BookingDate        camtDate        `xml:"BookgDt"`
+	ValueDate          camtDate        `xml:"ValDt"`
+	AccountServicerRef string          `xml:"AcctSvcrRef"`
+	AdditionalInfo     string          `xml:"AddtlNtryInf"`
+	Details            []camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+// ParseCamt reads ISO 20022 camt.053 statements and camt.052 reports, see ScanCamt
+func ParseCamt(r io.Reader, options Options) ([]entity.ImportedTransaction, error) {
+	var imported []entity.ImportedTransaction
+	err := ScanCamt(r, options, func(t entity.ImportedTransaction) error {
+		imported = append(imported, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// ScanCamt streams booked entries of camt.053 and camt.052 documents to fn without loading the whole
+// document, batch bookings become a transaction per entry detail and the account servicer's
+// reference of the entry is the deduplication key
+func ScanCamt(r io.Reader, options Options, fn func(entity.ImportedTransaction) error) error {
+	options = options.withDefaults()
+	decoder := xml.NewDecoder(r)
+
+	account := options.Account
+	var found bool
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("camt: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Stmt", "Rpt":
+			found = true
+			account = options.Account
+		case "Acct":
+			var acct camtAccount
+			err = decoder.DecodeElement(&acct, &start)
+			if err != nil {
+				return fmt.Errorf("camt: %w", err)
+			}
+			if options.Account == "" {
+				account = acct.IBAN
+				if account == "" {
+					account = acct.Other
+				}
+			}
+		case "Ntry":
+			var entry camtEntry
+			err = decoder.DecodeElement(&entry, &start)
+			if err != nil {
+				return fmt.Errorf("camt: %w", err)
+			}
+
+			if account == "" {
+				account = options.DefaultAccount
+			}
+			if account == "" {
+				return errors.New("camt: statement has no account")
+			}
+
+			transactions, err := makeCamtTransactions(entry, account, options)
+			if err != nil {
+				return err
+			}
+			for _, t := range transactions {
+				err = fn(t)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if !found {
+		return errors.New("camt: no statements or reports")
+	}
+	return nil
+}
+
+func makeCamtTransactions(entry camtEntry, account string, options Options) ([]entity.ImportedTransaction, error) {
+	status := strings.TrimSpace(entry.Status.Code)
+	if status == "" {
+		status = strings.TrimSpace(entry.Status.Text)
+	}
+	// pending and informational entries of intraday reports are booked later with the same reference
+	if status != "" && status != "BOOK" {
+		return nil, nil
+	}
+
+	date, err := parseCamtDate(entry.BookingDate)
+	if err != nil {
+		date, err = parseCamtDate(entry.ValueDate)
+		if err != nil {
+			return nil, fmt.Errorf("camt: entry %s: no booking date", entry.Ref)
+		}
+	}
+
+	ref := entry.AccountServicerRef
+	if ref == "" {
+		ref = entry.Ref
+	}
+	if ref == "" {
+		ref = hashKey("entry", date.Format(time.RFC3339), entry.Amount.Value, entry.CreditDebit, entry.AdditionalInfo)
+	}
+	key := "camt:" + account + ":" + ref
+
+	// batch bookings are split only when every detail carries its own amount
+	split := len(entry.Details) > 1
+	for _, details := range entry.Details {
+		if details.amount() == nil {
+			split = false
+		}
+	}
+
+	if !split {
+		var details camtTxDetails
+		if len(entry.Details) == 1 {
+			details = entry.Details[0]
+		}
+
+		t, err := makeCamtTransaction(date, entry.Amount, entry.CreditDebit, details, entry.AdditionalInfo, account, options)
+		if err != nil {
+			return nil, fmt.Errorf("camt: entry %s: %w", ref, err)
+		}
+		return []entity.ImportedTransaction{{Key: key, Transaction: t}}, nil
+	}
+
+	imported := make([]entity.ImportedTransaction, 0, len(entry.Details))
+	for i, details := range entry.Details {
+		creditDebit := details.CreditDebit
+		if creditDebit == "" {
+			creditDebit = entry.CreditDebit
+		}
+
+		t, err := makeCamtTransaction(date, *details.amount(), creditDebit, details, entry.AdditionalInfo, account, options)
+		if err != nil {
+			return nil, fmt.Errorf("camt: entry %s: %w", ref, err)
+		}
+		imported = append(imported, entity.ImportedTransaction{
+			Key:         key + ":" + strconv.Itoa(i),
+			Transaction: t,
+		})
+	}
+	return imported, nil
+}
+
+func makeCamtTransaction(
+	date time.Time,
+	amount camtAmount,
+	creditDebit string,
+	details camtTxDetails,
+	additionalInfo string,
+	account string,
+	options Options,
+) (entity.Transaction, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(amount.Value), 64)
+	if err != nil {
+		return entity.Transaction{}, fmt.Errorf("invalid amount %s", amount.Value)
+	}
+
+	counterparty := details.Debtor.name()
+	switch creditDebit {
+	case "DBIT":
+		value = -value
+		counterparty = details.Creditor.name()
+	case "CRDT":
+	default:
+		return entity.Transaction{}, fmt.Errorf("invalid credit/debit indicator %s", creditDebit)
+	}
+
+	info := strings.Join(details.Unstructured, " ")
+	if info == "" {
+		info = details.CreditorReference
+	}
+	if info == "" {
+		info = details.AdditionalInfo
+	}
+	if info == "" {
+		info = additionalInfo
+	}
+
+	t := options.transaction(date, value, account, collapseSpaces(counterparty+" "+info))
+	t.Currency = amount.Currency
+	if t.Currency == entity.DefaultCurrency {
+		t.Currency = ""
+	}
+	return t, nil
+}
+
+func (d camtTxDetails) amount() *camtAmount {
+	if d.Amount != nil {
+		return d.Amount
+	}
+	return d.TxAmount
+}
+
+func parseCamtDate(d camtDate) (time.Time, error) {
+	if d.Date != "" {
+		return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+	}
+	if d.DateTime != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+			date, err := time.Parse(layout, strings.TrimSpace(d.DateTime))
+			if err == nil {
+				return date.UTC(), nil
+			}
+		}
+	}
+	return time.Time{}, errors.New("no date")
+}
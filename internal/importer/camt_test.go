@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"enigma/internal/entity"
+)
+
+func TestParseCamt(t *testing.T) {
+	imported, err := ParseCamt(openTestdata(t, "statement.camt053.xml"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const iban = "DE89370400440532013000"
+
+	// the pending entry is booked later and imported then
+	checkTransactions(t, transactionsOf(imported), []entity.Transaction{
+		{Date: date("2022-05-02"), FromAccount: iban, ToAccount: "expenses", Amount: 42.9, Currency: "EUR", Description: "REWE Markt GmbH Groceries #food"},
+		{Date: date("2022-05-05"), FromAccount: "income", ToAccount: iban, Amount: 3200, Currency: "EUR", Description: "Example AG Salary May 2022"},
+		{Date: date("2022-05-10"), FromAccount: iban, ToAccount: "expenses", Amount: 100, Currency: "EUR", Description: "Stadtwerke Electricity"},
+		{Date: date("2022-05-10"), FromAccount: iban, ToAccount: "expenses", Amount: 150, Currency: "EUR", Description: "Telekom RF18539007547034"},
+		{Date: date("2022-05-20 12:30"), FromAccount: iban, ToAccount: "expenses", Amount: 19.99, Currency: "USD", Description: "GitHub Inc Subscription"},
+	})
+
+	keys := []string{
+		"camt:" + iban + ":2022050200001",
+		"camt:" + iban + ":2022050500002",
+		"camt:" + iban + ":2022051000003:0",
+		"camt:" + iban + ":2022051000003:1",
+		"camt:" + iban + ":2022052000004",
+	}
+	for i := range imported {
+		if i < len(keys) && imported[i].Key != keys[i] {
+			t.Errorf("transaction %d has key %s, want %s", i, imported[i].Key, keys[i])
+		}
+	}
+}
+
+func TestParseCamtAccountOptions(t *testing.T) {
+	imported, err := ParseCamt(openTestdata(t, "statement.camt053.xml"), Options{Account: "checking", IncomeAccount: "salary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	salary := imported[1]
+	if salary.Transaction.FromAccount != "salary" || salary.Transaction.ToAccount != "checking" || salary.Key != "camt:checking:2022050500002" {
+		t.Errorf("account options are not applied: %+v", salary)
+	}
+}
+
+func TestParseCamtRejectsInvalidDocuments(t *testing.T) {
+	tests := map[string]string{
+		"not a statement": `<Document><BkToCstmrDbtCdtNtfctn/></Document>`,
+		"not XML":         `<Document><Stmt>`,
+		"invalid indicator": `<Document><Stmt><Acct><Id><IBAN>DE1</IBAN></Id></Acct>` +
+			`<Ntry><Amt Ccy="EUR">1</Amt><CdtDbtInd>X</CdtDbtInd><BookgDt><Dt>2022-05-01</Dt></BookgDt></Ntry></Stmt></Document>`,
+		"no date": `<Document><Stmt><Acct><Id><IBAN>DE1</IBAN></Id></Acct>` +
+			`<Ntry><Amt Ccy="EUR">1</Amt><CdtDbtInd>DBIT</CdtDbtInd></Ntry></Stmt></Document>`,
+	}
+	for name, document := range tests {
+		_, err := ParseCamt(strings.NewReader(document), Options{})
+		if err == nil {
+			t.Errorf("%s: document is parsed", name)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"enigma/internal/entity"
+)
+
+// ParseCSV reads a bank CSV export mapped by profile, every row gets a key hashed
+// from its content, so the same row is recognized when statements overlap
+func ParseCSV(r io.Reader, profile entity.ImportProfile) ([]entity.ImportedTransaction, error) {
+	rows, _, err := readCSV(r, profile.Delimiter, profile.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile.Account == "" {
+		return nil, errors.New("profile account is required")
+	}
+
+	options := Options{
+		Account:        profile.Account,
+		ExpenseAccount: profile.ExpenseAccount,
+		IncomeAccount:  profile.IncomeAccount,
+	}.withDefaults()
+
+	var imported []entity.ImportedTransaction
+	occurrences := make(map[string]int)
+
+	for i, row := range rows {
+		if i < profile.SkipRows {
+			continue
+		}
+
+		line := i + 1
+		dateField, err := column(row, profile.DateColumn, line)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(dateField) == "" {
+			continue
+		}
+
+		date, err := time.Parse(profile.DateFormat, strings.TrimSpace(dateField))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date %s", line, dateField)
+		}
+
+		amountField, err := column(row, profile.AmountColumn, line)
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := ParseAmountWithSeparator(amountField, profile.DecimalSeparator)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", line, err)
+		}
+		if amount == 0 {
+			continue
+		}
+
+		var description string
+		if profile.DescriptionColumn >= 0 {
+			description, err = column(row, profile.DescriptionColumn, line)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		transaction := options.transaction(date.UTC(), amount, profile.Account, collapseSpaces(description))
+
+		// identical rows in one statement are different purchases, e.g. two coffees on the same day
+		content := strings.Join(row, "\x1f")
+		occurrences[content]++
+
+		imported = append(imported, entity.ImportedTransaction{
+			Key:         hashKey("csv", profile.Account, content, strconv.Itoa(occurrences[content])),
+			Transaction: transaction,
+		})
+	}
+
+	return imported, nil
+}
+
+// PreviewCSV returns the first rows of a CSV file to choose the column mapping,
+// an empty delimiter is detected from the first line
+func PreviewCSV(r io.Reader, delimiter, encoding string, limit int) ([][]string, string, error) {
+	rows, comma, err := readCSV(r, delimiter, encoding)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return rows, string(comma), nil
+}
+
+func readCSV(r io.Reader, delimiter, encoding string) ([][]string, rune, error) {
+	decoded, err := Decode(r, encoding)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	raw, err := io.ReadAll(decoded)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !utf8.Valid(raw) {
+		return nil, 0, errors.New("file is not valid UTF-8, choose another encoding")
+	}
+
+	comma := detectDelimiter(firstLine(string(raw)))
+	if delimiter != "" {
+		comma, _ = utf8.DecodeRuneInString(unescapeDelimiter(delimiter))
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(raw)))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, 0, err
+	}
+	return rows, comma, nil
+}
+
+func column(row []string, index, line int) (string, error) {
+	if index < 0 || index >= len(row) {
+		return "", fmt.Errorf("row %d: no column %d", line, index)
+	}
+	return row[index], nil
+}
+
+func unescapeDelimiter(delimiter string) string {
+	switch delimiter {
+	case `\t`, "tab":
+		return "\t"
+	case "semicolon":
+		return ";"
+	case "comma":
+		return ","
+	default:
+		return delimiter
+	}
+}
+
+func detectDelimiter(line string) rune {
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{';', ',', '\t', '|'} {
+		if count := strings.Count(line, string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
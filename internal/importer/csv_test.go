@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"enigma/internal/entity"
+)
+
+func TestParseCSV(t *testing.T) {
+	// a Windows-1251 export delimited with semicolons, with a header of two lines and column names
+	imported, err := ParseCSV(openTestdata(t, "statement-cp1251.csv"), entity.ImportProfile{
+		Delimiter:         "semicolon",
+		Encoding:          "windows-1251",
+		DateFormat:        "02.01.2006",
+		SkipRows:          3,
+		DateColumn:        0,
+		DescriptionColumn: 1,
+		AmountColumn:      2,
+		Account:           "card",
+		ExpenseAccount:    "expenses",
+		IncomeAccount:     "income",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the row of zero amount and the total without a date are skipped
+	checkTransactions(t, transactionsOf(imported), []entity.Transaction{
+		{Date: date("2022-05-03"), FromAccount: "card", ToAccount: "expenses", Amount: 1250, Description: "Пятёрочка продукты"},
+		{Date: date("2022-05-05"), FromAccount: "income", ToAccount: "card", Amount: 50000, Description: "Зарплата"},
+		{Date: date("2022-05-07"), FromAccount: "card", ToAccount: "expenses", Amount: 350.5, Description: `Кафе "Ёлка"`},
+		{Date: date("2022-05-07"), FromAccount: "card", ToAccount: "expenses", Amount: 350.5, Description: `Кафе "Ёлка"`},
+	})
+
+	// identical rows are different purchases, so they get different keys
+	if imported[2].Key == imported[3].Key {
+		t.Errorf("identical rows have the same key %s", imported[2].Key)
+	}
+
+	again, err := ParseCSV(openTestdata(t, "statement-cp1251.csv"), entity.ImportProfile{
+		Delimiter: ";", Encoding: "cp1251", DateFormat: "02.01.2006", SkipRows: 3,
+		DescriptionColumn: 1, AmountColumn: 2, Account: "card",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range imported {
+		if again[i].Key != imported[i].Key {
+			t.Errorf("row %d has key %s on the second import, want %s", i, again[i].Key, imported[i].Key)
+		}
+	}
+}
+
+func TestParseCSVDecimalSeparator(t *testing.T) {
+	profile := entity.ImportProfile{
+		DateFormat:        "2006-01-02",
+		SkipRows:          1,
+		DateColumn:        0,
+		AmountColumn:      1,
+		DescriptionColumn: 2,
+		Account:           "card",
+	}
+
+	// the comma delimiter is detected, the UTF-8 byte order mark is dropped and "1,250" groups thousands
+	imported, err := ParseCSV(openTestdata(t, "statement-comma.csv"), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTransactions(t, transactionsOf(imported), []entity.Transaction{
+		{Date: date("2022-05-02"), FromAccount: "card", ToAccount: DefaultExpenseAccount, Amount: 1250, Description: "Rent"},
+		{Date: date("2022-05-03"), FromAccount: DefaultIncomeAccount, ToAccount: "card", Amount: 1250, Description: "Refund"},
+		{Date: date("2022-05-04"), FromAccount: "card", ToAccount: DefaultExpenseAccount, Amount: 12.5, Description: "Coffee, large"},
+	})
+
+	// a profile with the decimal comma reads "1,250" as 1.25
+	profile.Delimiter = "\t"
+	profile.DecimalSeparator = ","
+	imported, err = ParseCSV(strings.NewReader("date\tamount\tdescription\n2022-05-03\t1,250\tRefund\n"), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTransactions(t, transactionsOf(imported), []entity.Transaction{
+		{Date: date("2022-05-03"), FromAccount: DefaultIncomeAccount, ToAccount: "card", Amount: 1.25, Description: "Refund"},
+	})
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	profile := entity.ImportProfile{DateFormat: "2006-01-02", AmountColumn: 1, DescriptionColumn: -1, Account: "card"}
+
+	tests := map[string]string{
+		"2022-05-03;12,50\n2022-13-01;1\n": "row 2: invalid date 2022-13-01",
+		"2022-05-03;twelve\n":              "row 1: invalid amount twelve",
+		"2022-05-03\n":                     "row 1: no column 1",
+	}
+	for input, want := range tests {
+		_, err := ParseCSV(strings.NewReader(input), profile)
+		if err == nil || err.Error() != want {
+			t.Errorf("ParseCSV(%q) err %v, want %s", input, err, want)
+		}
+	}
+
+	_, err := ParseCSV(openTestdata(t, "statement-cp1251.csv"), entity.ImportProfile{DateFormat: "02.01.2006", SkipRows: 3, Account: "card"})
+	if err == nil {
+		t.Error("Windows-1251 file is parsed as UTF-8")
+	}
+}
@@ -0,0 +1,174 @@
+// Package importer reads bank statements and journals of other finance software into transactions
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+
+	"enigma/internal/entity"
+)
+
+const (
+	DefaultExpenseAccount = "expenses"
+	DefaultIncomeAccount  = "income"
+)
+
+var UnsupportedFormatErr = errors.New("unsupported file format")
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// Options tell statement parsers which accounts to use, empty Account means
+// the account is taken from the statement itself, or DefaultAccount if the statement names none
+type Options struct {
+	Account        string
+	DefaultAccount string
+	ExpenseAccount string
+	IncomeAccount  string
+}
+
+func (o Options) withDefaults() Options {
+	if o.ExpenseAccount == "" {
+		o.ExpenseAccount = DefaultExpenseAccount
+	}
+	if o.IncomeAccount == "" {
+		o.IncomeAccount = DefaultIncomeAccount
+	}
+	return o
+}
+
+// transaction makes a transaction of a signed statement amount, negative amounts are spent from account
+func (o Options) transaction(date time.Time, amount float64, account, description string) entity.Transaction {
+	if amount < 0 {
+		return entity.Transaction{
+			Date:        date,
+			FromAccount: account,
+			ToAccount:   o.ExpenseAccount,
+			Amount:      -amount,
+			Description: description,
+		}
+	}
+
+	return entity.Transaction{
+		Date:        date,
+		FromAccount: o.IncomeAccount,
+		ToAccount:   account,
+		Amount:      amount,
+		Description: description,
+	}
+}
+
+// Parse reads a statement of a format which needs no column mapping, the format is chosen by the file extension
+func Parse(fileName string, r io.Reader, options Options) ([]entity.ImportedTransaction, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ofx", ".qfx":
+		return ParseOFX(r, options)
+	case ".xml", ".053", ".052":
+		return ParseCamt(r, options)
+	case ".jsonl", ".ndjson":
+		return ParseJSONL(r)
+	default:
+		return nil, UnsupportedFormatErr
+	}
+}
+
+// Decode converts r from the named encoding to UTF-8
+func Decode(r io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ReplaceAll(strings.ToLower(encoding), "-", "") {
+	case "", "utf8":
+		br := bufio.NewReader(r)
+		if prefix, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+			_, _ = br.Discard(len(utf8BOM))
+		}
+		return br, nil
+	case "windows1251", "cp1251":
+		return transform.NewReader(r, charmap.Windows1251.NewDecoder()), nil
+	case "koi8r":
+		return transform.NewReader(r, charmap.KOI8R.NewDecoder()), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %s", encoding)
+	}
+}
+
+// ParseAmount parses amounts written like "1 250,00", "-1,250.00" or "−1250 ₽", the decimal separator is guessed,
+// see ParseAmountWithSeparator
+func ParseAmount(s string) (float64, error) {
+	return ParseAmountWithSeparator(s, "")
+}
+
+// ParseAmountWithSeparator parses the amount with decimalSeparator "." or ",", the other one groups thousands.
+// An empty decimalSeparator is guessed: it is the last of them unless it repeats or is followed by three digits,
+// like in "1,250", so amounts with three decimal places need decimalSeparator
+func ParseAmountWithSeparator(s, decimalSeparator string) (float64, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			return r
+		case r == '-' || r == '−' || r == '–':
+			return '-'
+		default:
+			return -1
+		}
+	}, s)
+
+	if decimalSeparator == "" {
+		decimalSeparator = guessDecimalSeparator(cleaned)
+	}
+
+	switch decimalSeparator {
+	case ".":
+		cleaned = strings.ReplaceAll(cleaned, ",", "")
+	case ",":
+		cleaned = strings.ReplaceAll(cleaned, ".", "")
+		cleaned = strings.Replace(cleaned, ",", ".", 1)
+	default:
+		return 0, fmt.Errorf("invalid decimal separator %s", decimalSeparator)
+	}
+
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s", strings.TrimSpace(s))
+	}
+	return amount, nil
+}
+
+func guessDecimalSeparator(s string) string {
+	separator, other := ".", ","
+	if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+		separator, other = ",", "."
+	}
+
+	last := strings.LastIndex(s, separator)
+	if last < 0 || strings.Contains(s, other) {
+		return separator
+	}
+
+	// "1,250" groups thousands, "0,125" or "1234,567" can't
+	integer := strings.TrimPrefix(s[:last], "-")
+	if strings.Count(s, separator) > 1 || (len(s)-last-1 == 3 && len(integer) <= 3 && integer != "0" && integer != "") {
+		return other
+	}
+	return separator
+}
+
+// hashKey builds a deduplication key of a statement entry from its fields
+func hashKey(prefix string, fields ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return prefix + ":" + hex.EncodeToString(sum[:16])
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+}
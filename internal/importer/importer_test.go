@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+func openTestdata(t *testing.T, name string) *os.File {
+	t.Helper()
+
+	file, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		d, err = time.Parse("2006-01-02", s)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// checkTransactions compares what statement parsers fill, tags are extracted later on import
+func checkTransactions(t *testing.T, got, want []entity.Transaction) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%d transactions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Date.Equal(w.Date) || g.FromAccount != w.FromAccount || g.ToAccount != w.ToAccount ||
+			g.Amount != w.Amount || g.Currency != w.Currency || g.Description != w.Description {
+			t.Errorf("transaction %d\n got %+v\nwant %+v", i, g, w)
+		}
+	}
+}
+
+func transactionsOf(imported []entity.ImportedTransaction) []entity.Transaction {
+	transactions := make([]entity.Transaction, 0, len(imported))
+	for _, i := range imported {
+		transactions = append(transactions, i.Transaction)
+	}
+	return transactions
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]float64{
+		"1 250,00":    1250,
+		"-1,250.00":   -1250,
+		"−1250 ₽":     -1250,
+		"12345.67":    12345.67,
+		"0,5":         0.5,
+		"1.250.000,5": 1250000.5,
+		"12,345,678":  12345678,
+		// a single separator followed by three digits groups thousands
+		"1,250":    1250,
+		"-1.250":   -1250,
+		"0,125":    0.125,
+		"1234,567": 1234.567,
+	}
+	for s, want := range tests {
+		got, err := ParseAmount(s)
+		if err != nil || got != want {
+			t.Errorf("ParseAmount(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	_, err := ParseAmount("abc")
+	if err == nil {
+		t.Error("ParseAmount of text succeeded")
+	}
+}
+
+func TestParseAmountWithSeparator(t *testing.T) {
+	tests := []struct {
+		s, separator string
+		want         float64
+	}{
+		{"1,250", ",", 1.25},
+		{"1,250", ".", 1250},
+		{"1.250", ".", 1.25},
+		{"1 250,125", ",", 1250.125},
+		{"-1,250.125", ".", -1250.125},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmountWithSeparator(tt.s, tt.separator)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAmountWithSeparator(%q, %q) = %v, %v, want %v", tt.s, tt.separator, got, err, tt.want)
+		}
+	}
+
+	_, err := ParseAmountWithSeparator("1,25", ";")
+	if err == nil {
+		t.Error("ParseAmountWithSeparator with an invalid separator succeeded")
+	}
+}
+
+func TestParseChoosesFormatByExtension(t *testing.T) {
+	imported, err := Parse("statement.QFX", openTestdata(t, "statement-v2.ofx"), Options{})
+	if err != nil || len(imported) != 3 {
+		t.Errorf("parsed %d transactions, err %v, want 3 of the OFX statement", len(imported), err)
+	}
+
+	_, err = Parse("statement.pdf", openTestdata(t, "statement-v2.ofx"), Options{})
+	if err != UnsupportedFormatErr {
+		t.Errorf("err %v, want %v", err, UnsupportedFormatErr)
+	}
+}
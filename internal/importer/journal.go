@@ -0,0 +1,746 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"enigma/internal/entity"
+)
+
+const (
+	JournalBeancount = "beancount"
+	JournalLedger    = "ledger"
+)
+
+// ConversionAccount takes the other side of postings exchanged between commodities at a price,
+// since a transaction holds a single currency
+const ConversionAccount = "Equity:Conversions"
+
+// journalTolerance is the largest residual of a balanced transaction
+const journalTolerance = 0.005
+
+// JournalWarning is a line of a journal which was not imported
+type JournalWarning struct {
+	Line    int
+	Message string
+}
+
+func (w JournalWarning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// Journal is the part of a plain-text accounting journal which maps onto transactions and accounts
+type Journal struct {
+	Transactions []entity.ImportedTransaction
+	Accounts     []entity.Account
+	Warnings     []JournalWarning
+}
+
+// JournalFormat returns the journal dialect of a file by its extension, empty if it is not a journal
+func JournalFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".beancount", ".bean":
+		return JournalBeancount
+	case ".ledger", ".journal", ".hledger", ".dat":
+		return JournalLedger
+	default:
+		return ""
+	}
+}
+
+type journalPosting struct {
+	account   string
+	amount    float64
+	commodity string
+	hasAmount bool
+
+	// weight is what the posting contributes to the balance, it differs from the amount for postings at a price
+	weight          float64
+	weightCommodity string
+}
+
+type journalTransaction struct {
+	line        int
+	date        time.Time
+	description string
+	tags        []string
+	postings    []journalPosting
+	invalid     bool
+}
+
+type journalParser struct {
+	format  string
+	journal Journal
+
+	current     *journalTransaction
+	pushedTags  []string
+	inComment   bool
+	occurrences map[string]int
+}
+
+// ParseJournal reads the common subset of beancount or ledger and hledger journals: transactions
+// with elided amounts, prices and costs, tags and metadata, and account declarations.
+// Other directives and transactions which can't be imported are reported as warnings.
+// A transaction of several postings becomes a transaction per pair of postings it moves money between.
+func ParseJournal(r io.Reader, format string) (Journal, error) {
+	if format != JournalBeancount && format != JournalLedger {
+		return Journal{}, fmt.Errorf("unsupported journal format %s", format)
+	}
+
+	p := &journalParser{
+		format:      format,
+		occurrences: make(map[string]int),
+	}
+
+	decoded, err := Decode(r, "")
+	if err != nil {
+		return Journal{}, err
+	}
+
+	scanner := bufio.NewScanner(decoded)
+	for line := 1; scanner.Scan(); line++ {
+		p.parseLine(line, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return Journal{}, err
+	}
+	p.finish()
+
+	return p.journal, nil
+}
+
+func (p *journalParser) warn(line int, format string, args ...interface{}) {
+	p.journal.Warnings = append(p.journal.Warnings, JournalWarning{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *journalParser) parseLine(line int, text string) {
+	trimmed := strings.TrimSpace(text)
+
+	if p.inComment {
+		if trimmed == "end comment" || trimmed == "end test" {
+			p.inComment = false
+		}
+		return
+	}
+
+	if trimmed == "" {
+		return
+	}
+
+	if text[0] == ' ' || text[0] == '\t' {
+		// lines under directives other than transactions hold their metadata or subdirectives
+		if p.current != nil && !p.current.invalid {
+			p.parsePostingLine(line, trimmed)
+		}
+		return
+	}
+
+	p.finish()
+
+	switch {
+	case strings.ContainsRune(";#%|*", rune(text[0])):
+		return
+	case text[0] >= '0' && text[0] <= '9':
+		p.parseDated(line, trimmed)
+	default:
+		p.parseDirective(line, trimmed)
+	}
+}
+
+func (p *journalParser) parseDirective(line int, text string) {
+	fields := strings.Fields(text)
+	keyword := fields[0]
+
+	switch {
+	case p.format == JournalLedger && keyword == "account" && len(fields) > 1:
+		declaration, comment := cutComment(strings.TrimSpace(text[len("account"):]))
+		p.declareAccount(declaration, hledgerAccountType(comment))
+	case p.format == JournalLedger && (keyword == "comment" || keyword == "test"):
+		p.inComment = true
+	case p.format == JournalBeancount && keyword == "pushtag" && len(fields) == 2:
+		p.pushedTags = append(p.pushedTags, entity.NormalizeTag(fields[1]))
+	case p.format == JournalBeancount && keyword == "poptag" && len(fields) == 2:
+		tag := entity.NormalizeTag(fields[1])
+		for i := len(p.pushedTags) - 1; i >= 0; i-- {
+			if p.pushedTags[i] == tag {
+				p.pushedTags = append(p.pushedTags[:i], p.pushedTags[i+1:]...)
+				break
+			}
+		}
+	default:
+		p.warn(line, "unsupported directive %s", keyword)
+	}
+}
+
+func (p *journalParser) parseDated(line int, text string) {
+	dateField := strings.Fields(text)[0]
+	rest := strings.TrimSpace(text[len(dateField):])
+
+	// ledger transactions may have an auxiliary date after "="
+	dateField = strings.SplitN(dateField, "=", 2)[0]
+
+	date, err := parseJournalDate(dateField)
+	if err != nil {
+		p.warn(line, "invalid date %s", dateField)
+		return
+	}
+
+	if p.format == JournalBeancount {
+		fields := strings.Fields(rest)
+		keyword := ""
+		if len(fields) > 0 {
+			keyword = fields[0]
+		}
+
+		switch {
+		case keyword == "open" && len(fields) > 1:
+			p.declareAccount(fields[1], entity.UnknownAccount)
+			return
+		case keyword == "close" && len(fields) > 1:
+			return
+		case keyword == "" || isBeancountFlag(keyword) || strings.HasPrefix(keyword, `"`):
+			p.startBeancountTransaction(line, date, rest)
+			return
+		default:
+			p.warn(line, "unsupported directive %s", keyword)
+			return
+		}
+	}
+
+	p.startLedgerTransaction(line, date, rest)
+}
+
+func (p *journalParser) declareAccount(name string, accountType entity.AccountType) {
+	if accountType == entity.UnknownAccount {
+		accountType = entity.InferAccountType(name)
+	}
+	p.journal.Accounts = append(p.journal.Accounts, entity.Account{Name: name, Type: accountType})
+}
+
+func (p *journalParser) startBeancountTransaction(line int, date time.Time, header string) {
+	t := &journalTransaction{line: line, date: date}
+	p.current = t
+
+	var strs []string
+	rest, _ := cutComment(header)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		switch rest[0] {
+		case '"':
+			s, n, err := readBeancountString(rest)
+			if err != nil {
+				p.warn(line, "%s", err)
+				t.invalid = true
+				return
+			}
+			strs = append(strs, s)
+			rest = rest[n:]
+		case '#', '^':
+			word := rest
+			if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+				word = rest[:i]
+			}
+			if word[0] == '#' && len(word) > 1 {
+				t.tags = append(t.tags, entity.NormalizeTag(word))
+			}
+			rest = rest[len(word):]
+		default:
+			word := rest
+			if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+				word = rest[:i]
+			}
+			if !isBeancountFlag(word) || len(strs) > 0 {
+				p.warn(line, "unexpected %s in transaction header", word)
+				t.invalid = true
+				return
+			}
+			rest = rest[len(word):]
+		}
+	}
+
+	// payee and narration
+	t.description = strings.Join(strs, " ")
+	t.tags = append(t.tags, p.pushedTags...)
+}
+
+func (p *journalParser) startLedgerTransaction(line int, date time.Time, header string) {
+	t := &journalTransaction{line: line, date: date}
+	p.current = t
+
+	header = strings.TrimSpace(header)
+	if strings.HasPrefix(header, "*") || strings.HasPrefix(header, "!") {
+		header = strings.TrimSpace(header[1:])
+	}
+	if strings.HasPrefix(header, "(") {
+		if i := strings.IndexByte(header, ')'); i >= 0 {
+			header = strings.TrimSpace(header[i+1:])
+		}
+	}
+
+	description, comment := cutComment(header)
+	t.description = strings.TrimSpace(description)
+	t.tags = append(t.tags, ledgerCommentTags(comment)...)
+}
+
+var beancountMetadataRegexp = regexp.MustCompile(`^[a-z][A-Za-z0-9_-]*:(\s|$)`)
+
+func (p *journalParser) parsePostingLine(line int, text string) {
+	t := p.current
+
+	if strings.HasPrefix(text, ";") {
+		if p.format == JournalLedger {
+			t.tags = append(t.tags, ledgerCommentTags(text[1:])...)
+		}
+		return
+	}
+
+	if p.format == JournalBeancount && beancountMetadataRegexp.MatchString(text) {
+		return
+	}
+
+	text, comment := cutComment(text)
+	if p.format == JournalLedger {
+		t.tags = append(t.tags, ledgerCommentTags(comment)...)
+	}
+
+	if strings.HasPrefix(text, "* ") || strings.HasPrefix(text, "! ") {
+		text = strings.TrimSpace(text[2:])
+	}
+
+	var account, amount string
+	if p.format == JournalLedger {
+		// ledger account names may have single spaces, the amount follows two spaces or a tab
+		account = text
+		if i := strings.Index(text, "  "); i >= 0 {
+			account, amount = text[:i], text[i:]
+		}
+		if i := strings.IndexByte(account, '\t'); i >= 0 {
+			account, amount = account[:i], account[i:]+amount
+		}
+		account = strings.TrimSpace(account)
+
+		switch {
+		case strings.HasPrefix(account, "(") && strings.HasSuffix(account, ")"):
+			p.warn(line, "virtual posting to %s is skipped", account)
+			return
+		case strings.HasPrefix(account, "[") && strings.HasSuffix(account, "]"):
+			account = account[1 : len(account)-1]
+		}
+
+		// balance assertions and assignments follow "="
+		if i := strings.IndexByte(amount, '='); i >= 0 {
+			amount = amount[:i]
+		}
+	} else {
+		fields := strings.Fields(text)
+		account = fields[0]
+		amount = text[len(fields[0]):]
+	}
+
+	posting, err := parseJournalPosting(account, strings.TrimSpace(amount))
+	if err != nil {
+		p.warn(line, "%s", err)
+		t.invalid = true
+		return
+	}
+
+	if !posting.hasAmount {
+		for _, other := range t.postings {
+			if !other.hasAmount {
+				p.warn(line, "more than one posting without amount")
+				t.invalid = true
+				return
+			}
+		}
+	}
+
+	t.postings = append(t.postings, posting)
+}
+
+func parseJournalPosting(account, text string) (journalPosting, error) {
+	posting := journalPosting{account: account}
+	if text == "" {
+		return posting, nil
+	}
+
+	var price string
+	total := false
+	if i := strings.Index(text, "@@"); i >= 0 {
+		text, price, total = text[:i], text[i+2:], true
+	} else if i := strings.IndexByte(text, '@'); i >= 0 {
+		text, price = text[:i], text[i+1:]
+	}
+
+	// costs of lots are weights just like prices
+	if i := strings.IndexByte(text, '{'); i >= 0 {
+		j := strings.LastIndexByte(text, '}')
+		if j < i {
+			return posting, fmt.Errorf("invalid cost %s", text[i:])
+		}
+		cost := text[i+1 : j]
+		if strings.HasPrefix(cost, "{") {
+			cost, total = strings.Trim(cost, "{}"), true
+		}
+		if strings.TrimSpace(cost) != "" {
+			price = cost
+		}
+		text = text[:i] + text[j+1:]
+	}
+
+	var err error
+	posting.amount, posting.commodity, err = parseCommodityAmount(text)
+	if err != nil {
+		return posting, err
+	}
+	posting.hasAmount = true
+	posting.weight, posting.weightCommodity = posting.amount, posting.commodity
+
+	if strings.TrimSpace(price) != "" {
+		priceAmount, priceCommodity, err := parseCommodityAmount(price)
+		if err != nil {
+			return posting, err
+		}
+
+		posting.weightCommodity = priceCommodity
+		if total {
+			posting.weight = math.Copysign(math.Abs(priceAmount), posting.amount)
+		} else {
+			posting.weight = posting.amount * priceAmount
+		}
+	}
+
+	return posting, nil
+}
+
+var commoditySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"₽": "RUB",
+}
+
+// parseCommodityAmount parses amounts like "-12.50 RUB", "RUB -12.50", "$-12.50" or "1,250.00 EUR"
+func parseCommodityAmount(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") {
+		return 0, "", fmt.Errorf("amount expression %s is not supported", s)
+	}
+
+	var number, commodity strings.Builder
+	inQuotes, afterNumber := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+			commodity.WriteRune(r)
+		case r >= '0' && r <= '9' || r == '.' || r == ',' || r == '-' || r == '+':
+			if afterNumber {
+				return 0, "", fmt.Errorf("invalid amount %s", s)
+			}
+			number.WriteRune(r)
+		case unicode.IsSpace(r):
+		default:
+			afterNumber = strings.ContainsAny(number.String(), "0123456789")
+			commodity.WriteRune(r)
+		}
+	}
+
+	amount, err := parseJournalNumber(number.String())
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid amount %s", s)
+	}
+
+	code := commodity.String()
+	if symbol, ok := commoditySymbols[code]; ok {
+		code = symbol
+	}
+	if code == "" {
+		code = entity.DefaultCurrency
+	}
+
+	return amount, code, nil
+}
+
+// parseJournalNumber takes commas for thousand separators unless a comma is the only separator
+// and is not followed by three digits, e.g. "1,250.00" and "1,250" are 1250 and "12,5" is 12.5
+func parseJournalNumber(s string) (float64, error) {
+	if i := strings.LastIndexByte(s, ','); i >= 0 && !strings.Contains(s, ".") && len(s)-i-1 != 3 {
+		s = s[:i] + "." + s[i+1:]
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
+
+func parseJournalDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-1-2", "2006/1/2", "2006.1.2"} {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid date")
+}
+
+// finish turns the transaction being parsed into transactions between pairs of its postings
+func (p *journalParser) finish() {
+	t := p.current
+	p.current = nil
+	if t == nil || t.invalid {
+		return
+	}
+
+	if len(t.postings) == 0 {
+		p.warn(t.line, "transaction has no postings")
+		return
+	}
+
+	var commodities []string
+	residuals := make(map[string]float64)
+	elided := -1
+	for i, posting := range t.postings {
+		if !posting.hasAmount {
+			elided = i
+			continue
+		}
+		if _, ok := residuals[posting.weightCommodity]; !ok {
+			commodities = append(commodities, posting.weightCommodity)
+		}
+		residuals[posting.weightCommodity] += posting.weight
+	}
+
+	postings := make([]journalPosting, 0, len(t.postings)+len(commodities))
+	for i, posting := range t.postings {
+		if i != elided {
+			postings = append(postings, posting)
+			continue
+		}
+
+		// an elided amount balances every commodity at once
+		for _, commodity := range commodities {
+			residual := roundJournalAmount(residuals[commodity])
+			if residual == 0 {
+				continue
+			}
+			postings = append(postings, journalPosting{
+				account: posting.account, amount: -residual, commodity: commodity, hasAmount: true,
+				weight: -residual, weightCommodity: commodity,
+			})
+			residuals[commodity] = 0
+		}
+	}
+
+	for _, commodity := range commodities {
+		if math.Abs(residuals[commodity]) > journalTolerance {
+			p.warn(t.line, "transaction does not balance by %v %s", roundJournalAmount(residuals[commodity]), commodity)
+			return
+		}
+	}
+
+	transactions := pairJournalPostings(postings)
+	if len(transactions) == 0 {
+		p.warn(t.line, "transaction moves no money")
+		return
+	}
+
+	description := t.description
+	existing := entity.ExtractTags(description)
+	for _, tag := range t.tags {
+		if tag != "" && !containsString(existing, tag) {
+			description += " #" + tag
+			existing = append(existing, tag)
+		}
+	}
+	description = strings.TrimSpace(description)
+
+	content := []string{t.date.Format("2006-01-02"), description}
+	for _, transaction := range transactions {
+		content = append(content, transaction.FromAccount, transaction.ToAccount,
+			strconv.FormatFloat(transaction.Amount, 'f', -1, 64), transaction.Currency)
+	}
+	joined := strings.Join(content, "\x1f")
+	p.occurrences[joined]++
+	key := hashKey("journal", joined, strconv.Itoa(p.occurrences[joined]))
+
+	for i, transaction := range transactions {
+		transaction.Date = t.date
+		transaction.Description = description
+		if transaction.Currency == entity.DefaultCurrency {
+			transaction.Currency = ""
+		}
+
+		p.journal.Transactions = append(p.journal.Transactions, entity.ImportedTransaction{
+			Key:         key + ":" + strconv.Itoa(i),
+			Transaction: transaction,
+		})
+	}
+}
+
+// pairJournalPostings moves money from postings with negative amounts to the ones with positive amounts
+// of the same commodity, commodities exchanged at a price are balanced through ConversionAccount
+func pairJournalPostings(postings []journalPosting) []entity.Transaction {
+	var commodities []string
+	byCommodity := make(map[string][]journalPosting)
+	sums := make(map[string]float64)
+	for _, posting := range postings {
+		if _, ok := byCommodity[posting.commodity]; !ok {
+			commodities = append(commodities, posting.commodity)
+		}
+		byCommodity[posting.commodity] = append(byCommodity[posting.commodity], posting)
+		sums[posting.commodity] += posting.amount
+	}
+
+	var transactions []entity.Transaction
+	for _, commodity := range commodities {
+		group := byCommodity[commodity]
+		if sum := roundJournalAmount(sums[commodity]); sum != 0 {
+			group = append(group, journalPosting{account: ConversionAccount, amount: -sum, commodity: commodity})
+		}
+
+		var from, to []journalPosting
+		for _, posting := range group {
+			amount := roundJournalAmount(posting.amount)
+			switch {
+			case amount < 0:
+				posting.amount = -amount
+				from = append(from, posting)
+			case amount > 0:
+				posting.amount = amount
+				to = append(to, posting)
+			}
+		}
+
+		for i, j := 0, 0; i < len(from) && j < len(to); {
+			amount := math.Min(from[i].amount, to[j].amount)
+			if from[i].account != to[j].account {
+				transactions = append(transactions, entity.Transaction{
+					FromAccount: from[i].account,
+					ToAccount:   to[j].account,
+					Amount:      roundJournalAmount(amount),
+					Currency:    commodity,
+				})
+			}
+
+			from[i].amount = roundJournalAmount(from[i].amount - amount)
+			to[j].amount = roundJournalAmount(to[j].amount - amount)
+			if from[i].amount == 0 {
+				i++
+			}
+			if to[j].amount == 0 {
+				j++
+			}
+		}
+	}
+
+	return transactions
+}
+
+// roundJournalAmount drops the error of floating point sums
+func roundJournalAmount(amount float64) float64 {
+	return math.Round(amount*1e8) / 1e8
+}
+
+// cutComment splits s at the first ";" which is not inside a quoted string
+func cutComment(s string) (string, string) {
+	inQuotes := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ';' && !inQuotes:
+			return strings.TrimSpace(s[:i]), s[i+1:]
+		}
+	}
+	return strings.TrimSpace(s), ""
+}
+
+// ledgerCommentTags returns ledger tags ":one:two:" and hledger tags "one:, two:" of a comment,
+// "key: value" metadata is not a tag
+func ledgerCommentTags(comment string) []string {
+	var tags []string
+	for _, part := range strings.Split(comment, ",") {
+		part = strings.TrimSpace(part)
+
+		if strings.HasSuffix(part, ":") && strings.Count(part, ":") == 1 && !strings.ContainsAny(part, " \t") {
+			tags = append(tags, entity.NormalizeTag(strings.TrimSuffix(part, ":")))
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			if len(word) > 2 && strings.HasPrefix(word, ":") && strings.HasSuffix(word, ":") {
+				for _, tag := range strings.Split(strings.Trim(word, ":"), ":") {
+					if tag != "" {
+						tags = append(tags, entity.NormalizeTag(tag))
+					}
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// hledgerAccountType reads the "type:" tag of hledger account declarations
+func hledgerAccountType(comment string) entity.AccountType {
+	i := strings.Index(comment, "type:")
+	if i < 0 {
+		return entity.UnknownAccount
+	}
+
+	value := strings.TrimSpace(comment[i+len("type:"):])
+	if j := strings.IndexAny(value, ", "); j >= 0 {
+		value = value[:j]
+	}
+
+	switch strings.ToUpper(value) {
+	case "A", "ASSET", "C", "CASH":
+		return entity.AssetAccount
+	case "L", "LIABILITY":
+		return entity.LiabilityAccount
+	case "E", "EQUITY", "V", "CONVERSION":
+		return entity.EquityAccount
+	case "R", "REVENUE":
+		return entity.IncomeAccount
+	case "X", "EXPENSE":
+		return entity.ExpenseAccount
+	default:
+		return entity.UnknownAccount
+	}
+}
+
+func isBeancountFlag(s string) bool {
+	return s == "*" || s == "!" || s == "txn" || (len(s) == 1 && s[0] >= 'A' && s[0] <= 'Z')
+}
+
+// readBeancountString reads a quoted string at the start of s and returns it with the length it took
+func readBeancountString(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
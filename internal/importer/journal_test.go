@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"enigma/internal/entity"
+	"enigma/internal/exporter"
+)
+
+func checkWarnings(t *testing.T, got []JournalWarning, want []string) {
+	t.Helper()
+
+	lines := make([]string, 0, len(got))
+	for _, w := range got {
+		lines = append(lines, w.String())
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func checkAccounts(t *testing.T, got []entity.Account, want []entity.Account) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("accounts %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Type != want[i].Type {
+			t.Errorf("account %d is %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseBeancount(t *testing.T) {
+	journal, err := ParseJournal(openTestdata(t, "journal.beancount"), JournalBeancount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkTransactions(t, transactionsOf(journal.Transactions), []entity.Transaction{
+		{Date: date("2022-03-05"), FromAccount: "Income:Salary", ToAccount: "Assets:Card", Amount: 100000, Description: "Employer Salary for February #spring"},
+		{Date: date("2022-04-02"), FromAccount: "Assets:Card", ToAccount: "Expenses:Home", Amount: 1250.5, Description: `Pharmacy "36.6" #health #spring`},
+		{Date: date("2022-04-02"), FromAccount: "Assets:Card", ToAccount: "Expenses:Food", Amount: 449.5, Description: `Pharmacy "36.6" #health #spring`},
+		// the exchange at a price moves euros and roubles through the conversion account
+		{Date: date("2022-05-10"), FromAccount: ConversionAccount, ToAccount: "Assets:Cash:EUR", Amount: 100, Currency: "EUR", Description: "Exchange office"},
+		{Date: date("2022-05-10"), FromAccount: "Assets:Card", ToAccount: ConversionAccount, Amount: 8000, Description: "Exchange office"},
+		{Date: date("2022-05-11"), FromAccount: "Assets:Cash:EUR", ToAccount: "Expenses:Food", Amount: 35, Currency: "EUR", Description: "Dinner abroad"},
+	})
+
+	checkAccounts(t, journal.Accounts, []entity.Account{
+		{Name: "Assets:Card", Type: entity.AssetAccount},
+		{Name: "Assets:Cash:EUR", Type: entity.AssetAccount},
+		{Name: "Expenses:Food", Type: entity.ExpenseAccount},
+		{Name: "Expenses:Home", Type: entity.ExpenseAccount},
+		{Name: "Income:Salary", Type: entity.IncomeAccount},
+	})
+
+	checkWarnings(t, journal.Warnings, []string{
+		"line 1: unsupported directive option",
+		"line 2: unsupported directive option",
+		"line 9: unsupported directive commodity",
+		"line 34: unsupported directive balance",
+		"line 35: unsupported directive price",
+		"line 37: transaction does not balance by 5 RUB",
+	})
+}
+
+func TestParseLedger(t *testing.T) {
+	journal, err := ParseJournal(openTestdata(t, "journal.ledger"), JournalLedger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transaction in the comment block is not imported
+	checkTransactions(t, transactionsOf(journal.Transactions), []entity.Transaction{
+		{Date: date("2022-03-05"), FromAccount: "Income:Salary", ToAccount: "Assets:Card", Amount: 100000, Description: "Salary #work"},
+		{Date: date("2022-04-02"), FromAccount: "Assets:Card", ToAccount: "Expenses:Home", Amount: 1250.5, Description: "Pharmacy and groceries #health #spring"},
+		{Date: date("2022-04-02"), FromAccount: "Assets:Card", ToAccount: "Expenses:Food", Amount: 449.5, Description: "Pharmacy and groceries #health #spring"},
+		// a single space is a part of the account name, two spaces end it
+		{Date: date("2022-05-10"), FromAccount: ConversionAccount, ToAccount: "Assets:Cash USD", Amount: 100, Currency: "USD", Description: "Exchange office"},
+		{Date: date("2022-05-10"), FromAccount: "Assets:Card", ToAccount: ConversionAccount, Amount: 8000, Description: "Exchange office"},
+	})
+
+	checkAccounts(t, journal.Accounts, []entity.Account{
+		{Name: "Assets:Card", Type: entity.AssetAccount},
+		{Name: "Expenses:Food", Type: entity.ExpenseAccount},
+		{Name: "Income:Salary", Type: entity.IncomeAccount},
+	})
+
+	checkWarnings(t, journal.Warnings, []string{
+		"line 6: unsupported directive commodity",
+		"line 7: unsupported directive P",
+		"line 22: virtual posting to (Budget:Travel) is skipped",
+		"line 30: unsupported directive ~",
+		"line 36: more than one posting without amount",
+	})
+}
+
+func TestParseJournalKeysOfRepeatedTransactions(t *testing.T) {
+	transaction := "2022-05-01 * \"Coffee\"\n  Expenses:Food  150 RUB\n  Assets:Card\n\n"
+	journal, err := ParseJournal(strings.NewReader(transaction+transaction), JournalBeancount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same coffee bought twice a day is two transactions
+	if len(journal.Transactions) != 2 || journal.Transactions[0].Key == journal.Transactions[1].Key {
+		t.Errorf("repeated transactions %+v, want two with different keys", journal.Transactions)
+	}
+}
+
+func TestJournalFormat(t *testing.T) {
+	tests := map[string]string{
+		"household.beancount": JournalBeancount,
+		"household.bean":      JournalBeancount,
+		"household.ledger":    JournalLedger,
+		"household.journal":   JournalLedger,
+		"household.hledger":   JournalLedger,
+		"household.dat":       JournalLedger,
+		"household.csv":       "",
+	}
+	for name, want := range tests {
+		if got := JournalFormat(name); got != want {
+			t.Errorf("JournalFormat(%s) = %q, want %q", name, got, want)
+		}
+	}
+
+	_, err := ParseJournal(strings.NewReader(""), "gnucash")
+	if err == nil {
+		t.Error("unsupported format is parsed")
+	}
+}
+
+func TestExportedJournalsAreImportedBack(t *testing.T) {
+	exported := []entity.Transaction{
+		{Date: date("2022-05-01"), FromAccount: "assets:card", ToAccount: "expenses:pharmacy", Amount: 1250.5, Description: `Pharmacy "36,6" #health`},
+		{Date: date("2022-05-03"), FromAccount: "assets:cash eur", ToAccount: "expenses:cafe", Amount: 12, Currency: "EUR", Description: "(coffee) and cake #Trip"},
+		{Date: date("2022-05-05"), FromAccount: "income:salary", ToAccount: "assets:card", Amount: 85000, Description: "Employer"},
+	}
+	for i := range exported {
+		exported[i].Tags = entity.ExtractTags(exported[i].Description)
+	}
+	accounts := []entity.Account{
+		{Name: "assets:card", Type: entity.AssetAccount},
+		{Name: "assets:cash eur", Type: entity.AssetAccount},
+		{Name: "expenses:cafe", Type: entity.ExpenseAccount},
+		{Name: "expenses:pharmacy", Type: entity.ExpenseAccount},
+		{Name: "income:salary", Type: entity.IncomeAccount},
+	}
+
+	for _, format := range []string{exporter.FormatLedger, exporter.FormatHledger, exporter.FormatBeancount} {
+		var buf bytes.Buffer
+		w, err := exporter.NewJournal(&buf, format, accounts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, transaction := range exported {
+			if err := w.Write(transaction); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		dialect := JournalLedger
+		if format == exporter.FormatBeancount {
+			dialect = JournalBeancount
+		}
+		journal, err := ParseJournal(&buf, dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkWarnings(t, journal.Warnings, nil)
+
+		want := []entity.Transaction{
+			{Date: date("2022-05-01"), FromAccount: "assets:card", ToAccount: "expenses:pharmacy", Amount: 1250.5, Description: `Pharmacy "36,6" #health`},
+			// the description is moved out of the code position
+			{Date: date("2022-05-03"), FromAccount: "assets:cash eur", ToAccount: "expenses:cafe", Amount: 12, Currency: "EUR", Description: "- (coffee) and cake #Trip"},
+			{Date: date("2022-05-05"), FromAccount: "income:salary", ToAccount: "assets:card", Amount: 85000, Description: "Employer"},
+		}
+		if format == exporter.FormatBeancount {
+			want[0].FromAccount, want[0].ToAccount = "Assets:Card", "Expenses:Pharmacy"
+			want[1].FromAccount, want[1].ToAccount, want[1].Description = "Assets:Cash-eur", "Expenses:Cafe", "(coffee) and cake #Trip"
+			want[2].FromAccount, want[2].ToAccount = "Income:Salary", "Assets:Card"
+		}
+		checkTransactions(t, transactionsOf(journal.Transactions), want)
+
+		// declared types are read back
+		for _, account := range journal.Accounts {
+			if account.Type != entity.InferAccountType(account.Name) {
+				t.Errorf("%s: account %+v", format, account)
+			}
+		}
+		if len(journal.Accounts) != len(accounts) {
+			t.Errorf("%s: %d accounts are imported, want %d", format, len(journal.Accounts), len(accounts))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"enigma/internal/entity"
+)
+
+// maxJSONLLineLength bounds a single exported transaction, descriptions are short in practice
+const maxJSONLLineLength = 1 << 20
+
+// ParseJSONL reads transactions exported as JSON Lines, ids and receipts of the export are dropped
+// and the key is hashed from the content, so importing the same export twice creates nothing
+func ParseJSONL(r io.Reader) ([]entity.ImportedTransaction, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineLength)
+
+	var imported []entity.ImportedTransaction
+	occurrences := make(map[string]int)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var transaction entity.Transaction
+		err := json.Unmarshal([]byte(raw), &transaction)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if transaction.Date.IsZero() || transaction.FromAccount == "" || transaction.ToAccount == "" {
+			return nil, fmt.Errorf("line %d: date and accounts are required", line)
+		}
+		if transaction.Amount <= 0 {
+			return nil, fmt.Errorf("line %d: amount must be positive", line)
+		}
+
+		transaction.ID = 0
+		transaction.Tags = nil
+		// a receipt is entered once, the transaction which holds it is saved already or is imported without it
+		transaction.Receipt = nil
+
+		content, err := json.Marshal(transaction)
+		if err != nil {
+			return nil, err
+		}
+		occurrences[string(content)]++
+
+		imported = append(imported, entity.ImportedTransaction{
+			Key:         hashKey("jsonl", string(content), strconv.Itoa(occurrences[string(content)])),
+			Transaction: transaction,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
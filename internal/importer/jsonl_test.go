@@ -0,0 +1,41 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseJSONLRejectsInvalidLines(t *testing.T) {
+	tests := map[string]string{
+		"not json":        `{"date": "2022-05-01"`,
+		"no date":         `{"from_account": "card", "to_account": "cafe", "amount": 12}`,
+		"no account":      `{"date": "2022-05-01T00:00:00Z", "from_account": "card", "amount": 12}`,
+		"negative amount": `{"date": "2022-05-01T00:00:00Z", "from_account": "card", "to_account": "cafe", "amount": -12}`,
+	}
+	for name, line := range tests {
+		_, err := ParseJSONL(strings.NewReader(line + "\n"))
+		if err == nil {
+			t.Errorf("%s: line is parsed", name)
+		}
+	}
+}
+
+func TestParseJSONLDropsReceipts(t *testing.T) {
+	line := `{"date": "2022-05-01T00:00:00Z", "from_account": "card", "to_account": "shop", "amount": 1234.5, ` +
+		`"receipt": {"time": "2022-05-01T18:30:00Z", "sum": 1234.5, "fn": "9289000100123456", "fd": "12345", "fp": "1234567890", "type": 1}}`
+	withReceipt, err := ParseJSONL(strings.NewReader(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withReceipt) != 1 || withReceipt[0].Transaction.Receipt != nil {
+		t.Fatalf("imported %+v, want a transaction without receipt", withReceipt)
+	}
+
+	withoutReceipt, err := ParseJSONL(strings.NewReader(`{"date": "2022-05-01T00:00:00Z", "from_account": "card", "to_account": "shop", "amount": 1234.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withReceipt[0].Key != withoutReceipt[0].Key {
+		t.Errorf("the receipt changes the key")
+	}
+}
@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+// fragments of built-in notification patterns, amounts are like "1 250", "1 250,00" or "12345.67"
+const (
+	notificationAmount   = `\d[\d ]*(?:[.,]\d{1,2})?`
+	notificationCurrency = `₽|р\.?|руб\.?|RUB|RUR|USD|EUR|\$|€`
+	notificationCard     = `(?:[A-ZА-ЯЁ]+[- ]?)?(?P<card>\d{4})`
+)
+
+// NotificationTemplates are built-in templates of common banks, testdata/notifications.jsonl has samples of each
+var NotificationTemplates = []entity.NotificationTemplate{
+	{
+		// "MIR-1234 12:34 Покупка 1 250р PYATEROCHKA Баланс: 10 000.50р", SMS of 900 and pushes of Sber
+		Name: "sber",
+		Pattern: `(?i)^` + notificationCard + ` (?:\d{2}:\d{2} )?(?:Покупка|Оплата) (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `) ` +
+			`(?P<merchant>.+?) Баланс:? (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `)$`,
+	},
+	{
+		// "MIR-1234 12:34 зачисление 5 000р Баланс: 15 000р"
+		Name: "sber-income",
+		Pattern: `(?i)^` + notificationCard + ` (?:\d{2}:\d{2} )?(?:Зачисление|Перевод)(?: (?:от|из) (?P<merchant>.+?))? (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `)` +
+			`(?: .*?Баланс:? (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `))?$`,
+		Income: true,
+	},
+	{
+		// "Покупка, карта *1234. 1 250 RUB. PYATEROCHKA. Доступно 10 000 RUB"
+		Name: "tinkoff",
+		Pattern: `(?i)^(?:Покупка|Оплата), карта \*(?P<card>\d{4})\. (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `)\. ` +
+			`(?P<merchant>.+?)\. Доступно (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `)$`,
+	},
+	{
+		// "Пополнение, карта *1234. 5 000 RUB. Иван И. Доступно 15 000 RUB"
+		Name: "tinkoff-income",
+		Pattern: `(?i)^(?:Пополнение|Зачисление), (?:карта \*(?P<card>\d{4})|сч[её]т \S+)\. (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `)\.` +
+			`(?: (?P<merchant>.+?)\.)? Доступно (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `)$`,
+		Income: true,
+	},
+	{
+		// "Карта *1234: Покупка 1 250,00 RUR; PYATEROCHKA; 01.05.22 12:34; Доступно 10 000,00 RUR"
+		Name: "alfa",
+		Pattern: `(?i)^Карта \*(?P<card>\d{4}): (?:Покупка|Оплата) (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `); ` +
+			`(?P<merchant>[^;]+);(?: [^;]*;)? Доступно (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `)$`,
+	},
+	{
+		// "Оплата 1250.00р Карта*1234 PYATEROCHKA Баланс 10000.00р 12:34"
+		Name: "vtb",
+		Pattern: `(?i)^(?:Оплата|Списание|Покупка) (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `) Карта ?\*(?P<card>\d{4}) ` +
+			`(?P<merchant>.+?) Баланс (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `)(?: \d{2}:\d{2})?$`,
+	},
+	{
+		// "Покупка 1 250,00 ₽ PYATEROCHKA Баланс 10 000 ₽", many banks write purchases like this
+		Name: "generic",
+		Pattern: `(?i)^(?:Покупка|Оплата|Списание) (?P<amount>` + notificationAmount + `) ?(?P<currency>` + notificationCurrency + `) ` +
+			`(?P<merchant>.+?)(?: Баланс:? (?P<balance>` + notificationAmount + `) ?(?:` + notificationCurrency + `)?)?$`,
+	},
+}
+
+var notificationCurrencies = map[string]string{
+	"₽":    "RUB",
+	"р":    "RUB",
+	"р.":   "RUB",
+	"руб":  "RUB",
+	"руб.": "RUB",
+	"rur":  "RUB",
+	"$":    "USD",
+	"€":    "EUR",
+}
+
+// ParseNotification matches text against templates of the user first and then against built-in ones not replaced by them,
+// whitespace of text is collapsed to single spaces before matching
+func ParseNotification(text string, templates []entity.NotificationTemplate) (entity.BankNotification, entity.NotificationTemplate, error) {
+	text = collapseSpaces(text)
+
+	names := make(map[string]struct{}, len(templates))
+	for _, template := range templates {
+		names[template.Name] = struct{}{}
+	}
+	for _, template := range NotificationTemplates {
+		if _, ok := names[template.Name]; !ok {
+			templates = append(templates, template)
+		}
+	}
+
+	for _, template := range templates {
+		pattern, err := regexp.Compile(template.Pattern)
+		if err != nil {
+			return entity.BankNotification{}, entity.NotificationTemplate{}, fmt.Errorf("template %s: %w", template.Name, err)
+		}
+
+		match := pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+
+		group := func(name string) string {
+			if i := pattern.SubexpIndex(name); i >= 0 {
+				return strings.TrimSpace(match[i])
+			}
+			return ""
+		}
+
+		notification := entity.BankNotification{
+			Template: template.Name,
+			Income:   template.Income,
+			Currency: notificationCurrencyCode(group("currency")),
+			Merchant: group("merchant"),
+			Card:     group("card"),
+		}
+
+		notification.Amount, err = ParseAmount(group("amount"))
+		if err != nil {
+			return entity.BankNotification{}, entity.NotificationTemplate{}, fmt.Errorf("template %s: %w", template.Name, err)
+		}
+
+		if balance := group("balance"); balance != "" {
+			amount, err := ParseAmount(balance)
+			if err != nil {
+				return entity.BankNotification{}, entity.NotificationTemplate{}, fmt.Errorf("template %s: %w", template.Name, err)
+			}
+			notification.Balance = &amount
+		}
+
+		return notification, template, nil
+	}
+
+	return entity.BankNotification{}, entity.NotificationTemplate{}, entity.UnknownNotificationErr
+}
+
+func notificationCurrencyCode(s string) string {
+	if code, ok := notificationCurrencies[strings.ToLower(s)]; ok {
+		return code
+	}
+	if s == "" {
+		return entity.DefaultCurrency
+	}
+	return strings.ToUpper(s)
+}
+
+// NotificationTransaction makes the transaction of a notification on date, accounts of merchants are looked up in
+// merchantAccounts, e.g. category mappings, otherwise the accounts of the template are used
+func NotificationTransaction(
+	notification entity.BankNotification,
+	template entity.NotificationTemplate,
+	date time.Time,
+	merchantAccounts map[string]string,
+) entity.Transaction {
+	account := template.Account
+	if account == "" {
+		account = "card" + notification.Card
+	}
+
+	options := Options{ExpenseAccount: template.ExpenseAccount, IncomeAccount: template.IncomeAccount}.withDefaults()
+	for merchant, merchantAccount := range merchantAccounts {
+		if strings.EqualFold(merchant, notification.Merchant) {
+			options.ExpenseAccount, options.IncomeAccount = merchantAccount, merchantAccount
+			break
+		}
+	}
+
+	amount := -notification.Amount
+	if notification.Income {
+		amount = notification.Amount
+	}
+
+	t := options.transaction(date, amount, account, notification.Merchant)
+	if notification.Currency != entity.DefaultCurrency {
+		t.Currency = notification.Currency
+	}
+	return t
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+type notificationSample struct {
+	Text         string                   `json:"text"`
+	Notification *entity.BankNotification `json:"notification"`
+}
+
+func readNotificationSamples(t *testing.T) []notificationSample {
+	t.Helper()
+
+	file, err := os.Open("testdata/notifications.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var samples []notificationSample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample notificationSample
+		err := json.Unmarshal(scanner.Bytes(), &sample)
+		if err != nil {
+			t.Fatalf("sample %d: %v", len(samples)+1, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return samples
+}
+
+func TestParseNotificationSamples(t *testing.T) {
+	samples := readNotificationSamples(t)
+
+	// every built-in template has a sample
+	templates := make(map[string]bool)
+	for _, sample := range samples {
+		if sample.Notification != nil {
+			templates[sample.Notification.Template] = true
+		}
+	}
+	for _, template := range NotificationTemplates {
+		if !templates[template.Name] {
+			t.Errorf("template %s has no samples", template.Name)
+		}
+	}
+
+	for _, sample := range samples {
+		t.Run(sample.Text, func(t *testing.T) {
+			got, _, err := ParseNotification(sample.Text, nil)
+
+			want := sample.Notification
+			if want == nil {
+				if !errors.Is(err, entity.UnknownNotificationErr) {
+					t.Errorf("parsed %+v, err %v, want %v", got, err, entity.UnknownNotificationErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Template != want.Template {
+				t.Errorf("template %s, want %s", got.Template, want.Template)
+			}
+			if got.Income != want.Income {
+				t.Errorf("income %t, want %t", got.Income, want.Income)
+			}
+			if got.Amount != want.Amount {
+				t.Errorf("amount %v, want %v", got.Amount, want.Amount)
+			}
+			if got.Currency != want.Currency {
+				t.Errorf("currency %s, want %s", got.Currency, want.Currency)
+			}
+			if got.Merchant != want.Merchant {
+				t.Errorf("merchant %q, want %q", got.Merchant, want.Merchant)
+			}
+			if got.Card != want.Card {
+				t.Errorf("card %q, want %q", got.Card, want.Card)
+			}
+			switch {
+			case got.Balance == nil && want.Balance == nil:
+			case got.Balance == nil || want.Balance == nil:
+				t.Errorf("balance %v, want %v", got.Balance, want.Balance)
+			case *got.Balance != *want.Balance:
+				t.Errorf("balance %v, want %v", *got.Balance, *want.Balance)
+			}
+		})
+	}
+}
+
+func TestParseNotificationPrefersTemplatesOfUser(t *testing.T) {
+	templates := []entity.NotificationTemplate{{
+		Name:    "sber",
+		Pattern: `^(?P<card>\d{4}) (?P<amount>\d+) (?P<merchant>\S+)$`,
+	}}
+
+	notification, template, err := ParseNotification("1234 99 MTS", templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if template.Pattern != templates[0].Pattern || notification.Amount != 99 || notification.Currency != entity.DefaultCurrency {
+		t.Errorf("parsed %+v with template %+v, want the template of the user", notification, template)
+	}
+
+	// the built-in template of the same name is replaced
+	_, _, err = ParseNotification("MIR-1234 12:34 Покупка 1 250р PYATEROCHKA Баланс: 10 000.50р", templates)
+	if !errors.Is(err, entity.UnknownNotificationErr) {
+		t.Errorf("err %v, want %v", err, entity.UnknownNotificationErr)
+	}
+}
+
+func TestNotificationTransaction(t *testing.T) {
+	date := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	samples := readNotificationSamples(t)
+
+	purchase, template, err := ParseNotification(samples[0].Text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction := NotificationTransaction(purchase, template, date, map[string]string{"pyaterochka": "groceries"})
+	if transaction.FromAccount != "card1234" || transaction.ToAccount != "groceries" || transaction.Amount != 1250 || transaction.Currency != "" {
+		t.Errorf("transaction of a purchase %+v", transaction)
+	}
+
+	income, template, err := ParseNotification(samples[3].Text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction = NotificationTransaction(income, template, date, nil)
+	if transaction.ToAccount != "card1234" || transaction.Amount != 5000 {
+		t.Errorf("transaction of an income %+v", transaction)
+	}
+}
@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+// ParseOFX reads OFX 1.x (SGML) and 2.x (XML) statements, FITID of a transaction
+// is its deduplication key and the statement's ACCTID is its account unless options override it
+func ParseOFX(r io.Reader, options Options) ([]entity.ImportedTransaction, error) {
+	options = options.withDefaults()
+
+	var imported []entity.ImportedTransaction
+	var account string
+	var trn map[string]string
+
+	err := scanOFX(r, func(path []string, tag, value string) error {
+		switch {
+		case tag == "STMTTRN" && value == "":
+			trn = make(map[string]string)
+		case tag == "/STMTTRN":
+			t, err := makeOFXTransaction(trn, account, options)
+			if err != nil {
+				return err
+			}
+			imported = append(imported, t)
+			trn = nil
+		case tag == "ACCTID" && (parent(path) == "BANKACCTFROM" || parent(path) == "CCACCTFROM"):
+			account = value
+		case trn != nil:
+			trn[tag] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+func makeOFXTransaction(trn map[string]string, account string, options Options) (entity.ImportedTransaction, error) {
+	if trn["FITID"] == "" {
+		return entity.ImportedTransaction{}, errors.New("ofx: transaction without FITID")
+	}
+
+	date, err := parseOFXDate(trn["DTPOSTED"])
+	if err != nil {
+		return entity.ImportedTransaction{}, fmt.Errorf("ofx: transaction %s: %w", trn["FITID"], err)
+	}
+
+	amount, err := ParseAmount(trn["TRNAMT"])
+	if err != nil {
+		return entity.ImportedTransaction{}, fmt.Errorf("ofx: transaction %s: %w", trn["FITID"], err)
+	}
+
+	if options.Account != "" {
+		account = options.Account
+	}
+	if account == "" {
+		account = options.DefaultAccount
+	}
+	if account == "" {
+		return entity.ImportedTransaction{}, errors.New("ofx: statement has no account")
+	}
+
+	description := trn["NAME"]
+	if memo := trn["MEMO"]; memo != "" && memo != description {
+		description = strings.TrimSpace(description + " " + memo)
+	}
+
+	return entity.ImportedTransaction{
+		Key:         "ofx:" + account + ":" + trn["FITID"],
+		Transaction: options.transaction(date, amount, account, collapseSpaces(description)),
+	}, nil
+}
+
+// parseOFXDate parses dates like "20220501", "20220501183000" and "20220501183000.000[+3:MSK]"
+func parseOFXDate(s string) (time.Time, error) {
+	location := time.UTC
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		zone := strings.TrimSuffix(s[i+1:], "]")
+		s = s[:i]
+
+		offset := strings.SplitN(zone, ":", 2)[0]
+		var hours float64
+		if _, err := fmt.Sscanf(offset, "%g", &hours); err == nil {
+			location = time.FixedZone(zone, int(hours*3600))
+		}
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s = s[:i]
+	}
+
+	var layout string
+	switch len(s) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	case 14:
+		layout = "20060102150405"
+	default:
+		return time.Time{}, fmt.Errorf("invalid date %s", s)
+	}
+
+	date, err := time.ParseInLocation(layout, s, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s", s)
+	}
+	return date.UTC(), nil
+}
+
+// scanOFX calls fn for every tag of the document, SGML leaf elements have no closing tags,
+// so closing tags are only reported for aggregates and value is empty for them and for opening ones
+func scanOFX(r io.Reader, fn func(path []string, tag, value string) error) error {
+	br := bufio.NewReader(r)
+
+	// skip OFX 1.x header lines and the XML declaration up to the root element
+	for {
+		b, err := br.Peek(5)
+		if err != nil {
+			return errors.New("ofx: no OFX element")
+		}
+		if strings.EqualFold(string(b), "<OFX>") {
+			break
+		}
+		_, _ = br.Discard(1)
+	}
+
+	var path []string
+	for {
+		_, err := br.ReadString('<')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		tag, err := br.ReadString('>')
+		if err != nil {
+			return errors.New("ofx: unterminated tag")
+		}
+		tag = strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(tag, ">")))
+
+		text, err := br.ReadString('<')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == nil {
+			_ = br.UnreadByte()
+		}
+		value := strings.TrimSpace(html.UnescapeString(strings.TrimSuffix(text, "<")))
+
+		switch {
+		case strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+			continue
+		case strings.HasPrefix(tag, "/"):
+			// closing tags of XML leaf elements are not aggregates, while empty SGML
+			// leaf elements are never closed and get closed with their aggregate
+			i := len(path) - 1
+			for ; i >= 0 && path[i] != tag[1:]; i-- {
+			}
+			if i < 0 {
+				continue
+			}
+			path = path[:i]
+			err = fn(path, tag, "")
+		case value != "":
+			err = fn(path, tag, value)
+		default:
+			err = fn(path, tag, "")
+			path = append(path, tag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func parent(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return path[len(path)-1]
+}
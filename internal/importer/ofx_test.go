@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"enigma/internal/entity"
+)
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		file string
+		keys []string
+		want []entity.Transaction
+	}{
+		{
+			file: "statement-v1.ofx",
+			keys: []string{
+				"ofx:40817810000000000001:2022050100001",
+				"ofx:40817810000000000001:2022050500002",
+				"ofx:40817810000000000001:2022051200003",
+			},
+			want: []entity.Transaction{
+				{Date: date("2022-05-01 15:30"), FromAccount: "40817810000000000001", ToAccount: "expenses", Amount: 1250, Description: "PYATEROCHKA 1234"},
+				{Date: date("2022-05-05"), FromAccount: "income", ToAccount: "40817810000000000001", Amount: 85000, Description: "SALARY May salary #work"},
+				{Date: date("2022-05-12"), FromAccount: "40817810000000000001", ToAccount: "expenses", Amount: 349.9, Description: "APTEKA 36.6 Pharmacy & care"},
+			},
+		},
+		{
+			file: "statement-v2.ofx",
+			keys: []string{
+				"ofx:5536000000001234:CC-20220503-1",
+				"ofx:5536000000001234:CC-20220520-2",
+				"ofx:5536000000001234:CC-20220525-3",
+			},
+			want: []entity.Transaction{
+				{Date: date("2022-05-03 07:15"), FromAccount: "5536000000001234", ToAccount: "expenses", Amount: 2990, Description: "OZON.RU"},
+				{Date: date("2022-05-20"), FromAccount: "5536000000001234", ToAccount: "expenses", Amount: 15400, Description: "AEROFLOT Tickets to Sochi #vacation"},
+				{Date: date("2022-05-25"), FromAccount: "income", ToAccount: "5536000000001234", Amount: 18390, Description: "PAYMENT THANK YOU"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			imported, err := ParseOFX(openTestdata(t, test.file), Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkTransactions(t, transactionsOf(imported), test.want)
+			for i := range imported {
+				if i < len(test.keys) && imported[i].Key != test.keys[i] {
+					t.Errorf("transaction %d has key %s, want %s", i, imported[i].Key, test.keys[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseOFXAccountOptions(t *testing.T) {
+	imported, err := ParseOFX(openTestdata(t, "statement-v1.ofx"), Options{Account: "checking", ExpenseAccount: "groceries"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := imported[0]
+	if first.Transaction.FromAccount != "checking" || first.Transaction.ToAccount != "groceries" || first.Key != "ofx:checking:2022050100001" {
+		t.Errorf("account options are not applied: %+v", first)
+	}
+
+	// a statement naming no account takes the default one
+	statement := "<OFX><STMTTRN><DTPOSTED>20220501<TRNAMT>-1<FITID>1</STMTTRN></OFX>"
+	imported, err = ParseOFX(strings.NewReader(statement), Options{DefaultAccount: "wallet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 1 || imported[0].Transaction.FromAccount != "wallet" {
+		t.Errorf("statement without account is imported as %+v", imported)
+	}
+}
+
+func TestParseOFXRejectsInvalidTransactions(t *testing.T) {
+	tests := map[string]string{
+		"no FITID":     "<OFX><BANKACCTFROM><ACCTID>1</BANKACCTFROM><STMTTRN><DTPOSTED>20220501<TRNAMT>-1</STMTTRN></OFX>",
+		"invalid date": "<OFX><BANKACCTFROM><ACCTID>1</BANKACCTFROM><STMTTRN><DTPOSTED>2022-05-01<TRNAMT>-1<FITID>1</STMTTRN></OFX>",
+		"no amount":    "<OFX><BANKACCTFROM><ACCTID>1</BANKACCTFROM><STMTTRN><DTPOSTED>20220501<FITID>1</STMTTRN></OFX>",
+		"no account":   "<OFX><STMTTRN><DTPOSTED>20220501<TRNAMT>-1<FITID>1</STMTTRN></OFX>",
+	}
+	for name, statement := range tests {
+		_, err := ParseOFX(strings.NewReader(statement), Options{})
+		if err == nil {
+			t.Errorf("%s: statement is parsed", name)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+var qifSupportedTypes = map[string]bool{"bank": true, "ccard": true, "cash": true}
+
+// QIFTransaction is a QIF record before its categories are mapped onto accounts
+type QIFTransaction struct {
+	Key      string
+	Account  string
+	Date     time.Time
+	Amount   float64
+	Payee    string
+	Memo     string
+	Category string
+	Splits   []QIFSplit
+}
+
+type QIFSplit struct {
+	Category string
+	Memo     string
+	Amount   float64
+}
+
+// ParseQIF reads !Type:Bank, !Type:CCard and !Type:Cash sections of a QIF file,
+// sections of other types, e.g. category lists and investments, are skipped
+func ParseQIF(r io.Reader, options Options) ([]QIFTransaction, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	account := options.Account
+	if account == "" {
+		account = options.DefaultAccount
+	}
+
+	var transactions []QIFTransaction
+	var current QIFTransaction
+	var section string
+	var accountHeader bool
+	occurrences := make(map[string]int)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, string(utf8BOM))
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToLower(strings.TrimSpace(line[1:]))
+			switch {
+			case header == "account":
+				accountHeader = true
+			case strings.HasPrefix(header, "type:"):
+				section = strings.TrimSpace(strings.TrimPrefix(header, "type:"))
+				accountHeader = false
+			}
+			continue
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+
+		if accountHeader {
+			switch code {
+			case 'N':
+				if options.Account == "" {
+					account = value
+				}
+			case '^':
+				accountHeader = false
+			}
+			continue
+		}
+
+		if !qifSupportedTypes[section] {
+			continue
+		}
+
+		var err error
+		switch code {
+		case 'D':
+			current.Date, err = parseQIFDate(value)
+		case 'T', 'U':
+			current.Amount, err = ParseAmount(value)
+		case 'P':
+			current.Payee = value
+		case 'M':
+			current.Memo = value
+		case 'L':
+			current.Category = value
+		case 'S':
+			current.Splits = append(current.Splits, QIFSplit{Category: value})
+		case 'E':
+			if len(current.Splits) > 0 {
+				current.Splits[len(current.Splits)-1].Memo = value
+			}
+		case '$':
+			if len(current.Splits) > 0 {
+				current.Splits[len(current.Splits)-1].Amount, err = ParseAmount(value)
+			}
+		case '^':
+			if account == "" {
+				return nil, fmt.Errorf("qif: line %d: statement has no account", lineNumber)
+			}
+			if current.Date.IsZero() {
+				return nil, fmt.Errorf("qif: line %d: transaction without date", lineNumber)
+			}
+
+			current.Account = account
+			current.Key = qifKey(current, occurrences)
+			transactions = append(transactions, current)
+			current = QIFTransaction{}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("qif: line %d: %w", lineNumber, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// QIFCategories returns categories used by transactions, transfers to other accounts are not included
+func QIFCategories(transactions []QIFTransaction) []string {
+	seen := make(map[string]struct{})
+	add := func(category string) {
+		if category != "" && qifTransferAccount(category) == "" {
+			seen[category] = struct{}{}
+		}
+	}
+
+	for _, t := range transactions {
+		if len(t.Splits) == 0 {
+			add(t.Category)
+		}
+		for _, split := range t.Splits {
+			add(split.Category)
+		}
+	}
+
+	categories := make([]string, 0, len(seen))
+	for category := range seen {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// MapQIF turns QIF records into transactions, categories are replaced with accounts they are mapped to,
+// unmapped categories become accounts themselves, and every split becomes a separate transaction
+func MapQIF(transactions []QIFTransaction, accounts map[string]string, options Options) []entity.ImportedTransaction {
+	options = options.withDefaults()
+
+	counterAccount := func(category string, amount float64) string {
+		if transfer := qifTransferAccount(category); transfer != "" {
+			return transfer
+		}
+		if account, ok := accounts[category]; ok {
+			return account
+		}
+		if category != "" {
+			return category
+		}
+		if amount < 0 {
+			return options.ExpenseAccount
+		}
+		return options.IncomeAccount
+	}
+
+	var imported []entity.ImportedTransaction
+	for _, t := range transactions {
+		if len(t.Splits) == 0 {
+			imported = append(imported, entity.ImportedTransaction{
+				Key:         t.Key,
+				Transaction: qifTransaction(t.Date, t.Amount, t.Account, counterAccount(t.Category, t.Amount), t.Payee, t.Memo),
+			})
+			continue
+		}
+
+		for i, split := range t.Splits {
+			memo := split.Memo
+			if memo == "" {
+				memo = t.Memo
+			}
+			imported = append(imported, entity.ImportedTransaction{
+				Key:         t.Key + ":" + strconv.Itoa(i),
+				Transaction: qifTransaction(t.Date, split.Amount, t.Account, counterAccount(split.Category, split.Amount), t.Payee, memo),
+			})
+		}
+	}
+
+	return imported
+}
+
+func qifTransaction(date time.Time, amount float64, account, counterAccount, payee, memo string) entity.Transaction {
+	description := payee
+	if memo != "" && memo != payee {
+		description = strings.TrimSpace(payee + " " + memo)
+	}
+
+	if amount < 0 {
+		return entity.Transaction{
+			Date:        date,
+			FromAccount: account,
+			ToAccount:   counterAccount,
+			Amount:      -amount,
+			Description: collapseSpaces(description),
+		}
+	}
+
+	return entity.Transaction{
+		Date:        date,
+		FromAccount: counterAccount,
+		ToAccount:   account,
+		Amount:      amount,
+		Description: collapseSpaces(description),
+	}
+}
+
+// qifKey hashes the record, transfers are exported by both accounts, so their
+// key is built from the pair of accounts to import them once
+func qifKey(t QIFTransaction, occurrences map[string]int) string {
+	fields := []string{t.Date.Format("2006-01-02")}
+
+	if transfer := qifTransferAccount(t.Category); transfer != "" && len(t.Splits) == 0 {
+		pair := []string{t.Account, transfer}
+		sort.Strings(pair)
+		fields = append(fields, "transfer", pair[0], pair[1], strconv.FormatFloat(math.Abs(t.Amount), 'f', 2, 64))
+	} else {
+		fields = append(fields, t.Account, strconv.FormatFloat(t.Amount, 'f', 2, 64), t.Payee, t.Memo, t.Category)
+	}
+
+	// occurrences are counted per account, so both sides of a transfer get the same key
+	content := strings.Join(fields, "\x1f")
+	occurrences[content+"\x1f"+t.Account]++
+	return hashKey("qif", content, strconv.Itoa(occurrences[content+"\x1f"+t.Account]))
+}
+
+// qifTransferAccount returns the account name of transfer categories like "[Savings]"
+func qifTransferAccount(category string) string {
+	if strings.HasPrefix(category, "[") && strings.HasSuffix(category, "]") {
+		return category[1 : len(category)-1]
+	}
+	return ""
+}
+
+// parseQIFDate parses "05/01/2022", "5/ 1'22", "01.05.2022" and "2022-05-01", dates with slashes are month first
+func parseQIFDate(s string) (time.Time, error) {
+	normalized := strings.ReplaceAll(strings.ReplaceAll(s, " ", ""), "'", "/")
+
+	separator := "/"
+	for _, candidate := range []string{".", "-"} {
+		if strings.Contains(normalized, candidate) {
+			separator = candidate
+		}
+	}
+
+	parts := strings.Split(normalized, separator)
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date %s", s)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %s", s)
+		}
+		numbers[i] = n
+	}
+
+	var year, month, day int
+	switch {
+	case len(parts[0]) == 4:
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	case separator == ".":
+		day, month, year = numbers[0], numbers[1], numbers[2]
+	default:
+		month, day, year = numbers[0], numbers[1], numbers[2]
+	}
+
+	if year < 100 {
+		// two digit years of old exports, "'" marks 2000s in Quicken
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date %s", s)
+	}
+	return date, nil
+}
@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"enigma/internal/entity"
+)
+
+func TestParseQIF(t *testing.T) {
+	transactions, err := ParseQIF(openTestdata(t, "history.qif"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// category lists and investments are skipped
+	if len(transactions) != 6 {
+		t.Fatalf("%d records, want 6", len(transactions))
+	}
+
+	ikea := transactions[1]
+	if !ikea.Date.Equal(date("2022-05-03")) || ikea.Amount != -3000 || len(ikea.Splits) != 2 ||
+		ikea.Splits[0].Amount != -2500 || ikea.Splits[1].Memo != "Hot dogs" {
+		t.Errorf("record with splits %+v", ikea)
+	}
+
+	categories := QIFCategories(transactions)
+	want := []string{"Food:Cafe", "Food:Groceries", "Household:Furniture", "Salary"}
+	if strings.Join(categories, ",") != strings.Join(want, ",") {
+		t.Errorf("categories %v, want %v", categories, want)
+	}
+
+	imported := MapQIF(transactions, map[string]string{"Food:Groceries": "groceries"}, Options{})
+	checkTransactions(t, transactionsOf(imported), []entity.Transaction{
+		{Date: date("2022-05-01"), FromAccount: "Checking", ToAccount: "groceries", Amount: 1250, Description: "Pyaterochka"},
+		{Date: date("2022-05-03"), FromAccount: "Checking", ToAccount: "Household:Furniture", Amount: 2500, Description: "IKEA New shelves #home"},
+		{Date: date("2022-05-03"), FromAccount: "Checking", ToAccount: "Food:Cafe", Amount: 500, Description: "IKEA Hot dogs"},
+		{Date: date("2022-05-05"), FromAccount: "Salary", ToAccount: "Checking", Amount: 85000, Description: "Employer"},
+		{Date: date("2022-05-10"), FromAccount: "Checking", ToAccount: "Savings", Amount: 10000, Description: "Transfer to savings"},
+		{Date: date("2022-05-10"), FromAccount: "Checking", ToAccount: "Savings", Amount: 10000, Description: "Transfer from checking"},
+		{Date: date("2022-05-01"), FromAccount: "Wallet", ToAccount: "Food:Cafe", Amount: 150, Description: "Coffee"},
+	})
+
+	// both accounts export the transfer, the records share the key, so it is imported once
+	if imported[4].Key != imported[5].Key {
+		t.Errorf("sides of the transfer have keys %s and %s", imported[4].Key, imported[5].Key)
+	}
+
+	keys := make(map[string]struct{})
+	for _, i := range imported {
+		keys[i.Key] = struct{}{}
+	}
+	if len(keys) != len(imported)-1 {
+		t.Errorf("%d distinct keys of %d transactions, only the transfer is expected to repeat", len(keys), len(imported))
+	}
+}
+
+func TestParseQIFKeysOfRepeatedRecords(t *testing.T) {
+	record := "D05/01/2022\nT-150\nPCoffee\n^\n"
+	transactions, err := ParseQIF(strings.NewReader("!Type:Cash\n"+record+record), Options{Account: "wallet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same coffee bought twice a day is two transactions
+	if len(transactions) != 2 || transactions[0].Key == transactions[1].Key {
+		t.Errorf("repeated records %+v, want two with different keys", transactions)
+	}
+}
+
+func TestParseQIFDate(t *testing.T) {
+	tests := map[string]string{
+		"05/01/2022": "2022-05-01",
+		"5/ 3'22":    "2022-05-03",
+		"01.05.2022": "2022-05-01",
+		"2022-05-01": "2022-05-01",
+	}
+	for s, want := range tests {
+		got, err := parseQIFDate(s)
+		if err != nil || !got.Equal(date(want)) {
+			t.Errorf("parseQIFDate(%q) = %v, %v, want %s", s, got, err, want)
+		}
+	}
+}
+
+func TestParseQIFRejectsRecordsWithoutAccount(t *testing.T) {
+	_, err := ParseQIF(strings.NewReader("!Type:Bank\nD05/01/2022\nT-1\n^\n"), Options{})
+	if err == nil {
+		t.Error("record without account is parsed")
+	}
+}
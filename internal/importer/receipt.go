@@ -0,0 +1,43 @@
+package importer
+
+import (
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+var NoQRCodeErr = errors.New("no QR code found on the photo")
+
+// DecodeQR returns the content of the QR code on a JPEG or PNG photo
+func DecodeQR(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", err
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+
+	// receipts are photographed at an angle and on crumpled paper, so the slower search is worth it
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, hints)
+	if err != nil {
+		var notFound gozxing.NotFoundException
+		if errors.As(err, &notFound) {
+			return "", NoQRCodeErr
+		}
+		return "", err
+	}
+
+	return result.GetText(), nil
+}
@@ -0,0 +1,230 @@
+// Package logger writes leveled logs of key-value pairs as logfmt text or JSON lines
+// such as the update or the user
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "level" + strconv.Itoa(int(l))
+	}
+	return levelNames[l]
+}
+
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %s, it is one of %s", s, strings.Join(levelNames, ", "))
+}
+
+// output is shared by a logger and the loggers derived from it with With
+type output struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+	json  bool
+}
+
+type Logger struct {
+	out *output
+
+	// fields are key-value pairs written with every entry, keys are strings
+	fields []interface{}
+}
+
+// New returns a logger writing entries of level and above to w, as JSON lines if asJSON is set
+func New(w io.Writer, level Level, asJSON bool) *Logger {
+	return &Logger{out: &output{w: w, level: level, json: asJSON}}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger = New(os.Stderr, LevelInfo, false)
+)
+
+// Default is the logger of code which is given no other, e.g. outside of handling an update
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying l, e.g. a logger tagged with the update being handled
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of ctx, the default one if ctx carries none
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default()
+}
+
+// NewTraceID returns a short random ID, it is shown to users so they can point at the entries of a failure
+func NewTraceID() string {
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano()%1e8, 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// With returns a logger adding the key-value pairs to every entry
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	return &Logger{out: l.out, fields: fields}
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.out.level
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+// Fields formats the key-value pairs of the logger as logfmt, e.g. for error reports
+func (l *Logger) Fields() string {
+	var b strings.Builder
+	writeText(&b, l.fields)
+	return strings.TrimPrefix(b.String(), " ")
+}
+
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := make([]interface{}, 0, 6+len(l.fields)+len(keyvals))
+	fields = append(fields, "time", time.Now().UTC().Format(time.RFC3339Nano), "level", level.String(), "msg", msg)
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+
+	var b strings.Builder
+	if l.out.json {
+		writeJSON(&b, fields)
+	} else {
+		writeText(&b, fields)
+	}
+	b.WriteByte('\n')
+	line := strings.TrimPrefix(b.String(), " ")
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	io.WriteString(l.out.w, line)
+}
+
+// pairs calls fn for every key-value pair, a key without a value gets the value "MISSING"
+func pairs(keyvals []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{} = "MISSING"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fn(key, value)
+	}
+}
+
+func writeText(b *strings.Builder, keyvals []interface{}) {
+	pairs(keyvals, func(key string, value interface{}) {
+		s := formatValue(value)
+		if s == "" || strings.ContainsAny(s, " =\"\\\n\t") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(" " + key + "=" + s)
+	})
+}
+
+func writeJSON(b *strings.Builder, keyvals []interface{}) {
+	b.WriteByte('{')
+	first := true
+	pairs(keyvals, func(key string, value interface{}) {
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+
+		encodedKey, _ := json.Marshal(key)
+		b.Write(encodedKey)
+		b.WriteByte(':')
+
+		switch value.(type) {
+		case error, fmt.Stringer:
+			value = formatValue(value)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			encoded, _ = json.Marshal(fmt.Sprint(value))
+		}
+		b.Write(encoded)
+	})
+	b.WriteByte('}')
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,264 @@
+// Package metrics counts what the running bot does and exposes it in the Prometheus text format,
+// metrics are registered once as package variables where they are measured
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are upper bounds in seconds fitting request latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+var registry struct {
+	sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+func register(name string, m metric) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registry.names == nil {
+		registry.names = make(map[string]bool)
+	}
+	if registry.names[name] {
+		panic("metric " + name + " is registered twice")
+	}
+	registry.names[name] = true
+	registry.metrics = append(registry.metrics, m)
+}
+
+// Handler serves every registered metric
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		registry.Lock()
+		metrics := append([]metric{}, registry.metrics...)
+		registry.Unlock()
+
+		buffered := bufio.NewWriter(w)
+		for _, m := range metrics {
+			m.write(buffered)
+		}
+		buffered.Flush()
+	})
+}
+
+// series are values of a metric by values of its labels
+type series struct {
+	sync.Mutex
+	labels []string
+	values map[string]*value
+}
+
+type value struct {
+	labels []string
+
+	sum float64
+
+	// counts of histograms are per bucket, the last one is +Inf
+	count  uint64
+	counts []uint64
+}
+
+func (s *series) get(labels []string, buckets int) *value {
+	if len(labels) != len(s.labels) {
+		panic(fmt.Sprintf("%d label values for labels %v", len(labels), s.labels))
+	}
+
+	key := strings.Join(labels, "\xff")
+	v, ok := s.values[key]
+	if !ok {
+		v = &value{labels: append([]string{}, labels...)}
+		if buckets > 0 {
+			v.counts = make([]uint64, buckets+1)
+		}
+		s.values[key] = v
+	}
+	return v
+}
+
+// sorted returns values ordered by their labels, so scrapes are stable
+func (s *series) sorted() []value {
+	values := make([]value, 0, len(s.values))
+	for _, v := range s.values {
+		copied := *v
+		copied.counts = append([]uint64{}, v.counts...)
+		values = append(values, copied)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return strings.Join(values[i].labels, "\xff") < strings.Join(values[j].labels, "\xff")
+	})
+	return values
+}
+
+// Counter is a total which only grows, e.g. of handled updates
+type Counter struct {
+	name, help string
+	series
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, series: series{labels: labels, values: make(map[string]*value)}}
+	register(name, c)
+	return c
+}
+
+// Inc adds one to the counter of label values, they go in the order of the labels of the counter
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *Counter) Add(delta float64, labels ...string) {
+	c.Lock()
+	defer c.Unlock()
+	c.get(labels, 0).sum += delta
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.Lock()
+	values := c.sorted()
+	c.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, v := range values {
+		writeSample(w, c.name, c.labels, v.labels, "", "", v.sum)
+	}
+}
+
+// Histogram counts observations, e.g. durations, in buckets of upper bounds
+type Histogram struct {
+	name, help string
+	buckets    []float64
+	series
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	h := &Histogram{name: name, help: help, buckets: buckets, series: series{labels: labels, values: make(map[string]*value)}}
+	register(name, h)
+	return h
+}
+
+func (h *Histogram) Observe(observation float64, labels ...string) {
+	i := sort.SearchFloat64s(h.buckets, observation)
+
+	h.Lock()
+	defer h.Unlock()
+
+	v := h.get(labels, len(h.buckets))
+	v.counts[i]++
+	v.count++
+	v.sum += observation
+}
+
+// Since observes the seconds passed since start
+func (h *Histogram) Since(start time.Time, labels ...string) {
+	h.Observe(time.Since(start).Seconds(), labels...)
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.Lock()
+	values := h.sorted()
+	h.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, v := range values {
+		var cumulative uint64
+		for i, count := range v.counts {
+			cumulative += count
+
+			bound := math.Inf(1)
+			if i < len(h.buckets) {
+				bound = h.buckets[i]
+			}
+			writeSample(w, h.name+"_bucket", h.labels, v.labels, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_sum", h.labels, v.labels, "", "", v.sum)
+		writeSample(w, h.name+"_count", h.labels, v.labels, "", "", float64(v.count))
+	}
+}
+
+// GaugeFunc is a value read when metrics are scraped, e.g. the size of the database
+type GaugeFunc struct {
+	name, help string
+	read       func() float64
+}
+
+func NewGaugeFunc(name, help string, read func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, read: read}
+	register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	writeSample(w, g.name, nil, nil, "", "", g.read())
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes a line of the metric, extraLabel goes after the labels, e.g. le of buckets
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, sample float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 || extraLabel != "" {
+		escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, escape.Replace(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(sample))
+	w.WriteByte('\n')
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
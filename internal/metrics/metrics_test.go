@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func exposition(m metric) string {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	m.write(w)
+	w.Flush()
+	return b.String()
+}
+
+func checkExposition(t *testing.T, got string, want ...string) {
+	t.Helper()
+
+	if expected := strings.Join(want, "\n") + "\n"; got != expected {
+		t.Errorf("exposition\n%s\nwant\n%s", got, expected)
+	}
+}
+
+func TestHistogramBucketsAreCumulative(t *testing.T) {
+	h := NewHistogram("test_duration_seconds", "Test durations.", []float64{1, 0.1, 0.5}, "handler")
+
+	// an observation equal to the bound falls into its bucket
+	for _, observation := range []float64{0.05, 0.1, 0.3, 0.7, 2, 3} {
+		h.Observe(observation, "start")
+	}
+	h.Observe(0.2, "help")
+
+	checkExposition(t, exposition(h),
+		"# HELP test_duration_seconds Test durations.",
+		"# TYPE test_duration_seconds histogram",
+		`test_duration_seconds_bucket{handler="help",le="0.1"} 0`,
+		`test_duration_seconds_bucket{handler="help",le="0.5"} 1`,
+		`test_duration_seconds_bucket{handler="help",le="1"} 1`,
+		`test_duration_seconds_bucket{handler="help",le="+Inf"} 1`,
+		`test_duration_seconds_sum{handler="help"} 0.2`,
+		`test_duration_seconds_count{handler="help"} 1`,
+		`test_duration_seconds_bucket{handler="start",le="0.1"} 2`,
+		`test_duration_seconds_bucket{handler="start",le="0.5"} 3`,
+		`test_duration_seconds_bucket{handler="start",le="1"} 4`,
+		`test_duration_seconds_bucket{handler="start",le="+Inf"} 6`,
+		`test_duration_seconds_sum{handler="start"} 6.15`,
+		`test_duration_seconds_count{handler="start"} 6`,
+	)
+}
+
+func TestLabelValuesAreEscaped(t *testing.T) {
+	c := NewCounter("test_errors_total", "Test errors,\nby \\ reason.", "reason")
+	c.Inc(`quote " and \ backslash`)
+	c.Add(2, "line\nbreak")
+
+	checkExposition(t, exposition(c),
+		`# HELP test_errors_total Test errors,\nby \\ reason.`,
+		"# TYPE test_errors_total counter",
+		`test_errors_total{reason="line\nbreak"} 2`,
+		`test_errors_total{reason="quote \" and \\ backslash"} 1`,
+	)
+}
+
+func TestMetricsWithoutLabels(t *testing.T) {
+	c := NewCounter("test_updates_total", "Test updates.")
+	c.Inc()
+	g := NewGaugeFunc("test_size_bytes", "Test size.", func() float64 { return 1 << 20 })
+
+	checkExposition(t, exposition(c),
+		"# HELP test_updates_total Test updates.",
+		"# TYPE test_updates_total counter",
+		"test_updates_total 1",
+	)
+	checkExposition(t, exposition(g),
+		"# HELP test_size_bytes Test size.",
+		"# TYPE test_size_bytes gauge",
+		"test_size_bytes 1.048576e+06",
+	)
+}
+
+func TestHandler(t *testing.T) {
+	NewCounter("test_handler_total", "Test handler.").Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("response %d of %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "\ntest_handler_total 1\n") {
+		t.Errorf("metric is not served in\n%s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST is answered with %d", w.Code)
+	}
+}
+
+func TestMetricRegisteredTwice(t *testing.T) {
+	NewCounter("test_twice_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Error("metric is registered twice")
+		}
+	}()
+	NewCounter("test_twice_total", "Test.")
+}
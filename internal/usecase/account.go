@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"enigma/internal/entity"
+)
+
+type GetAccounts struct {
+	accountRepo     accountRepository
+	transactionRepo transactionRepository
+}
+
+func NewGetAccounts(accountRepo accountRepository, transactionRepo transactionRepository) *GetAccounts {
+	return &GetAccounts{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// Execute returns every known account, types which have not been set are inferred from names
+func (g *GetAccounts) Execute(ctx context.Context) ([]entity.Account, error) {
+	return getAccounts(ctx, g.accountRepo, g.transactionRepo)
+}
+
+type SetAccountType struct {
+	repo accountRepository
+}
+
+func NewSetAccountType(repo accountRepository) *SetAccountType {
+	return &SetAccountType{
+		repo: repo,
+	}
+}
+
+func (s *SetAccountType) Execute(ctx context.Context, name string, accountType entity.AccountType) error {
+	account, err := getAccount(ctx, s.repo, name)
+	if err != nil {
+		return err
+	}
+
+	account.Type = accountType
+	return s.repo.Save(ctx, account)
+}
+
+type SetAccountFloor struct {
+	repo accountRepository
+}
+
+func NewSetAccountFloor(repo accountRepository) *SetAccountFloor {
+	return &SetAccountFloor{
+		repo: repo,
+	}
+}
+
+// Execute sets the lowest balance the account should be kept above, nil floor removes it
+func (s *SetAccountFloor) Execute(ctx context.Context, name string, floor *float64) error {
+	account, err := getAccount(ctx, s.repo, name)
+	if err != nil {
+		return err
+	}
+
+	account.Floor = floor
+	return s.repo.Save(ctx, account)
+}
+
+type DeclareAccounts struct {
+	repo accountRepository
+}
+
+func NewDeclareAccounts(repo accountRepository) *DeclareAccounts {
+	return &DeclareAccounts{
+		repo: repo,
+	}
+}
+
+// Execute saves accounts which are not saved yet, types and floors set before are kept
+func (d *DeclareAccounts) Execute(ctx context.Context, accounts []entity.Account) (int, error) {
+	declared := 0
+	for _, account := range accounts {
+		if account.Name == "" {
+			return declared, errors.New("account name is required")
+		}
+
+		_, err := d.repo.Get(ctx, account.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, entity.AccountNotFoundErr) {
+			return declared, err
+		}
+
+		err = d.repo.Save(ctx, account)
+		if err != nil {
+			return declared, err
+		}
+		declared++
+	}
+	return declared, nil
+}
+
+// getAccount returns the saved account or a new one with the type inferred from its name
+func getAccount(ctx context.Context, repo accountRepository, name string) (entity.Account, error) {
+	if name == "" {
+		return entity.Account{}, errors.New("account name is required")
+	}
+
+	account, err := repo.Get(ctx, name)
+	if errors.Is(err, entity.AccountNotFoundErr) {
+		return entity.Account{Name: name, Type: entity.InferAccountType(name)}, nil
+	}
+	return account, err
+}
+
+func getAccounts(ctx context.Context, accountRepo accountRepository, transactionRepo transactionRepository) ([]entity.Account, error) {
+	configured, err := accountRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := transactionRepo.GetAccountNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]entity.Account, len(names))
+	for _, name := range names {
+		byName[name] = entity.Account{Name: name, Type: entity.InferAccountType(name)}
+	}
+	for _, account := range configured {
+		byName[account.Name] = account
+	}
+
+	accounts := make([]entity.Account, 0, len(byName))
+	for _, account := range byName {
+		accounts = append(accounts, account)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Type == accounts[j].Type {
+			return accounts[i].Name < accounts[j].Name
+		}
+		return accountTypeOrder(accounts[i].Type) < accountTypeOrder(accounts[j].Type)
+	})
+
+	return accounts, nil
+}
+
+func accountTypeOrder(t entity.AccountType) int {
+	for i, known := range entity.AccountTypes {
+		if t == known {
+			return i
+		}
+	}
+	return len(entity.AccountTypes)
+}
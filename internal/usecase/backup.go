@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"enigma/internal/encryption"
+	"enigma/internal/entity"
+)
+
+type CreateBackup struct {
+	repo backupRepository
+}
+
+func NewCreateBackup(repo backupRepository) *CreateBackup {
+	return &CreateBackup{
+		repo: repo,
+	}
+}
+
+// Execute writes a snapshot of the database to w, encrypted if passphrase is not empty
+func (c *CreateBackup) Execute(ctx context.Context, w io.Writer, passphrase string) error {
+	if passphrase == "" {
+		_, err := c.repo.WriteTo(ctx, w)
+		return err
+	}
+
+	encrypted, err := encryption.NewWriter(w, passphrase)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.repo.WriteTo(ctx, encrypted)
+	if err != nil {
+		return err
+	}
+
+	return encrypted.Close()
+}
+
+type InspectBackup struct {
+	repo backupRepository
+}
+
+func NewInspectBackup(repo backupRepository) *InspectBackup {
+	return &InspectBackup{
+		repo: repo,
+	}
+}
+
+// Execute validates the backup read from r, entity.BackupPassphraseRequiredErr is returned
+// for encrypted backups if passphrase is empty
+func (i *InspectBackup) Execute(ctx context.Context, r io.Reader, passphrase string) (entity.BackupInfo, error) {
+	decrypted, err := decryptBackup(r, passphrase)
+	if err != nil {
+		return entity.BackupInfo{}, err
+	}
+	return i.repo.Inspect(ctx, decrypted)
+}
+
+type RestoreBackup struct {
+	repo backupRepository
+}
+
+func NewRestoreBackup(repo backupRepository) *RestoreBackup {
+	return &RestoreBackup{
+		repo: repo,
+	}
+}
+
+// Execute replaces the whole database with the backup read from r once it is validated
+func (rb *RestoreBackup) Execute(ctx context.Context, r io.Reader, passphrase string) (entity.BackupInfo, error) {
+	decrypted, err := decryptBackup(r, passphrase)
+	if err != nil {
+		return entity.BackupInfo{}, err
+	}
+	return rb.repo.Restore(ctx, decrypted)
+}
+
+func decryptBackup(r io.Reader, passphrase string) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	if !encryption.IsEncrypted(buffered) {
+		return buffered, nil
+	}
+
+	if passphrase == "" {
+		return nil, entity.BackupPassphraseRequiredErr
+	}
+	return encryption.NewReader(buffered, passphrase)
+}
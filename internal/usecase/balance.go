@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+type GetBalances struct {
+	accountRepo     accountRepository
+	transactionRepo transactionRepository
+}
+
+func NewGetBalances(accountRepo accountRepository, transactionRepo transactionRepository) *GetBalances {
+	return &GetBalances{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// Execute returns balances of every account at the end of date, an account has a balance for every currency it was used in
+func (g *GetBalances) Execute(ctx context.Context, date time.Time) ([]entity.AccountBalance, error) {
+	accounts, err := getAccounts(ctx, g.accountRepo, g.transactionRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	type balanceKey struct {
+		account  string
+		currency string
+	}
+
+	balances := make(map[balanceKey]float64)
+	until := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	err = g.transactionRepo.ForEachInDateRange(ctx, time.Time{}, until, func(t entity.Transaction) error {
+		balances[balanceKey{t.FromAccount, t.CurrencyCode()}] -= t.Amount
+		balances[balanceKey{t.ToAccount, t.CurrencyCode()}] += t.Amount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]entity.Account, len(accounts))
+	for _, account := range accounts {
+		byName[account.Name] = account
+	}
+
+	result := make([]entity.AccountBalance, 0, len(balances))
+	for key, balance := range balances {
+		account, ok := byName[key.account]
+		if !ok {
+			account = entity.Account{Name: key.account}
+		}
+		result = append(result, entity.AccountBalance{Account: account, Currency: key.currency, Balance: balance})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Account.Name == result[j].Account.Name {
+			return result[i].Currency < result[j].Currency
+		}
+		return result[i].Account.Name < result[j].Account.Name
+	})
+
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package usecase_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase"
+	"enigma/internal/usecase/repository/account"
+	"enigma/internal/usecase/repository/goal"
+	"enigma/internal/usecase/repository/networth"
+	"enigma/internal/usecase/repository/transaction"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type repositories struct {
+	transactions *transaction.BoltDBRepository
+	accounts     *account.BoltDBRepository
+	netWorth     *networth.BoltDBRepository
+	goals        *goal.BoltDBRepository
+}
+
+// newLedger saves transactions of a card used in roubles and euros, the salary comes every month
+func newLedger(t *testing.T) repositories {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "enigma.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var r repositories
+	r.transactions, err = transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.accounts, err = account.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.netWorth, err = networth.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.goals, err = goal.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, transaction := range []entity.Transaction{
+		{Date: today.AddDate(0, -2, 0), FromAccount: "salary", ToAccount: "card", Amount: 1000, Description: "salary #work", Tags: []string{"work"}},
+		{Date: today.AddDate(0, -1, 0), FromAccount: "salary", ToAccount: "card", Amount: 1000, Description: "salary #work", Tags: []string{"work"}},
+		{Date: today.AddDate(0, -1, 0), FromAccount: "salary", ToAccount: "card", Amount: 300, Currency: "EUR", Description: "bonus #work", Tags: []string{"work"}},
+		{Date: today, FromAccount: "card", ToAccount: "cafe", Amount: 30, Currency: "EUR", Description: "dinner"},
+		{Date: today, FromAccount: "card", ToAccount: "cafe", Amount: 60, Description: "lunch"},
+	} {
+		_, err = r.transactions.Create(ctx, transaction, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = usecase.NewSetAccountType(r.accounts).Execute(ctx, "card", entity.AssetAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestForecastByCurrency(t *testing.T) {
+	r := newLedger(t)
+
+	forecasts, err := usecase.NewForecast(r.transactions, r.accounts).Execute(context.Background(), 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"EUR": 270, "RUB": 1940}
+	if len(forecasts) != len(want) {
+		t.Fatalf("%d forecasts, want one for every currency of the card", len(forecasts))
+	}
+	for _, f := range forecasts {
+		if f.Account.Name != "card" || f.Balance != want[f.Currency] {
+			t.Errorf("forecast of %s in %s starts from %v, want %v", f.Account.Name, f.Currency, f.Balance, want[f.Currency])
+		}
+		if f.Currency == "EUR" && f.DailySpending != 1 {
+			t.Errorf("daily spending in EUR is %v, want 1", f.DailySpending)
+		}
+		if f.Currency == "RUB" && f.DailySpending != 2 {
+			t.Errorf("daily spending in RUB is %v, want 2", f.DailySpending)
+		}
+	}
+}
+
+func TestNetWorthKeepsOtherCurrenciesApart(t *testing.T) {
+	r := newLedger(t)
+
+	points, err := usecase.NewGetNetWorthHistory(r.transactions, r.accounts, r.netWorth).Execute(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 {
+		t.Fatalf("%d points, want 1", len(points))
+	}
+	if points[0].NetWorth() != 1940 || points[0].Other["EUR"] != 270 || len(points[0].Other) != 1 {
+		t.Errorf("net worth %v and %v in other currencies, want 1940 and 270 EUR", points[0].NetWorth(), points[0].Other)
+	}
+}
+
+func TestGoalCountsDefaultCurrency(t *testing.T) {
+	r := newLedger(t)
+	ctx := context.Background()
+
+	err := usecase.NewCreateGoal(r.goals).Execute(ctx, entity.Goal{Name: "laptop", Account: "card", Target: 5000, Deadline: time.Now().AddDate(1, 0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := usecase.NewGetGoalsProgress(r.goals, r.transactions).Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 1 {
+		t.Fatalf("%d goals, want 1", len(progress))
+	}
+	p := progress[0]
+	if p.Saved != 1940 || len(p.OtherCurrencies) != 1 || p.OtherCurrencies[0] != "EUR" {
+		t.Errorf("saved %v with %v not counted, want 1940 with EUR not counted", p.Saved, p.OtherCurrencies)
+	}
+}
+
+func TestTagTotalsByCurrency(t *testing.T) {
+	r := newLedger(t)
+
+	tags, err := usecase.NewGetTags(r.transactions).Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []entity.TagSummary{
+		{Tag: "work", Currency: "RUB", Count: 2, Total: 2000},
+		{Tag: "work", Currency: "EUR", Count: 1, Total: 300},
+	}
+	if len(tags) != len(want) {
+		t.Fatalf("tags %+v, want %+v", tags, want)
+	}
+	for i := range want {
+		if tags[i] != want[i] {
+			t.Errorf("tag %d is %+v, want %+v", i, tags[i], want[i])
+		}
+	}
+}
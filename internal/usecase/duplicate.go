@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"enigma/internal/entity"
+)
+
+type FindDuplicates struct {
+	repo transactionRepository
+}
+
+func NewFindDuplicates(repo transactionRepository) *FindDuplicates {
+	return &FindDuplicates{
+		repo: repo,
+	}
+}
+
+// Execute returns saved transactions which t is likely a duplicate of, it is run before t is created
+func (f *FindDuplicates) Execute(ctx context.Context, t entity.Transaction) ([]entity.Transaction, error) {
+	// dates are kept without time, so the range covers whole days around t
+	since := t.Date.Add(-entity.DuplicateWindow)
+	until := t.Date.Add(entity.DuplicateWindow).AddDate(0, 0, 1)
+
+	transactions, err := f.repo.GetByDateRange(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []entity.Transaction
+	for _, transaction := range transactions {
+		if entity.IsDuplicate(t, transaction) {
+			duplicates = append(duplicates, transaction)
+		}
+	}
+
+	return duplicates, nil
+}
+
+type GetDuplicates struct {
+	transactionRepo transactionRepository
+	duplicateRepo   duplicateRepository
+}
+
+func NewGetDuplicates(transactionRepo transactionRepository, duplicateRepo duplicateRepository) *GetDuplicates {
+	return &GetDuplicates{
+		transactionRepo: transactionRepo,
+		duplicateRepo:   duplicateRepo,
+	}
+}
+
+// Execute returns every pair of likely duplicates among saved transactions except dismissed ones, oldest first
+func (g *GetDuplicates) Execute(ctx context.Context) ([]entity.DuplicatePair, error) {
+	dismissedPairs, err := g.duplicateRepo.GetDismissed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dismissed := make(map[[2]uint64]struct{}, len(dismissedPairs))
+	for _, pair := range dismissedPairs {
+		dismissed[pair] = struct{}{}
+	}
+
+	since, until, _ := entity.ParsePeriod("all")
+
+	// transactions come oldest first, so only those within the window of the current one are kept
+	var window []entity.Transaction
+	var pairs []entity.DuplicatePair
+	err = g.transactionRepo.ForEachInDateRange(ctx, since, until, func(t entity.Transaction) error {
+		i := 0
+		for i < len(window) && t.Date.Sub(window[i].Date) > entity.DuplicateWindow {
+			i++
+		}
+		window = window[i:]
+
+		for _, previous := range window {
+			if !entity.IsDuplicate(previous, t) {
+				continue
+			}
+
+			pair := entity.DuplicatePair{First: previous, Second: t}
+			first, second := pair.Key()
+			if _, ok := dismissed[[2]uint64{first, second}]; ok {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+
+		window = append(window, t)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+type DismissDuplicate struct {
+	repo duplicateRepository
+}
+
+func NewDismissDuplicate(repo duplicateRepository) *DismissDuplicate {
+	return &DismissDuplicate{
+		repo: repo,
+	}
+}
+
+// Execute marks the pair as not duplicates, so it is not shown again
+func (d *DismissDuplicate) Execute(ctx context.Context, a, b uint64) error {
+	if a > b {
+		a, b = b, a
+	}
+	return d.repo.Dismiss(ctx, a, b)
+}
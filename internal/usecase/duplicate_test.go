@@ -0,0 +1,99 @@
+package usecase_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase"
+	"enigma/internal/usecase/repository/duplicate"
+	"enigma/internal/usecase/repository/transaction"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDismissedDuplicatesAreNotShownAgain(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "enigma.db")
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactions, err := transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicates, err := duplicate.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC)
+	for _, saved := range []entity.Transaction{
+		{Date: day, FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "groceries"},
+		{Date: day.AddDate(0, 0, 2), FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "GROCERIES"},
+		{Date: day.AddDate(0, 0, 1), FromAccount: "card", ToAccount: "cafe", Amount: 300, Description: "coffee"},
+		{Date: day.AddDate(0, 0, 9), FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "groceries"},
+	} {
+		_, err = transactions.Create(ctx, saved, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pairs, err := usecase.NewGetDuplicates(transactions, duplicates).Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last groceries are out of the window of the others
+	if len(pairs) != 1 || pairs[0].First.ID != 1 || pairs[0].Second.ID != 2 {
+		t.Fatalf("found %+v, want the pair of the first two transactions", pairs)
+	}
+
+	// the pair is dismissed in any order of its transactions
+	err = usecase.NewDismissDuplicate(duplicates).Execute(ctx, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// dismissed pairs are kept in the database
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	transactions, err = transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicates, err = duplicate.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairs, err = usecase.NewGetDuplicates(transactions, duplicates).Execute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 0 {
+		t.Errorf("found %+v after the pair is dismissed", pairs)
+	}
+
+	found, err := usecase.NewFindDuplicates(transactions).Execute(ctx, entity.Transaction{
+		Date: day.AddDate(0, 0, 1), FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "Groceries!",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Errorf("found %d duplicates of a new transaction, want 2 within the window", len(found))
+	}
+}
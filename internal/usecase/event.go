@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/logger"
+)
+
+const (
+	// deliveryBatchSize is how many deliveries are attempted in one pass over the outbox
+	deliveryBatchSize = 100
+
+	// failed deliveries are retried after retryBaseDelay doubled with every attempt up to retryMaxDelay,
+	// after maxDeliveryAttempts, about thirty hours, the delivery is dropped
+	retryBaseDelay      = 10 * time.Second
+	retryMaxDelay       = 6 * time.Hour
+	maxDeliveryAttempts = 16
+)
+
+type PublishEvents struct {
+	webhookRepo webhookRepository
+}
+
+func NewPublishEvents(webhookRepo webhookRepository) *PublishEvents {
+	return &PublishEvents{
+		webhookRepo: webhookRepo,
+	}
+}
+
+// Execute returns Deliver making a delivery of an event to every webhook subscribed to it, it is nil without webhooks
+func (p *PublishEvents) Execute(ctx context.Context) (entity.Deliver, error) {
+	webhooks, err := p.webhookRepo.GetAll(ctx)
+	if err != nil || len(webhooks) == 0 {
+		return nil, err
+	}
+
+	return func(eventType entity.EventType, transaction entity.Transaction, previous *entity.Transaction) []entity.Delivery {
+		event := newEvent(eventType, transaction, previous)
+
+		var deliveries []entity.Delivery
+		for _, webhook := range webhooks {
+			if !webhook.IsSubscribed(event.Type) {
+				continue
+			}
+
+			deliveries = append(deliveries, entity.Delivery{
+				ID:          newRandomID(),
+				WebhookID:   webhook.ID,
+				Event:       event,
+				NextAttempt: event.Time,
+			})
+		}
+		return deliveries
+	}, nil
+}
+
+func newEvent(eventType entity.EventType, transaction entity.Transaction, previous *entity.Transaction) entity.Event {
+	return entity.Event{
+		ID:          newRandomID(),
+		Type:        eventType,
+		Time:        time.Now().UTC(),
+		Transaction: transaction,
+		Previous:    previous,
+	}
+}
+
+func newRandomID() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+type DeliverWebhooks struct {
+	webhookRepo webhookRepository
+	outboxRepo  outboxRepository
+	sender      webhookSender
+}
+
+func NewDeliverWebhooks(webhookRepo webhookRepository, outboxRepo outboxRepository, sender webhookSender) *DeliverWebhooks {
+	return &DeliverWebhooks{
+		webhookRepo: webhookRepo,
+		outboxRepo:  outboxRepo,
+		sender:      sender,
+	}
+}
+
+// Execute attempts deliveries which are due at now and returns how many of them were delivered,
+// failed deliveries are rescheduled with exponential backoff
+func (d *DeliverWebhooks) Execute(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := d.outboxRepo.GetDue(ctx, now, deliveryBatchSize)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+
+	webhooks, err := d.webhookRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	byID := make(map[uint64]entity.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+
+	delivered := 0
+	for _, delivery := range deliveries {
+		webhook, ok := byID[delivery.WebhookID]
+		if !ok {
+			// the webhook was removed, nobody waits for its events
+			err = d.outboxRepo.Delete(ctx, delivery.ID)
+			if err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		sendErr := d.sender.Send(ctx, webhook, delivery)
+		if sendErr == nil {
+			delivered++
+			err = d.outboxRepo.Delete(ctx, delivery.ID)
+			if err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		// an attempt cut short by shutting down is not counted, the delivery is sent after a restart
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+
+		delivery.Attempts++
+		delivery.LastError = sendErr.Error()
+		if delivery.Attempts >= maxDeliveryAttempts {
+			logger.FromContext(ctx).Warn("drop the delivery", "webhook_id", webhook.ID, "delivery_id", delivery.ID,
+				"event_id", delivery.Event.ID, "attempts", delivery.Attempts, "err", sendErr)
+			err = d.outboxRepo.Delete(ctx, delivery.ID)
+		} else {
+			delivery.NextAttempt = now.Add(retryDelay(delivery.Attempts))
+			err = d.outboxRepo.Update(ctx, delivery)
+		}
+		if err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// retryDelay is the delay after the failed attempt number attempts
+func retryDelay(attempts int) time.Duration {
+	delay := float64(retryBaseDelay) * math.Pow(2, float64(attempts-1))
+	if delay > float64(retryMaxDelay) {
+		return retryMaxDelay
+	}
+	return time.Duration(delay)
+}
+
+type CreateWebhook struct {
+	repo webhookRepository
+}
+
+func NewCreateWebhook(repo webhookRepository) *CreateWebhook {
+	return &CreateWebhook{
+		repo: repo,
+	}
+}
+
+// Execute saves a webhook posting events to rawURL, a secret for signatures is generated if it is empty
+func (c *CreateWebhook) Execute(ctx context.Context, rawURL, secret string, events []entity.EventType) (entity.Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return entity.Webhook{}, fmt.Errorf("invalid webhook URL %s, an http or https URL is expected", rawURL)
+	}
+
+	for _, event := range events {
+		if !isEventType(event) {
+			return entity.Webhook{}, fmt.Errorf("unknown event %s", event)
+		}
+	}
+
+	if secret == "" {
+		secret = newRandomID() + newRandomID()
+	}
+
+	return c.repo.Create(ctx, entity.Webhook{URL: u.String(), Secret: secret, Events: events})
+}
+
+func isEventType(t entity.EventType) bool {
+	for _, eventType := range entity.EventTypes {
+		if eventType == t {
+			return true
+		}
+	}
+	return false
+}
+
+type DeleteWebhook struct {
+	repo webhookRepository
+}
+
+func NewDeleteWebhook(repo webhookRepository) *DeleteWebhook {
+	return &DeleteWebhook{
+		repo: repo,
+	}
+}
+
+// Execute removes the webhook, its pending deliveries are dropped by DeliverWebhooks
+func (d *DeleteWebhook) Execute(ctx context.Context, id uint64) error {
+	return d.repo.Delete(ctx, id)
+}
+
+type GetWebhooks struct {
+	webhookRepo webhookRepository
+	outboxRepo  outboxRepository
+}
+
+func NewGetWebhooks(webhookRepo webhookRepository, outboxRepo outboxRepository) *GetWebhooks {
+	return &GetWebhooks{
+		webhookRepo: webhookRepo,
+		outboxRepo:  outboxRepo,
+	}
+}
+
+// Execute returns every webhook and the number of deliveries waiting in the outbox
+func (g *GetWebhooks) Execute(ctx context.Context) ([]entity.Webhook, int, error) {
+	webhooks, err := g.webhookRepo.GetAll(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pending, err := g.outboxRepo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return webhooks, pending, nil
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+type ExportTransactions struct {
+	repo transactionRepository
+}
+
+func NewExportTransactions(repo transactionRepository) *ExportTransactions {
+	return &ExportTransactions{
+		repo: repo,
+	}
+}
+
+// Execute streams transactions dated from since inclusive to until exclusive to fn, oldest first,
+// and returns the number of exported transactions
+func (e *ExportTransactions) Execute(ctx context.Context, since, until time.Time, fn func(entity.Transaction) error) (int, error) {
+	if !since.Before(until) {
+		return 0, errors.New("export period is empty")
+	}
+
+	count := 0
+	err := e.repo.ForEachInDateRange(ctx, since, until, func(t entity.Transaction) error {
+		count++
+		return fn(t)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
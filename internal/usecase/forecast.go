@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+const (
+	// recurringMonths is the history window where monthly payments are looked for
+	recurringMonths = 3
+	// recurringMinOccurrences is how many of those months a payment must appear in
+	recurringMinOccurrences = 2
+
+	// spendingDays is the history window averaged to get daily discretionary spending
+	spendingDays = 30
+)
+
+type Forecast struct {
+	transactionRepo transactionRepository
+	accountRepo     accountRepository
+}
+
+func NewForecast(transactionRepo transactionRepository, accountRepo accountRepository) *Forecast {
+	return &Forecast{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+	}
+}
+
+// Execute projects the balance of every asset account for the next days in every currency of the account
+func (f *Forecast) Execute(ctx context.Context, days int) ([]entity.AccountForecast, error) {
+	if days <= 0 {
+		return nil, errors.New("forecast period must be positive")
+	}
+
+	accounts, err := getAccounts(ctx, f.accountRepo, f.transactionRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	transactions, err := f.transactionRepo.GetByDateRange(ctx, time.Time{}, tomorrow)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]map[string]float64)
+	addBalance := func(account, currency string, amount float64) {
+		if balances[account] == nil {
+			balances[account] = make(map[string]float64)
+		}
+		balances[account][currency] += amount
+	}
+	for _, t := range transactions {
+		addBalance(t.FromAccount, t.CurrencyCode(), -t.Amount)
+		addBalance(t.ToAccount, t.CurrencyCode(), t.Amount)
+	}
+
+	recurring, recurringKeys := detectRecurring(transactions, today)
+	spendingSince := tomorrow.AddDate(0, 0, -spendingDays)
+
+	var forecasts []entity.AccountForecast
+	for _, account := range accounts {
+		if account.Type != entity.AssetAccount {
+			continue
+		}
+
+		currencies := make([]string, 0, len(balances[account.Name]))
+		for currency := range balances[account.Name] {
+			currencies = append(currencies, currency)
+		}
+		if len(currencies) == 0 {
+			currencies = append(currencies, entity.DefaultCurrency)
+		}
+		sort.Strings(currencies)
+
+		for _, currency := range currencies {
+			forecast := forecastAccount(
+				account, currency, balances[account.Name][currency],
+				transactions, recurring, recurringKeys, spendingSince, tomorrow, days,
+			)
+			forecasts = append(forecasts, forecast)
+		}
+	}
+
+	return forecasts, nil
+}
+
+// forecastAccount projects the balance of account in currency from transactions in currency
+func forecastAccount(
+	account entity.Account,
+	currency string,
+	balance float64,
+	transactions []entity.Transaction,
+	recurring []entity.RecurringTransaction,
+	recurringKeys map[recurringKey]struct{},
+	spendingSince, tomorrow time.Time,
+	days int,
+) entity.AccountForecast {
+	forecast := entity.AccountForecast{
+		Account:  account,
+		Currency: currency,
+		Balance:  balance,
+	}
+
+	var spent float64
+	for _, t := range transactions {
+		if t.FromAccount != account.Name || t.CurrencyCode() != currency || t.Date.Before(spendingSince) {
+			continue
+		}
+		if _, ok := recurringKeys[recurringKeyOf(t)]; ok {
+			continue
+		}
+		spent += t.Amount
+	}
+	forecast.DailySpending = spent / spendingDays
+
+	for _, r := range recurring {
+		if r.Currency == currency && (r.FromAccount == account.Name || r.ToAccount == account.Name) {
+			forecast.Recurring = append(forecast.Recurring, r)
+		}
+	}
+
+	// the floor is set in the default currency
+	var floor *float64
+	if currency == entity.DefaultCurrency {
+		floor = account.Floor
+	}
+
+	for day := tomorrow; day.Before(tomorrow.AddDate(0, 0, days)); day = day.AddDate(0, 0, 1) {
+		balance -= forecast.DailySpending
+		for _, r := range forecast.Recurring {
+			if clampedDay(day.Year(), day.Month(), r.DayOfMonth) != day.Day() {
+				continue
+			}
+			if r.FromAccount == account.Name {
+				balance -= r.Amount
+			}
+			if r.ToAccount == account.Name {
+				balance += r.Amount
+			}
+		}
+
+		forecast.Points = append(forecast.Points, entity.ForecastPoint{Date: day, Balance: balance})
+
+		if balance < 0 && forecast.BelowZeroAt == nil {
+			date := day
+			forecast.BelowZeroAt = &date
+		}
+		if floor != nil && balance < *floor && forecast.BelowFloorAt == nil {
+			date := day
+			forecast.BelowFloorAt = &date
+		}
+	}
+
+	return forecast
+}
+
+type recurringKey struct {
+	from, to string
+	amount   int64
+	currency string
+}
+
+func recurringKeyOf(t entity.Transaction) recurringKey {
+	return recurringKey{
+		from:     t.FromAccount,
+		to:       t.ToAccount,
+		amount:   int64(math.Round(t.Amount * 100)),
+		currency: t.CurrencyCode(),
+	}
+}
+
+// detectRecurring looks for payments between the same accounts with the same amount and currency
+// repeated in different months, they are expected on the day of their last occurrence
+func detectRecurring(transactions []entity.Transaction, today time.Time) ([]entity.RecurringTransaction, map[recurringKey]struct{}) {
+	since := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -recurringMonths, 0)
+
+	months := make(map[recurringKey]map[string]struct{})
+	last := make(map[recurringKey]entity.Transaction)
+	for _, t := range transactions {
+		if t.Date.Before(since) {
+			continue
+		}
+
+		key := recurringKeyOf(t)
+		if months[key] == nil {
+			months[key] = make(map[string]struct{})
+		}
+		months[key][t.Date.Format("2006-01")] = struct{}{}
+
+		if previous, ok := last[key]; !ok || !t.Date.Before(previous.Date) {
+			last[key] = t
+		}
+	}
+
+	var recurring []entity.RecurringTransaction
+	keys := make(map[recurringKey]struct{})
+	for key, seen := range months {
+		if len(seen) < recurringMinOccurrences {
+			continue
+		}
+
+		t := last[key]
+		keys[key] = struct{}{}
+		recurring = append(recurring, entity.RecurringTransaction{
+			FromAccount: t.FromAccount,
+			ToAccount:   t.ToAccount,
+			Amount:      t.Amount,
+			Currency:    t.CurrencyCode(),
+			Description: t.Description,
+			DayOfMonth:  t.Date.Day(),
+		})
+	}
+
+	sort.Slice(recurring, func(i, j int) bool {
+		if recurring[i].DayOfMonth == recurring[j].DayOfMonth {
+			return recurring[i].Description < recurring[j].Description
+		}
+		return recurring[i].DayOfMonth < recurring[j].DayOfMonth
+	})
+
+	return recurring, keys
+}
+
+// clampedDay moves days missing in short months to the last day of the month
+func clampedDay(year int, month time.Month, day int) int {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDay {
+		return lastDay
+	}
+	return day
+}
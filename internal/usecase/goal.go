@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+const (
+	averageDaysInMonth = 30.44
+
+	// goalPaceMonths is how many recent months are averaged to get the contribution pace
+	goalPaceMonths = 3
+)
+
+type CreateGoal struct {
+	repo goalRepository
+}
+
+func NewCreateGoal(repo goalRepository) *CreateGoal {
+	return &CreateGoal{
+		repo: repo,
+	}
+}
+
+func (c *CreateGoal) Execute(ctx context.Context, goal entity.Goal) error {
+	if goal.Name == "" || goal.Account == "" {
+		return errors.New("goal name and account are required")
+	}
+	if goal.Target <= 0 {
+		return errors.New("goal target must be positive")
+	}
+
+	goal.CreatedAt = time.Now().UTC()
+	if !goal.Deadline.After(goal.CreatedAt) {
+		return errors.New("goal deadline must be in the future")
+	}
+
+	return c.repo.Create(ctx, goal)
+}
+
+type GetGoalsProgress struct {
+	goalRepo        goalRepository
+	transactionRepo transactionRepository
+}
+
+func NewGetGoalsProgress(goalRepo goalRepository, transactionRepo transactionRepository) *GetGoalsProgress {
+	return &GetGoalsProgress{
+		goalRepo:        goalRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+func (g *GetGoalsProgress) Execute(ctx context.Context) ([]entity.GoalProgress, error) {
+	goals, err := g.goalRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	progress := make([]entity.GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		p, err := calculateGoalProgress(ctx, g.transactionRepo, goal, now)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, nil
+}
+
+// CheckGoals marks goals whose target has been saved as reached
+type CheckGoals struct {
+	goalRepo        goalRepository
+	transactionRepo transactionRepository
+}
+
+func NewCheckGoals(goalRepo goalRepository, transactionRepo transactionRepository) *CheckGoals {
+	return &CheckGoals{
+		goalRepo:        goalRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// Execute returns goals which have been reached since the previous check
+func (c *CheckGoals) Execute(ctx context.Context) ([]entity.Goal, error) {
+	goals, err := c.goalRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var reached []entity.Goal
+	for _, goal := range goals {
+		if goal.ReachedAt != nil {
+			continue
+		}
+
+		p, err := calculateGoalProgress(ctx, c.transactionRepo, goal, now)
+		if err != nil {
+			return nil, err
+		}
+		if p.Saved < goal.Target {
+			continue
+		}
+
+		goal.ReachedAt = &now
+		err = c.goalRepo.Update(ctx, goal)
+		if err != nil {
+			return nil, err
+		}
+		reached = append(reached, goal)
+	}
+
+	return reached, nil
+}
+
+func calculateGoalProgress(ctx context.Context, repo transactionRepository, goal entity.Goal, now time.Time) (entity.GoalProgress, error) {
+	incoming, err := repo.Search(ctx, entity.TransactionFilter{ToAccount: goal.Account})
+	if err != nil {
+		return entity.GoalProgress{}, err
+	}
+
+	outgoing, err := repo.Search(ctx, entity.TransactionFilter{FromAccount: goal.Account})
+	if err != nil {
+		return entity.GoalProgress{}, err
+	}
+
+	paceSince := now.AddDate(0, -goalPaceMonths, 0)
+	firstContribution := now
+	var saved, recent float64
+
+	// targets are in the default currency
+	others := make(map[string]struct{})
+	counted := func(t entity.Transaction) bool {
+		if t.CurrencyCode() != entity.DefaultCurrency {
+			others[t.CurrencyCode()] = struct{}{}
+			return false
+		}
+		return true
+	}
+
+	for _, t := range incoming {
+		if !counted(t) {
+			continue
+		}
+		saved += t.Amount
+		if t.Date.After(paceSince) {
+			recent += t.Amount
+		}
+		if t.Date.Before(firstContribution) {
+			firstContribution = t.Date
+		}
+	}
+	for _, t := range outgoing {
+		if !counted(t) {
+			continue
+		}
+		saved -= t.Amount
+		if t.Date.After(paceSince) {
+			recent -= t.Amount
+		}
+	}
+
+	p := entity.GoalProgress{
+		Goal:  goal,
+		Saved: saved,
+	}
+	for currency := range others {
+		p.OtherCurrencies = append(p.OtherCurrencies, currency)
+	}
+	sort.Strings(p.OtherCurrencies)
+
+	// a young account has not had the whole pace window to accumulate contributions
+	if firstContribution.After(paceSince) {
+		paceSince = firstContribution
+	}
+	p.MonthlyPace = recent / math.Max(1, monthsBetween(paceSince, now))
+
+	remaining := goal.Target - saved
+	if remaining <= 0 {
+		reachedAt := now
+		if goal.ReachedAt != nil {
+			reachedAt = *goal.ReachedAt
+		}
+		p.ProjectedCompletion = &reachedAt
+		return p, nil
+	}
+
+	p.RequiredMonthly = remaining / math.Max(1, monthsBetween(now, goal.Deadline))
+
+	if p.MonthlyPace > 0 {
+		projected := now.Add(time.Duration(remaining / p.MonthlyPace * averageDaysInMonth * float64(24*time.Hour)))
+		p.ProjectedCompletion = &projected
+	}
+
+	return p, nil
+}
+
+func monthsBetween(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24 / averageDaysInMonth
+}
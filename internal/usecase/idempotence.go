@@ -1,5 +1,7 @@
 package usecase
 
+import "context"
+
 type Idempotence struct {
 	repo idempotenceRepository
 }
@@ -10,6 +12,6 @@ func NewIdempotence(repo idempotenceRepository) *Idempotence {
 	}
 }
 
-func (u *Idempotence) Execute(id string) (bool, error) {
-	return u.repo.MakeRecord(id)
+func (u *Idempotence) Execute(ctx context.Context, id string) (bool, error) {
+	return u.repo.MakeRecord(ctx, id)
 }
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"enigma/internal/entity"
+)
+
+type ImportTransactions struct {
+	repo          transactionRepository
+	publishEvents *PublishEvents
+}
+
+func NewImportTransactions(repo transactionRepository, publishEvents *PublishEvents) *ImportTransactions {
+	return &ImportTransactions{
+		repo:          repo,
+		publishEvents: publishEvents,
+	}
+}
+
+// Execute creates imported transactions skipping the ones imported before and reports likely duplicates among them
+func (i *ImportTransactions) Execute(ctx context.Context, imported []entity.ImportedTransaction) (entity.ImportResult, error) {
+	var result entity.ImportResult
+
+	deliver, err := i.publishEvents.Execute(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	keys := make([]string, 0, len(imported))
+	transactions := make([]entity.Transaction, 0, len(imported))
+	for _, t := range imported {
+		keys = append(keys, "import"+t.Key)
+
+		t.Transaction.Tags = entity.ExtractTags(t.Transaction.Description)
+		transactions = append(transactions, t.Transaction)
+	}
+
+	created, err := i.repo.CreateOnce(ctx, transactions, keys, deliver)
+	if err != nil {
+		return result, err
+	}
+	result.Created = len(created)
+	result.Skipped = len(imported) - len(created)
+
+	result.Duplicates, err = i.findDuplicates(ctx, created)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// findDuplicates pairs created transactions with saved ones they likely duplicate, rows of one statement are not compared
+func (i *ImportTransactions) findDuplicates(ctx context.Context, created []entity.Transaction) ([]entity.DuplicatePair, error) {
+	if len(created) == 0 {
+		return nil, nil
+	}
+
+	since, until := created[0].Date, created[0].Date
+	ids := make(map[uint64]struct{}, len(created))
+	for _, t := range created {
+		if t.Date.Before(since) {
+			since = t.Date
+		}
+		if t.Date.After(until) {
+			until = t.Date
+		}
+		ids[t.ID] = struct{}{}
+	}
+
+	// the range covers whole days around the statement
+	saved, err := i.repo.GetByDateRange(ctx, since.Add(-entity.DuplicateWindow), until.Add(entity.DuplicateWindow).AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []entity.DuplicatePair
+	for _, t := range created {
+		for _, s := range saved {
+			if _, ok := ids[s.ID]; ok {
+				continue
+			}
+			if entity.IsDuplicate(s, t) {
+				duplicates = append(duplicates, entity.DuplicatePair{First: s, Second: t})
+			}
+		}
+	}
+	return duplicates, nil
+}
+
+type SaveCategoryMappings struct {
+	repo categoryMappingRepository
+}
+
+func NewSaveCategoryMappings(repo categoryMappingRepository) *SaveCategoryMappings {
+	return &SaveCategoryMappings{
+		repo: repo,
+	}
+}
+
+func (s *SaveCategoryMappings) Execute(ctx context.Context, mappings []entity.CategoryMapping) error {
+	for _, mapping := range mappings {
+		if mapping.Category == "" || mapping.Account == "" {
+			return errors.New("category and account are required")
+		}
+
+		err := s.repo.Save(ctx, mapping)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type GetCategoryMappings struct {
+	repo categoryMappingRepository
+}
+
+func NewGetCategoryMappings(repo categoryMappingRepository) *GetCategoryMappings {
+	return &GetCategoryMappings{
+		repo: repo,
+	}
+}
+
+// Execute returns accounts by the category names of other finance software
+func (g *GetCategoryMappings) Execute(ctx context.Context) (map[string]string, error) {
+	mappings, err := g.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[string]string, len(mappings))
+	for _, mapping := range mappings {
+		accounts[mapping.Category] = mapping.Account
+	}
+	return accounts, nil
+}
+
+type SaveImportProfile struct {
+	repo importProfileRepository
+}
+
+func NewSaveImportProfile(repo importProfileRepository) *SaveImportProfile {
+	return &SaveImportProfile{
+		repo: repo,
+	}
+}
+
+func (s *SaveImportProfile) Execute(ctx context.Context, profile entity.ImportProfile) error {
+	if profile.Name == "" {
+		return errors.New("profile name is required")
+	}
+	if profile.Account == "" {
+		return errors.New("profile account is required")
+	}
+	return s.repo.Save(ctx, profile)
+}
+
+type GetImportProfile struct {
+	repo importProfileRepository
+}
+
+func NewGetImportProfile(repo importProfileRepository) *GetImportProfile {
+	return &GetImportProfile{
+		repo: repo,
+	}
+}
+
+func (g *GetImportProfile) Execute(ctx context.Context, name string) (entity.ImportProfile, error) {
+	return g.repo.Get(ctx, name)
+}
+
+type GetImportProfiles struct {
+	repo importProfileRepository
+}
+
+func NewGetImportProfiles(repo importProfileRepository) *GetImportProfiles {
+	return &GetImportProfiles{
+		repo: repo,
+	}
+}
+
+func (g *GetImportProfiles) Execute(ctx context.Context) ([]entity.ImportProfile, error) {
+	return g.repo.GetAll(ctx)
+}
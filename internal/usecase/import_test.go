@@ -0,0 +1,82 @@
+package usecase_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase"
+	"enigma/internal/usecase/repository/idempotence"
+	"enigma/internal/usecase/repository/outbox"
+	"enigma/internal/usecase/repository/transaction"
+	"enigma/internal/usecase/repository/webhook"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestImportReportsDuplicatesOfSavedTransactions(t *testing.T) {
+	ctx := context.Background()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "enigma.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = idempotence.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = outbox.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := webhook.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importTransactions := usecase.NewImportTransactions(transactions, usecase.NewPublishEvents(webhooks))
+
+	day := time.Date(2022, 5, 10, 0, 0, 0, 0, time.UTC)
+	// groceries entered by hand before the statement is imported
+	_, err = importTransactions.Execute(ctx, []entity.ImportedTransaction{
+		{Key: "hand", Transaction: entity.Transaction{Date: day, FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "groceries"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := []entity.ImportedTransaction{
+		{Key: "1", Transaction: entity.Transaction{Date: day.AddDate(0, 0, 1), FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "GROCERIES"}},
+		{Key: "2", Transaction: entity.Transaction{Date: day.AddDate(0, 0, 1), FromAccount: "card", ToAccount: "shop", Amount: 1250, Description: "Groceries"}},
+		{Key: "3", Transaction: entity.Transaction{Date: day.AddDate(0, 0, 2), FromAccount: "card", ToAccount: "cafe", Amount: 300, Description: "coffee"}},
+	}
+	result, err := importTransactions.Execute(ctx, statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rows of the statement are not duplicates of each other, both are duplicates of the groceries entered by hand
+	if result.Created != 3 || len(result.Duplicates) != 2 {
+		t.Fatalf("import result %+v, want 3 created and 2 duplicates", result)
+	}
+	for i, pair := range result.Duplicates {
+		if pair.First.ID != 1 || pair.Second.ID != uint64(i+2) {
+			t.Errorf("duplicate %d is #%d of #%d, want #%d of #1", i, pair.Second.ID, pair.First.ID, i+2)
+		}
+	}
+
+	// nothing is created importing the statement again, so nothing is reported
+	result, err = importTransactions.Execute(ctx, statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 0 || len(result.Duplicates) != 0 {
+		t.Errorf("import result %+v of the repeated import", result)
+	}
+}
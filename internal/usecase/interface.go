@@ -1,23 +1,106 @@
 package usecase
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"enigma/internal/entity"
 )
 
 type transactionRepository interface {
-	Create(entity.Transaction) error
-	GetByID(uint64) (entity.Transaction, error)
-	GetByDate(time.Time) ([]entity.Transaction, error)
+	// Create, CreateOnce, Update and Delete save deliveries of events of the change made by deliver along with it,
+	// deliver may be nil
+	Create(context.Context, entity.Transaction, entity.Deliver) (entity.Transaction, error)
+	// CreateOnce saves transactions whose keys are recorded for the first time, along with the keys
+	CreateOnce(ctx context.Context, transactions []entity.Transaction, keys []string, deliver entity.Deliver) ([]entity.Transaction, error)
+	// Update replaces the transaction with the same ID and returns the previous version
+	Update(context.Context, entity.Transaction, entity.Deliver) (entity.Transaction, error)
+	// Split updates first and creates rest at once
+	Split(ctx context.Context, first entity.Transaction, rest []entity.Transaction, deliver entity.Deliver) ([]entity.Transaction, error)
+	GetByID(context.Context, uint64) (entity.Transaction, error)
+	GetByDate(context.Context, time.Time) ([]entity.Transaction, error)
+	Search(context.Context, entity.TransactionFilter) ([]entity.Transaction, error)
+	GetTags(context.Context) ([]entity.TagSummary, error)
+	GetByDateRange(ctx context.Context, since, until time.Time) ([]entity.Transaction, error)
+	ForEachInDateRange(ctx context.Context, since, until time.Time, fn func(entity.Transaction) error) error
+	GetCreatedAfter(context.Context, uint64) ([]entity.Transaction, error)
+	GetAccountNames(context.Context) ([]string, error)
+	GetByReceipt(ctx context.Context, key string) (*entity.Transaction, error)
+	Delete(context.Context, uint64, entity.Deliver) error
 }
 
 type idempotenceRepository interface {
 	// MakeRecord return true if it was first time to call this method with same id
-	MakeRecord(string) (bool, error)
+	MakeRecord(context.Context, string) (bool, error)
 }
 
 type userstateRepository interface {
-	Get(int64) (entity.UserState, error)
-	Save(int64, entity.UserState) error
+	Get(context.Context, int64) (entity.UserState, error)
+	Save(context.Context, int64, entity.UserState) error
+}
+
+type goalRepository interface {
+	Create(context.Context, entity.Goal) error
+	Update(context.Context, entity.Goal) error
+	GetAll(context.Context) ([]entity.Goal, error)
+}
+
+type accountRepository interface {
+	Save(context.Context, entity.Account) error
+	Get(context.Context, string) (entity.Account, error)
+	GetAll(context.Context) ([]entity.Account, error)
+}
+
+type netWorthRepository interface {
+	// GetSnapshots returns cached snapshots ordered by month and the newest transaction ID they account for
+	GetSnapshots(context.Context) ([]entity.BalanceSnapshot, uint64, error)
+	ReplaceSnapshots(context.Context, []entity.BalanceSnapshot, uint64) error
+}
+
+type importProfileRepository interface {
+	Save(context.Context, entity.ImportProfile) error
+	Get(context.Context, string) (entity.ImportProfile, error)
+	GetAll(context.Context) ([]entity.ImportProfile, error)
+}
+
+type categoryMappingRepository interface {
+	Save(context.Context, entity.CategoryMapping) error
+	GetAll(context.Context) ([]entity.CategoryMapping, error)
+}
+
+type notificationTemplateRepository interface {
+	Save(context.Context, entity.NotificationTemplate) error
+	Delete(context.Context, string) error
+	GetAll(context.Context) ([]entity.NotificationTemplate, error)
+}
+
+type backupRepository interface {
+	WriteTo(context.Context, io.Writer) (int64, error)
+	Inspect(context.Context, io.Reader) (entity.BackupInfo, error)
+	Restore(context.Context, io.Reader) (entity.BackupInfo, error)
+}
+
+type duplicateRepository interface {
+	// Dismiss marks transactions as not duplicates of each other, first is the lesser ID
+	Dismiss(ctx context.Context, first, second uint64) error
+	GetDismissed(context.Context) ([][2]uint64, error)
+}
+
+type webhookRepository interface {
+	Create(context.Context, entity.Webhook) (entity.Webhook, error)
+	Delete(context.Context, uint64) error
+	GetAll(context.Context) ([]entity.Webhook, error)
+}
+
+type outboxRepository interface {
+	GetDue(ctx context.Context, now time.Time, limit int) ([]entity.Delivery, error)
+	Update(context.Context, entity.Delivery) error
+	Delete(ctx context.Context, id string) error
+	Count(context.Context) (int, error)
+}
+
+type webhookSender interface {
+	// Send posts the event of delivery to webhook, an error means it should be retried
+	Send(context.Context, entity.Webhook, entity.Delivery) error
 }
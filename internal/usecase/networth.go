@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"enigma/internal/entity"
+)
+
+type GetNetWorthHistory struct {
+	transactionRepo transactionRepository
+	accountRepo     accountRepository
+	netWorthRepo    netWorthRepository
+}
+
+func NewGetNetWorthHistory(
+	transactionRepo transactionRepository,
+	accountRepo accountRepository,
+	netWorthRepo netWorthRepository,
+) *GetNetWorthHistory {
+	return &GetNetWorthHistory{
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		netWorthRepo:    netWorthRepo,
+	}
+}
+
+// Execute returns end-of-month net worth for the last months up to the current one
+func (g *GetNetWorthHistory) Execute(ctx context.Context, months int) ([]entity.NetWorthPoint, error) {
+	snapshots, err := g.updateSnapshots(ctx, monthStart(time.Now().UTC()))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := getAccounts(ctx, g.accountRepo, g.transactionRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]entity.AccountType, len(accounts))
+	for _, account := range accounts {
+		types[account.Name] = account.Type
+	}
+
+	if len(snapshots) > months {
+		snapshots = snapshots[len(snapshots)-months:]
+	}
+
+	points := make([]entity.NetWorthPoint, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		point := entity.NetWorthPoint{Month: snapshot.Month}
+		for name, balance := range snapshot.Balances {
+			switch types[name] {
+			case entity.AssetAccount:
+				point.Assets += balance
+			case entity.LiabilityAccount:
+				point.Liabilities += balance
+			}
+		}
+		for currency, balances := range snapshot.Other {
+			for name, balance := range balances {
+				if types[name] != entity.AssetAccount && types[name] != entity.LiabilityAccount {
+					continue
+				}
+				if point.Other == nil {
+					point.Other = make(map[string]float64)
+				}
+				point.Other[currency] += balance
+			}
+		}
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// updateSnapshots brings cached snapshots up to currentMonth, snapshots affected by
+// transactions created since the previous update are recalculated
+func (g *GetNetWorthHistory) updateSnapshots(ctx context.Context, currentMonth time.Time) ([]entity.BalanceSnapshot, error) {
+	snapshots, lastTransactionID, err := g.netWorthRepo.GetSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := g.transactionRepo.GetCreatedAfter(ctx, lastTransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(created) == 0 && len(snapshots) > 0 && snapshots[len(snapshots)-1].Month.Equal(currentMonth) {
+		return snapshots, nil
+	}
+
+	for _, t := range created {
+		if t.ID > lastTransactionID {
+			lastTransactionID = t.ID
+		}
+
+		changed := monthStart(t.Date)
+		for i, snapshot := range snapshots {
+			if !snapshot.Month.Before(changed) {
+				snapshots = snapshots[:i]
+				break
+			}
+		}
+	}
+
+	since := time.Time{}
+	balances := make(map[string]map[string]float64)
+	if len(snapshots) > 0 {
+		last := snapshots[len(snapshots)-1]
+		since = last.Month.AddDate(0, 1, 0)
+		balances = snapshotBalances(last)
+	}
+	addBalance := func(account, currency string, amount float64) {
+		if balances[currency] == nil {
+			balances[currency] = make(map[string]float64)
+		}
+		balances[currency][account] += amount
+	}
+
+	transactions, err := g.transactionRepo.GetByDateRange(ctx, since, currentMonth.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) > 0 && len(snapshots) == 0 {
+		since = monthStart(transactions[0].Date)
+	}
+
+	i := 0
+	for month := since; !month.After(currentMonth) && !since.IsZero(); month = month.AddDate(0, 1, 0) {
+		next := month.AddDate(0, 1, 0)
+		for ; i < len(transactions) && transactions[i].Date.Before(next); i++ {
+			t := transactions[i]
+			addBalance(t.FromAccount, t.CurrencyCode(), -t.Amount)
+			addBalance(t.ToAccount, t.CurrencyCode(), t.Amount)
+		}
+
+		snapshots = append(snapshots, newSnapshot(month, balances))
+	}
+
+	err = g.netWorthRepo.ReplaceSnapshots(ctx, snapshots, lastTransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// snapshotBalances copies balances of the snapshot keyed by currency and account
+func snapshotBalances(snapshot entity.BalanceSnapshot) map[string]map[string]float64 {
+	balances := make(map[string]map[string]float64, len(snapshot.Other)+1)
+	balances[entity.DefaultCurrency] = make(map[string]float64, len(snapshot.Balances))
+	for name, balance := range snapshot.Balances {
+		balances[entity.DefaultCurrency][name] = balance
+	}
+	for currency, other := range snapshot.Other {
+		balances[currency] = make(map[string]float64, len(other))
+		for name, balance := range other {
+			balances[currency][name] = balance
+		}
+	}
+	return balances
+}
+
+func newSnapshot(month time.Time, balances map[string]map[string]float64) entity.BalanceSnapshot {
+	snapshot := entity.BalanceSnapshot{
+		Month:    month,
+		Balances: make(map[string]float64, len(balances[entity.DefaultCurrency])),
+	}
+	for currency, byAccount := range balances {
+		copied := snapshot.Balances
+		if currency != entity.DefaultCurrency {
+			if snapshot.Other == nil {
+				snapshot.Other = make(map[string]map[string]float64)
+			}
+			copied = make(map[string]float64, len(byAccount))
+			snapshot.Other[currency] = copied
+		}
+		for name, balance := range byAccount {
+			copied[name] = balance
+		}
+	}
+	return snapshot
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"enigma/internal/entity"
+)
+
+type SaveNotificationTemplate struct {
+	repo notificationTemplateRepository
+}
+
+func NewSaveNotificationTemplate(repo notificationTemplateRepository) *SaveNotificationTemplate {
+	return &SaveNotificationTemplate{
+		repo: repo,
+	}
+}
+
+// Execute saves the template after checking its pattern extracts at least the amount
+func (s *SaveNotificationTemplate) Execute(ctx context.Context, template entity.NotificationTemplate) error {
+	if template.Name == "" {
+		return errors.New("template name is required")
+	}
+
+	pattern, err := regexp.Compile(template.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	if pattern.SubexpIndex("amount") < 0 {
+		return errors.New("pattern has no (?P<amount>...) group")
+	}
+
+	return s.repo.Save(ctx, template)
+}
+
+type DeleteNotificationTemplate struct {
+	repo notificationTemplateRepository
+}
+
+func NewDeleteNotificationTemplate(repo notificationTemplateRepository) *DeleteNotificationTemplate {
+	return &DeleteNotificationTemplate{
+		repo: repo,
+	}
+}
+
+func (d *DeleteNotificationTemplate) Execute(ctx context.Context, name string) error {
+	return d.repo.Delete(ctx, name)
+}
+
+type GetNotificationTemplates struct {
+	repo notificationTemplateRepository
+}
+
+func NewGetNotificationTemplates(repo notificationTemplateRepository) *GetNotificationTemplates {
+	return &GetNotificationTemplates{
+		repo: repo,
+	}
+}
+
+// Execute returns templates saved by the user, built-in ones are in the importer
+func (g *GetNotificationTemplates) Execute(ctx context.Context) ([]entity.NotificationTemplate, error) {
+	return g.repo.GetAll(ctx)
+}
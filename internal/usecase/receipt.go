@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+	"enigma/internal/entity"
+)
+
+type GetReceiptTransaction struct {
+	repo transactionRepository
+}
+
+func NewGetReceiptTransaction(repo transactionRepository) *GetReceiptTransaction {
+	return &GetReceiptTransaction{
+		repo: repo,
+	}
+}
+
+// Execute returns the transaction entered from receipt before, nil if it is new
+func (g *GetReceiptTransaction) Execute(ctx context.Context, receipt entity.Receipt) (*entity.Transaction, error) {
+	return g.repo.GetByReceipt(ctx, receipt.Key())
+}
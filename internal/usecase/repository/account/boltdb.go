@@ -0,0 +1,86 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	accountsBucketName = []byte("accounts")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(accountsBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) Save(ctx context.Context, account entity.Account) error {
+	return repository.Update(ctx, t.db, "account", func(tx *bolt.Tx) error {
+		raw, err := json.Marshal(account)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(accountsBucketName).Put([]byte(account.Name), raw)
+	})
+}
+
+func (t *BoltDBRepository) Get(ctx context.Context, name string) (entity.Account, error) {
+	var account entity.Account
+	err := repository.View(ctx, t.db, "account", func(tx *bolt.Tx) error {
+		raw := tx.Bucket(accountsBucketName).Get([]byte(name))
+		if raw == nil {
+			return entity.AccountNotFoundErr
+		}
+		return json.Unmarshal(raw, &account)
+	})
+
+	if err != nil {
+		return entity.Account{}, err
+	}
+
+	return account, nil
+}
+
+func (t *BoltDBRepository) GetAll(ctx context.Context) ([]entity.Account, error) {
+	var accounts []entity.Account
+	err := repository.View(ctx, t.db, "account", func(tx *bolt.Tx) error {
+		return tx.Bucket(accountsBucketName).ForEach(func(k, v []byte) error {
+			var account entity.Account
+			err := json.Unmarshal(v, &account)
+			if err != nil {
+				return err
+			}
+			accounts = append(accounts, account)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// the backup is checked to hold transactions, the names are the ones of the transaction repository
+var (
+	transactionsBucketName = []byte("transactions")
+	byIDBucketName         = []byte("byID")
+	byDateBucketName       = []byte("byDate")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+
+	// migrations are Migrate of every repository
+	migrations []func(tx *bolt.Tx) error
+}
+
+func NewBoltDB(db *bolt.DB, migrations ...func(tx *bolt.Tx) error) (*BoltDBRepository, error) {
+	return &BoltDBRepository{db: db, migrations: migrations}, nil
+}
+
+// WriteTo writes a consistent snapshot of the database, writers are not blocked meanwhile
+func (b *BoltDBRepository) WriteTo(ctx context.Context, w io.Writer) (int64, error) {
+	var written int64
+	err := repository.View(ctx, b.db, "backup", func(tx *bolt.Tx) error {
+		var err error
+		written, err = tx.WriteTo(w)
+		return err
+	})
+	return written, err
+}
+
+// Inspect validates the database read from r and describes its transactions
+func (b *BoltDBRepository) Inspect(ctx context.Context, r io.Reader) (entity.BackupInfo, error) {
+	var info entity.BackupInfo
+	err := withBackup(r, func(backup *bolt.Tx, size int64) error {
+		var err error
+		info, err = inspect(backup, size)
+		return err
+	})
+	return info, err
+}
+
+// Restore validates the database read from r, replaces every bucket of the current database with its buckets
+// in a single transaction and brings them to the current schema with migrations
+func (b *BoltDBRepository) Restore(ctx context.Context, r io.Reader) (entity.BackupInfo, error) {
+	var info entity.BackupInfo
+	err := withBackup(r, func(backup *bolt.Tx, size int64) error {
+		var err error
+		info, err = inspect(backup, size)
+		if err != nil {
+			return err
+		}
+
+		return repository.Update(ctx, b.db, "backup", func(tx *bolt.Tx) error {
+			var names [][]byte
+			err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				names = append(names, append([]byte{}, name...))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, name := range names {
+				err = tx.DeleteBucket(name)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = backup.ForEach(func(name []byte, src *bolt.Bucket) error {
+				dst, err := tx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(dst, src)
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, migrate := range b.migrations {
+				err = migrate(tx)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	return info, err
+}
+
+// withBackup saves r to a temporary file, since bolt reads databases from files only, and opens it read-only
+func withBackup(r io.Reader, fn func(backup *bolt.Tx, size int64) error) error {
+	file, err := os.CreateTemp("", "enigma-backup-*.db")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	size, err := io.Copy(file, r)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(file.Name(), 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("invalid backup: %w", err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return fmt.Errorf("invalid backup: %w", err)
+		}
+		return fn(tx, size)
+	})
+}
+
+func inspect(tx *bolt.Tx, size int64) (entity.BackupInfo, error) {
+	info := entity.BackupInfo{Size: size}
+
+	tBucket := tx.Bucket(transactionsBucketName)
+	if tBucket == nil || tBucket.Bucket(byIDBucketName) == nil || tBucket.Bucket(byDateBucketName) == nil {
+		return info, errors.New("invalid backup: no transactions")
+	}
+
+	info.Transactions = tBucket.Bucket(byIDBucketName).Stats().KeyN
+
+	c := tBucket.Bucket(byDateBucketName).Cursor()
+	if k, _ := c.First(); k != nil {
+		first, err := time.Parse("2006-01-02", string(k))
+		if err != nil {
+			return info, fmt.Errorf("invalid backup: %w", err)
+		}
+		info.FirstDate = first
+	}
+	if k, _ := c.Last(); k != nil {
+		last, err := time.Parse("2006-01-02", string(k))
+		if err != nil {
+			return info, fmt.Errorf("invalid backup: %w", err)
+		}
+		info.LastDate = last
+	}
+
+	return info, nil
+}
+
+func copyBucket(dst, src *bolt.Bucket) error {
+	err := dst.SetSequence(src.Sequence())
+	if err != nil {
+		return err
+	}
+
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+
+		nested, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(nested, src.Bucket(k))
+	})
+}
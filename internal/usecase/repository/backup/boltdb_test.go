@@ -0,0 +1,176 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository/backup"
+	"enigma/internal/usecase/repository/outbox"
+	"enigma/internal/usecase/repository/transaction"
+	"enigma/internal/usecase/repository/userstate"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func openDB(t *testing.T, name string) *bolt.DB {
+	t.Helper()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), name), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// oldBackup is a database from before search, tags, accounts, receipts, states and the outbox, it has transactions
+// by ID and by date only
+func oldBackup(t *testing.T) []byte {
+	t.Helper()
+
+	db := openDB(t, "old.db")
+	err := db.Update(func(tx *bolt.Tx) error {
+		tBucket, err := tx.CreateBucket([]byte("transactions"))
+		if err != nil {
+			return err
+		}
+		byID, err := tBucket.CreateBucket([]byte("byID"))
+		if err != nil {
+			return err
+		}
+		byDate, err := tBucket.CreateBucket([]byte("byDate"))
+		if err != nil {
+			return err
+		}
+		day, err := byDate.CreateBucket([]byte("2021-03-14"))
+		if err != nil {
+			return err
+		}
+
+		id, err := byID.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, id)
+		raw := []byte(`{"id":1,"date":"2021-03-14T10:00:00Z","from_account":"card","to_account":"pharmacy","amount":350,"description":"vitamins"}`)
+
+		err = byID.Put(key, raw)
+		if err != nil {
+			return err
+		}
+		return day.Put(key, raw)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	err = db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(&b)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestRestoreOlderBackup(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, "current.db")
+
+	transactions, err := transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err := userstate.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = outbox.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := backup.NewBoltDB(db, transaction.Migrate, userstate.Migrate, outbox.Migrate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "cash", ToAccount: "cafe", Amount: 5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := backups.Restore(ctx, bytes.NewReader(oldBackup(t)))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if info.Transactions != 1 {
+		t.Errorf("backup has %d transactions, want 1", info.Transactions)
+	}
+
+	created, err := transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "books", Amount: 20, Description: "novel"}, nil)
+	if err != nil {
+		t.Fatalf("create after restore: %v", err)
+	}
+	if created.ID != 2 {
+		t.Errorf("created transaction has ID %d, want 2 following the restored one", created.ID)
+	}
+
+	err = states.Save(ctx, 1, entity.UserState{Name: entity.StartState})
+	if err != nil {
+		t.Fatalf("save state after restore: %v", err)
+	}
+
+	// indexes added after the backup are built from its transactions
+	found, err := transactions.Search(ctx, entity.TransactionFilter{Terms: []string{"vitamins"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != 1 {
+		t.Errorf("search for the restored transaction found %v", found)
+	}
+
+	names, err := transactions.GetAccountNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"books", "card", "pharmacy"}
+	if len(names) != len(want) {
+		t.Fatalf("accounts %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("accounts %v, want %v", names, want)
+			break
+		}
+	}
+}
+
+func TestRestoreRejectsDatabaseWithoutTransactions(t *testing.T) {
+	db := openDB(t, "current.db")
+	backups, err := backup.NewBoltDB(db, transaction.Migrate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empty := openDB(t, "empty.db")
+	path := empty.Path()
+	empty.Close()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = backups.Restore(context.Background(), bytes.NewReader(raw))
+	if err == nil {
+		t.Fatal("a database without transactions is restored")
+	}
+}
@@ -0,0 +1,95 @@
+// Package repository has what the Bolt repositories share, each repository is in a package of its own
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/logger"
+	"enigma/internal/metrics"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// slowTransaction is how long a transaction takes before it is logged, they block writers or hold old pages
+const slowTransaction = time.Second
+
+// boltErrors are failures of Bolt which repositories return from within transactions, e.g. of Put
+var boltErrors = []error{
+	bolt.ErrDatabaseNotOpen, bolt.ErrDatabaseReadOnly, bolt.ErrTxClosed, bolt.ErrTxNotWritable,
+	bolt.ErrBucketNotFound, bolt.ErrIncompatibleValue, bolt.ErrKeyTooLarge, bolt.ErrValueTooLarge,
+}
+
+var transactionDuration = metrics.NewHistogram(
+	"enigma_bolt_transaction_duration_seconds",
+	"Duration of Bolt transactions by repository and type, read or write, write ones include waiting for the lock of the writer",
+	[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	"repository", "type",
+)
+
+// View runs fn in a read-only transaction of the repository named name, unless ctx is done
+func View(ctx context.Context, db *bolt.DB, name string, fn func(tx *bolt.Tx) error) error {
+	return run(ctx, db.View, name, "read", fn)
+}
+
+// Update runs fn in a read-write transaction of the repository named name, unless ctx is done
+func Update(ctx context.Context, db *bolt.DB, name string, fn func(tx *bolt.Tx) error) error {
+	return run(ctx, db.Update, name, "write", fn)
+}
+
+// run measures the transaction and marks failures of Bolt and values which can't be decoded as entity.StorageError,
+// other errors of fn, e.g. entity.TransactionNotFoundErr, are returned as they are. Bolt can't interrupt transactions,
+// so a cancelled ctx only keeps new ones from starting
+func run(ctx context.Context, transaction func(func(*bolt.Tx) error) error, name, kind string, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		transactionDuration.Observe(elapsed.Seconds(), name, kind)
+		if elapsed > slowTransaction {
+			logger.FromContext(ctx).Warn("slow bolt transaction", "repository", name, "type", kind, "duration", elapsed)
+		}
+	}()
+
+	var fnErr error
+	err := transaction(func(tx *bolt.Tx) error {
+		fnErr = fn(tx)
+		return fnErr
+	})
+	if err == nil {
+		return nil
+	}
+
+	if err != fnErr || isStorageError(err) {
+		return entity.StorageError{Err: err}
+	}
+	return err
+}
+
+func isStorageError(err error) bool {
+	for _, boltErr := range boltErrors {
+		if errors.Is(err, boltErr) {
+			return true
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// Size is the size of the database file in bytes, reading it checks the database is open
+func Size(db *bolt.DB) (int64, error) {
+	var size int64
+	err := db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,69 @@
+package categorymapping
+
+import (
+	"context"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	categoryMappingsBucketName = []byte("categoryMappings")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(categoryMappingsBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) Save(ctx context.Context, mapping entity.CategoryMapping) error {
+	return repository.Update(ctx, t.db, "categorymapping", func(tx *bolt.Tx) error {
+		raw, err := json.Marshal(mapping)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(categoryMappingsBucketName).Put([]byte(mapping.Category), raw)
+	})
+}
+
+func (t *BoltDBRepository) GetAll(ctx context.Context) ([]entity.CategoryMapping, error) {
+	var mappings []entity.CategoryMapping
+	err := repository.View(ctx, t.db, "categorymapping", func(tx *bolt.Tx) error {
+		return tx.Bucket(categoryMappingsBucketName).ForEach(func(k, v []byte) error {
+			var mapping entity.CategoryMapping
+			err := json.Unmarshal(v, &mapping)
+			if err != nil {
+				return err
+			}
+			mappings = append(mappings, mapping)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
@@ -0,0 +1,70 @@
+package duplicate
+
+import (
+	"context"
+	"encoding/binary"
+
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	dismissedBucketName = []byte("dismissedDuplicates")
+)
+
+// BoltDBRepository keeps pairs of transactions the user has marked as not duplicates
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(dismissedBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Dismiss saves the pair, first must be the lesser ID
+func (t *BoltDBRepository) Dismiss(ctx context.Context, first, second uint64) error {
+	return repository.Update(ctx, t.db, "duplicate", func(tx *bolt.Tx) error {
+		return tx.Bucket(dismissedBucketName).Put(pairKey(first, second), []byte{})
+	})
+}
+
+func (t *BoltDBRepository) GetDismissed(ctx context.Context) ([][2]uint64, error) {
+	var pairs [][2]uint64
+	err := repository.View(ctx, t.db, "duplicate", func(tx *bolt.Tx) error {
+		return tx.Bucket(dismissedBucketName).ForEach(func(k, _ []byte) error {
+			if len(k) != 16 {
+				return nil
+			}
+			pairs = append(pairs, [2]uint64{binary.BigEndian.Uint64(k[:8]), binary.BigEndian.Uint64(k[8:])})
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+func pairKey(first, second uint64) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], first)
+	binary.BigEndian.PutUint64(b[8:], second)
+	return b
+}
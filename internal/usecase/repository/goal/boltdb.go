@@ -0,0 +1,103 @@
+package goal
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	goalsBucketName = []byte("goals")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(goalsBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) Create(ctx context.Context, goal entity.Goal) error {
+	return repository.Update(ctx, t.db, "goal", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(goalsBucketName)
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		goal.ID = id
+
+		raw, err := json.Marshal(goal)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(itob(goal.ID), raw)
+	})
+}
+
+func (t *BoltDBRepository) Update(ctx context.Context, goal entity.Goal) error {
+	return repository.Update(ctx, t.db, "goal", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(goalsBucketName)
+
+		key := itob(goal.ID)
+		if bucket.Get(key) == nil {
+			return entity.GoalNotFoundErr
+		}
+
+		raw, err := json.Marshal(goal)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key, raw)
+	})
+}
+
+func (t *BoltDBRepository) GetAll(ctx context.Context) ([]entity.Goal, error) {
+	var goals []entity.Goal
+	err := repository.View(ctx, t.db, "goal", func(tx *bolt.Tx) error {
+		return tx.Bucket(goalsBucketName).ForEach(func(k, v []byte) error {
+			var goal entity.Goal
+			err := json.Unmarshal(v, &goal)
+			if err != nil {
+				return err
+			}
+			goals = append(goals, goal)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return goals, nil
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
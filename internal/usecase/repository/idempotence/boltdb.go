@@ -1,6 +1,10 @@
 package idempotence
 
 import (
+	"context"
+
+	"enigma/internal/usecase/repository"
+
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -13,14 +17,7 @@ type BoltDBRepository struct {
 }
 
 func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
-	err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(idempotenceBucketName)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
-
+	err := db.Update(Migrate)
 	if err != nil {
 		return nil, err
 	}
@@ -28,8 +25,17 @@ func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
 	return &BoltDBRepository{db: db}, nil
 }
 
-func (t *BoltDBRepository) MakeRecord(id string) (ok bool, err error) {
-	err = t.db.Update(func(tx *bolt.Tx) error {
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(idempotenceBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) MakeRecord(ctx context.Context, id string) (ok bool, err error) {
+	err = repository.Update(ctx, t.db, "idempotence", func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(idempotenceBucketName)
 		v := bucket.Get([]byte(id))
 		if v != nil {
@@ -47,3 +53,22 @@ func (t *BoltDBRepository) MakeRecord(id string) (ok bool, err error) {
 	})
 	return
 }
+
+// MakeRecords is MakeRecord for many ids within tx, so records are saved along with what they guard
+func MakeRecords(tx *bolt.Tx, ids []string) ([]bool, error) {
+	ok := make([]bool, len(ids))
+	bucket := tx.Bucket(idempotenceBucketName)
+	for i, id := range ids {
+		if bucket.Get([]byte(id)) != nil {
+			continue
+		}
+
+		err := bucket.Put([]byte(id), []byte{})
+		if err != nil {
+			return nil, err
+		}
+
+		ok[i] = true
+	}
+	return ok, nil
+}
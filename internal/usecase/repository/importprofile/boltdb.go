@@ -0,0 +1,86 @@
+package importprofile
+
+import (
+	"context"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	importProfilesBucketName = []byte("importProfiles")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(importProfilesBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) Save(ctx context.Context, profile entity.ImportProfile) error {
+	return repository.Update(ctx, t.db, "importprofile", func(tx *bolt.Tx) error {
+		raw, err := json.Marshal(profile)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(importProfilesBucketName).Put([]byte(profile.Name), raw)
+	})
+}
+
+func (t *BoltDBRepository) Get(ctx context.Context, name string) (entity.ImportProfile, error) {
+	var profile entity.ImportProfile
+	err := repository.View(ctx, t.db, "importprofile", func(tx *bolt.Tx) error {
+		raw := tx.Bucket(importProfilesBucketName).Get([]byte(name))
+		if raw == nil {
+			return entity.ImportProfileNotFoundErr
+		}
+		return json.Unmarshal(raw, &profile)
+	})
+
+	if err != nil {
+		return entity.ImportProfile{}, err
+	}
+
+	return profile, nil
+}
+
+func (t *BoltDBRepository) GetAll(ctx context.Context) ([]entity.ImportProfile, error) {
+	var profiles []entity.ImportProfile
+	err := repository.View(ctx, t.db, "importprofile", func(tx *bolt.Tx) error {
+		return tx.Bucket(importProfilesBucketName).ForEach(func(k, v []byte) error {
+			var profile entity.ImportProfile
+			err := json.Unmarshal(v, &profile)
+			if err != nil {
+				return err
+			}
+			profiles = append(profiles, profile)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
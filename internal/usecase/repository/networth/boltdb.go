@@ -0,0 +1,139 @@
+package networth
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	netWorthBucketName  = []byte("netWorth")
+	snapshotsBucketName = []byte("snapshots")
+
+	lastTransactionIDKey = []byte("lastTransactionID")
+	versionKey           = []byte("version")
+)
+
+// snapshotsVersion changes with the format of snapshots, snapshots of another version are calculated again,
+// version 2 keeps balances in other currencies apart
+const snapshotsVersion = 2
+
+// BoltDBRepository caches monthly balance snapshots keyed by "2006-01"
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup,
+// and drops snapshots of another version
+func Migrate(tx *bolt.Tx) error {
+	bucket, err := tx.CreateBucketIfNotExists(netWorthBucketName)
+	if err != nil {
+		return err
+	}
+
+	if raw := bucket.Get(versionKey); raw == nil || binary.BigEndian.Uint64(raw) != snapshotsVersion {
+		if bucket.Bucket(snapshotsBucketName) != nil {
+			err = bucket.DeleteBucket(snapshotsBucketName)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = bucket.Delete(lastTransactionIDKey)
+		if err != nil {
+			return err
+		}
+
+		version := make([]byte, 8)
+		binary.BigEndian.PutUint64(version, snapshotsVersion)
+		err = bucket.Put(versionKey, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = bucket.CreateBucketIfNotExists(snapshotsBucketName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSnapshots returns cached snapshots ordered by month
+func (t *BoltDBRepository) GetSnapshots(ctx context.Context) ([]entity.BalanceSnapshot, uint64, error) {
+	var snapshots []entity.BalanceSnapshot
+	var lastTransactionID uint64
+
+	err := repository.View(ctx, t.db, "networth", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(netWorthBucketName)
+
+		if raw := bucket.Get(lastTransactionIDKey); raw != nil {
+			lastTransactionID = binary.BigEndian.Uint64(raw)
+		}
+
+		return bucket.Bucket(snapshotsBucketName).ForEach(func(k, v []byte) error {
+			var snapshot entity.BalanceSnapshot
+			err := json.Unmarshal(v, &snapshot)
+			if err != nil {
+				return err
+			}
+			snapshots = append(snapshots, snapshot)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return snapshots, lastTransactionID, nil
+}
+
+// ReplaceSnapshots drops every cached snapshot and saves the given ones,
+// lastTransactionID is the newest transaction they account for
+func (t *BoltDBRepository) ReplaceSnapshots(ctx context.Context, snapshots []entity.BalanceSnapshot, lastTransactionID uint64) error {
+	return repository.Update(ctx, t.db, "networth", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(netWorthBucketName)
+
+		err := bucket.DeleteBucket(snapshotsBucketName)
+		if err != nil {
+			return err
+		}
+
+		snapshotsBucket, err := bucket.CreateBucket(snapshotsBucketName)
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range snapshots {
+			raw, err := json.Marshal(snapshot)
+			if err != nil {
+				return err
+			}
+
+			err = snapshotsBucket.Put([]byte(snapshot.Month.Format("2006-01")), raw)
+			if err != nil {
+				return err
+			}
+		}
+
+		id := make([]byte, 8)
+		binary.BigEndian.PutUint64(id, lastTransactionID)
+		return bucket.Put(lastTransactionIDKey, id)
+	})
+}
@@ -0,0 +1,79 @@
+package notificationtemplate
+
+import (
+	"context"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	notificationTemplatesBucketName = []byte("notificationTemplates")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(notificationTemplatesBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) Save(ctx context.Context, template entity.NotificationTemplate) error {
+	return repository.Update(ctx, t.db, "notificationtemplate", func(tx *bolt.Tx) error {
+		raw, err := json.Marshal(template)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(notificationTemplatesBucketName).Put([]byte(template.Name), raw)
+	})
+}
+
+func (t *BoltDBRepository) Delete(ctx context.Context, name string) error {
+	return repository.Update(ctx, t.db, "notificationtemplate", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(notificationTemplatesBucketName)
+		if bucket.Get([]byte(name)) == nil {
+			return entity.NotificationTemplateNotFoundErr
+		}
+		return bucket.Delete([]byte(name))
+	})
+}
+
+func (t *BoltDBRepository) GetAll(ctx context.Context) ([]entity.NotificationTemplate, error) {
+	var templates []entity.NotificationTemplate
+	err := repository.View(ctx, t.db, "notificationtemplate", func(tx *bolt.Tx) error {
+		return tx.Bucket(notificationTemplatesBucketName).ForEach(func(k, v []byte) error {
+			var template entity.NotificationTemplate
+			err := json.Unmarshal(v, &template)
+			if err != nil {
+				return err
+			}
+			templates = append(templates, template)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	outboxBucketName = []byte("outbox")
+	// byIDBucketName maps delivery IDs to their keys in the outbox
+	byIDBucketName = []byte("byDeliveryID")
+)
+
+// BoltDBRepository keeps deliveries of events in the order they were enqueued until webhooks accept them
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	bucket, err := tx.CreateBucketIfNotExists(outboxBucketName)
+	if err != nil {
+		return err
+	}
+
+	_, err = bucket.CreateBucketIfNotExists(byIDBucketName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Enqueue saves deliveries in tx, repositories call it so deliveries are saved along with the change of their event
+func Enqueue(tx *bolt.Tx, deliveries []entity.Delivery) error {
+	bucket := tx.Bucket(outboxBucketName)
+	byIDBucket := bucket.Bucket(byIDBucketName)
+
+	for _, delivery := range deliveries {
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		raw, err := json.Marshal(delivery)
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(itob(seq), raw)
+		if err != nil {
+			return err
+		}
+
+		err = byIDBucket.Put([]byte(delivery.ID), itob(seq))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDue returns at most limit deliveries which should be attempted at now, oldest first
+func (t *BoltDBRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]entity.Delivery, error) {
+	var deliveries []entity.Delivery
+	err := repository.View(ctx, t.db, "outbox", func(tx *bolt.Tx) error {
+		c := tx.Bucket(outboxBucketName).Cursor()
+		for k, v := c.First(); k != nil && len(deliveries) < limit; k, v = c.Next() {
+			// nested buckets have nil values
+			if v == nil {
+				continue
+			}
+
+			var delivery entity.Delivery
+			err := json.Unmarshal(v, &delivery)
+			if err != nil {
+				return err
+			}
+
+			if !delivery.NextAttempt.After(now) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Update saves the state of a delivery after an attempt, deliveries removed meanwhile are not restored
+func (t *BoltDBRepository) Update(ctx context.Context, delivery entity.Delivery) error {
+	return repository.Update(ctx, t.db, "outbox", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBucketName)
+
+		key := bucket.Bucket(byIDBucketName).Get([]byte(delivery.ID))
+		if key == nil {
+			return nil
+		}
+
+		raw, err := json.Marshal(delivery)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key, raw)
+	})
+}
+
+func (t *BoltDBRepository) Delete(ctx context.Context, id string) error {
+	return repository.Update(ctx, t.db, "outbox", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(outboxBucketName)
+		byIDBucket := bucket.Bucket(byIDBucketName)
+
+		key := byIDBucket.Get([]byte(id))
+		if key == nil {
+			return nil
+		}
+
+		err := bucket.Delete(key)
+		if err != nil {
+			return err
+		}
+
+		return byIDBucket.Delete([]byte(id))
+	})
+}
+
+// Count returns the number of deliveries waiting in the outbox
+func (t *BoltDBRepository) Count(ctx context.Context) (int, error) {
+	count := 0
+	err := repository.View(ctx, t.db, "outbox", func(tx *bolt.Tx) error {
+		count = tx.Bucket(outboxBucketName).Bucket(byIDBucketName).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -1,22 +1,29 @@
 package transaction
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
-	"errors"
+	"sort"
 	"time"
 
 	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+	"enigma/internal/usecase/repository/idempotence"
+	"enigma/internal/usecase/repository/outbox"
 
 	bolt "go.etcd.io/bbolt"
 )
 
-var NotFoundErr = errors.New("transaction not found")
-
 var (
 	transactionsBucketName = []byte("transactions")
 	byIDBucketName         = []byte("byID")
 	byDateBucketName       = []byte("byDate")
+	byTokenBucketName      = []byte("byToken")
+	byTagBucketName        = []byte("byTag")
+	byAccountBucketName    = []byte("byAccount")
+	byReceiptBucketName    = []byte("byReceipt")
 )
 
 type BoltDBRepository struct {
@@ -24,22 +31,129 @@ type BoltDBRepository struct {
 }
 
 func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
-	err := db.Update(func(tx *bolt.Tx) error {
-		tBucket, err := tx.CreateBucketIfNotExists(transactionsBucketName)
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup,
+// indexes added after transactions were created are built from them
+func Migrate(tx *bolt.Tx) error {
+	tBucket, err := tx.CreateBucketIfNotExists(transactionsBucketName)
+	if err != nil {
+		return err
+	}
+
+	_, err = tBucket.CreateBucketIfNotExists(byIDBucketName)
+	if err != nil {
+		return err
+	}
+
+	_, err = tBucket.CreateBucketIfNotExists(byDateBucketName)
+	if err != nil {
+		return err
+	}
+
+	if tBucket.Bucket(byTokenBucketName) == nil {
+		_, err = tBucket.CreateBucket(byTokenBucketName)
+		if err != nil {
+			return err
+		}
+
+		err = reindexTokens(tBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tBucket.Bucket(byAccountBucketName) == nil {
+		_, err = tBucket.CreateBucket(byAccountBucketName)
+		if err != nil {
+			return err
+		}
+
+		err = reindexAccounts(tBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tBucket.Bucket(byTagBucketName) == nil {
+		_, err = tBucket.CreateBucket(byTagBucketName)
+		if err != nil {
+			return err
+		}
+
+		err = reindexTags(tBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tBucket.Bucket(byReceiptBucketName) == nil {
+		_, err = tBucket.CreateBucket(byReceiptBucketName)
 		if err != nil {
 			return err
 		}
 
-		_, err = tBucket.CreateBucketIfNotExists(byIDBucketName)
+		err = reindexReceipts(tBucket)
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
 
-		_, err = tBucket.CreateBucketIfNotExists(byDateBucketName)
+// Create saves transaction and deliveries of its event, it returns the transaction with the assigned ID
+func (t *BoltDBRepository) Create(ctx context.Context, transaction entity.Transaction, deliver entity.Deliver) (entity.Transaction, error) {
+	err := repository.Update(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		var err error
+		transaction, err = create(tx, transaction)
 		if err != nil {
 			return err
 		}
+		return enqueue(tx, deliver, entity.TransactionCreatedEvent, transaction, nil)
+	})
+
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// CreateOnce saves transactions whose keys, in the same order, are recorded for the first time, along with the keys
+// and deliveries of their events. It returns the saved transactions with the assigned IDs
+func (t *BoltDBRepository) CreateOnce(ctx context.Context, transactions []entity.Transaction, keys []string, deliver entity.Deliver) ([]entity.Transaction, error) {
+	var created []entity.Transaction
+	err := repository.Update(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		created = make([]entity.Transaction, 0, len(transactions))
 
+		first, err := idempotence.MakeRecords(tx, keys)
+		if err != nil {
+			return err
+		}
+
+		for i, transaction := range transactions {
+			if !first[i] {
+				continue
+			}
+
+			transaction, err := create(tx, transaction)
+			if err != nil {
+				return err
+			}
+			created = append(created, transaction)
+
+			err = enqueue(tx, deliver, entity.TransactionCreatedEvent, transaction, nil)
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 
@@ -47,54 +161,227 @@ func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
 		return nil, err
 	}
 
-	return &BoltDBRepository{db: db}, nil
+	return created, nil
 }
 
-func (t *BoltDBRepository) Create(transaction entity.Transaction) error {
-	return t.db.Update(func(tx *bolt.Tx) error {
-		tBucket := tx.Bucket(transactionsBucketName)
-		byIDBucket := tBucket.Bucket(byIDBucketName)
-		byDateBucket := tBucket.Bucket(byDateBucketName)
-
-		id, err := byIDBucket.NextSequence()
+// Update replaces the transaction with the ID of transaction, saves deliveries of its event and returns the previous version
+func (t *BoltDBRepository) Update(ctx context.Context, transaction entity.Transaction, deliver entity.Deliver) (entity.Transaction, error) {
+	var previous entity.Transaction
+	err := repository.Update(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		var err error
+		previous, err = update(tx, transaction)
 		if err != nil {
 			return err
 		}
+		return enqueue(tx, deliver, entity.TransactionUpdatedEvent, transaction, &previous)
+	})
 
-		transaction.ID = id
+	if err != nil {
+		return entity.Transaction{}, err
+	}
 
-		raw, err := json.Marshal(transaction)
+	return previous, nil
+}
+
+// Split replaces the transaction with the ID of first by first and creates the rest in a single database transaction,
+// it returns the parts with the assigned IDs
+func (t *BoltDBRepository) Split(ctx context.Context, first entity.Transaction, rest []entity.Transaction, deliver entity.Deliver) ([]entity.Transaction, error) {
+	var saved []entity.Transaction
+	err := repository.Update(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		saved = make([]entity.Transaction, 0, 1+len(rest))
+
+		previous, err := update(tx, first)
+		if err != nil {
+			return err
+		}
+		saved = append(saved, first)
+
+		err = enqueue(tx, deliver, entity.TransactionUpdatedEvent, first, &previous)
 		if err != nil {
 			return err
 		}
 
-		key := itob(transaction.ID)
+		for _, part := range rest {
+			part, err := create(tx, part)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, part)
+
+			err = enqueue(tx, deliver, entity.TransactionCreatedEvent, part, nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// update replaces the transaction with the ID of transaction within tx and returns the previous version
+func update(tx *bolt.Tx, transaction entity.Transaction) (entity.Transaction, error) {
+	tBucket := tx.Bucket(transactionsBucketName)
+	byIDBucket := tBucket.Bucket(byIDBucketName)
+	byDateBucket := tBucket.Bucket(byDateBucketName)
+
+	key := itob(transaction.ID)
+	raw := byIDBucket.Get(key)
+	if raw == nil {
+		return entity.Transaction{}, entity.TransactionNotFoundErr
+	}
+
+	var previous entity.Transaction
+	err := json.Unmarshal(raw, &previous)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	if transaction.Receipt != nil && (previous.Receipt == nil || previous.Receipt.Key() != transaction.Receipt.Key()) &&
+		tBucket.Bucket(byReceiptBucketName).Get([]byte(transaction.Receipt.Key())) != nil {
+		return entity.Transaction{}, entity.ReceiptExistsErr
+	}
+
+	err = removeFromIndex(byDateBucket, []byte(previous.Date.Format("2006-01-02")), key)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = unindex(tBucket, previous)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	raw, err = json.Marshal(transaction)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = byIDBucket.Put(key, raw)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	bucket, err := byDateBucket.CreateBucketIfNotExists([]byte(transaction.Date.Format("2006-01-02")))
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = bucket.Put(key, raw)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = index(tBucket, transaction)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	return previous, nil
+}
+
+func create(tx *bolt.Tx, transaction entity.Transaction) (entity.Transaction, error) {
+	tBucket := tx.Bucket(transactionsBucketName)
+	byIDBucket := tBucket.Bucket(byIDBucketName)
+	byDateBucket := tBucket.Bucket(byDateBucketName)
+
+	// receipts are checked within the same database transaction, so one can't be entered twice
+	if transaction.Receipt != nil && tBucket.Bucket(byReceiptBucketName).Get([]byte(transaction.Receipt.Key())) != nil {
+		return entity.Transaction{}, entity.ReceiptExistsErr
+	}
+
+	id, err := byIDBucket.NextSequence()
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	transaction.ID = id
+
+	raw, err := json.Marshal(transaction)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	key := itob(transaction.ID)
+
+	err = byIDBucket.Put(key, raw)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	bucket, err := byDateBucket.CreateBucketIfNotExists([]byte(transaction.Date.Format("2006-01-02")))
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = bucket.Put(key, raw)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = index(tBucket, transaction)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// Delete removes the transaction with id and every index entry of it and saves deliveries of its event
+func (t *BoltDBRepository) Delete(ctx context.Context, id uint64, deliver entity.Deliver) error {
+	return repository.Update(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		tBucket := tx.Bucket(transactionsBucketName)
+		byIDBucket := tBucket.Bucket(byIDBucketName)
+
+		key := itob(id)
+		raw := byIDBucket.Get(key)
+		if raw == nil {
+			return entity.TransactionNotFoundErr
+		}
+
+		var transaction entity.Transaction
+		err := json.Unmarshal(raw, &transaction)
+		if err != nil {
+			return err
+		}
 
-		err = byIDBucket.Put(key, raw)
+		err = byIDBucket.Delete(key)
 		if err != nil {
 			return err
 		}
 
-		bucket, err := byDateBucket.CreateBucketIfNotExists([]byte(transaction.Date.Format("2006-01-02")))
+		err = removeFromIndex(tBucket.Bucket(byDateBucketName), []byte(transaction.Date.Format("2006-01-02")), key)
 		if err != nil {
 			return err
 		}
 
-		err = bucket.Put(key, raw)
+		err = unindex(tBucket, transaction)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return enqueue(tx, deliver, entity.TransactionDeletedEvent, transaction, nil)
 	})
 }
 
-func (t *BoltDBRepository) GetByID(id uint64) (entity.Transaction, error) {
+// enqueue puts deliveries of the event of a change into the outbox, deliver is nil if no webhook is subscribed
+func enqueue(tx *bolt.Tx, deliver entity.Deliver, eventType entity.EventType, transaction entity.Transaction, previous *entity.Transaction) error {
+	if deliver == nil {
+		return nil
+	}
+	return outbox.Enqueue(tx, deliver(eventType, transaction, previous))
+}
+
+func (t *BoltDBRepository) GetByID(ctx context.Context, id uint64) (entity.Transaction, error) {
 	var transaction entity.Transaction
-	err := t.db.View(func(tx *bolt.Tx) error {
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
 		raw := tx.Bucket(transactionsBucketName).Bucket(byIDBucketName).Get(itob(id))
 		if raw == nil {
-			return NotFoundErr
+			return entity.TransactionNotFoundErr
 		}
 
 		err := json.Unmarshal(raw, &transaction)
@@ -112,9 +399,9 @@ func (t *BoltDBRepository) GetByID(id uint64) (entity.Transaction, error) {
 	return transaction, nil
 }
 
-func (t *BoltDBRepository) GetByDate(date time.Time) ([]entity.Transaction, error) {
+func (t *BoltDBRepository) GetByDate(ctx context.Context, date time.Time) ([]entity.Transaction, error) {
 	var transactions []entity.Transaction
-	err := t.db.View(func(tx *bolt.Tx) error {
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
 		dateKey := []byte(date.Format("2006-01-02"))
 		bucket := tx.Bucket(transactionsBucketName).Bucket(byDateBucketName).Bucket(dateKey)
 		if bucket == nil {
@@ -145,6 +432,231 @@ func (t *BoltDBRepository) GetByDate(date time.Time) ([]entity.Transaction, erro
 	return transactions, nil
 }
 
+// GetByDateRange returns transactions dated from since inclusive to until exclusive, oldest first
+func (t *BoltDBRepository) GetByDateRange(ctx context.Context, since, until time.Time) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := t.ForEachInDateRange(ctx, since, until, func(transaction entity.Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// ForEachInDateRange calls fn for every transaction dated from since inclusive to until exclusive, oldest first,
+// transactions are read one by one within a single read transaction, so fn sees a consistent snapshot
+func (t *BoltDBRepository) ForEachInDateRange(ctx context.Context, since, until time.Time, fn func(entity.Transaction) error) error {
+	return repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		byDateBucket := tx.Bucket(transactionsBucketName).Bucket(byDateBucketName)
+
+		sinceKey := []byte(since.Format("2006-01-02"))
+		untilKey := []byte(until.Format("2006-01-02"))
+
+		c := byDateBucket.Cursor()
+		for k, _ := c.Seek(sinceKey); k != nil && bytes.Compare(k, untilKey) < 0; k, _ = c.Next() {
+			// ctx is checked by the day, ranges of exports may span years
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			err := byDateBucket.Bucket(k).ForEach(func(_, v []byte) error {
+				var transaction entity.Transaction
+				err := json.Unmarshal(v, &transaction)
+				if err != nil {
+					return err
+				}
+				return fn(transaction)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetByReceipt returns the transaction entered from the receipt with key, nil if there is none
+func (t *BoltDBRepository) GetByReceipt(ctx context.Context, key string) (*entity.Transaction, error) {
+	var transaction *entity.Transaction
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		tBucket := tx.Bucket(transactionsBucketName)
+
+		id := tBucket.Bucket(byReceiptBucketName).Get([]byte(key))
+		if id == nil {
+			return nil
+		}
+
+		raw := tBucket.Bucket(byIDBucketName).Get(id)
+		if raw == nil {
+			return nil
+		}
+
+		transaction = &entity.Transaction{}
+		return json.Unmarshal(raw, transaction)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// GetCreatedAfter returns transactions with ID greater than id
+func (t *BoltDBRepository) GetCreatedAfter(ctx context.Context, id uint64) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		c := tx.Bucket(transactionsBucketName).Bucket(byIDBucketName).Cursor()
+		for k, v := c.Seek(itob(id + 1)); k != nil; k, v = c.Next() {
+			var transaction entity.Transaction
+			err := json.Unmarshal(v, &transaction)
+			if err != nil {
+				return err
+			}
+			transactions = append(transactions, transaction)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// GetAccountNames returns names of every account used in transactions
+func (t *BoltDBRepository) GetAccountNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		return tx.Bucket(transactionsBucketName).Bucket(byAccountBucketName).ForEach(func(k, _ []byte) error {
+			names = append(names, string(k))
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// Search returns transactions matching filter, newest first
+func (t *BoltDBRepository) Search(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		tBucket := tx.Bucket(transactionsBucketName)
+		byIDBucket := tBucket.Bucket(byIDBucketName)
+
+		appendIfMatch := func(raw []byte) error {
+			var transaction entity.Transaction
+			err := json.Unmarshal(raw, &transaction)
+			if err != nil {
+				return err
+			}
+			if filter.Match(transaction) {
+				transactions = append(transactions, transaction)
+			}
+			return nil
+		}
+
+		var keys [][]byte
+		switch {
+		case len(filter.Terms) > 0:
+			keys = searchTokens(tBucket.Bucket(byTokenBucketName), filter.Terms)
+		case len(filter.Tags) > 0:
+			keys = searchTag(tBucket.Bucket(byTagBucketName), filter.Tags[0])
+		default:
+			return byIDBucket.ForEach(func(k, v []byte) error {
+				return appendIfMatch(v)
+			})
+		}
+
+		for _, key := range keys {
+			raw := byIDBucket.Get(key)
+			if raw == nil {
+				continue
+			}
+
+			err := appendIfMatch(raw)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].Date.Equal(transactions[j].Date) {
+			return transactions[i].ID > transactions[j].ID
+		}
+		return transactions[i].Date.After(transactions[j].Date)
+	})
+
+	return transactions, nil
+}
+
+// GetTags returns every tag with the number and total amount of its transactions by currency
+func (t *BoltDBRepository) GetTags(ctx context.Context) ([]entity.TagSummary, error) {
+	var tags []entity.TagSummary
+	err := repository.View(ctx, t.db, "transaction", func(tx *bolt.Tx) error {
+		tBucket := tx.Bucket(transactionsBucketName)
+		byIDBucket := tBucket.Bucket(byIDBucketName)
+		byTagBucket := tBucket.Bucket(byTagBucketName)
+
+		return byTagBucket.ForEach(func(tag, _ []byte) error {
+			byCurrency := make(map[string]*entity.TagSummary)
+
+			err := byTagBucket.Bucket(tag).ForEach(func(k, _ []byte) error {
+				raw := byIDBucket.Get(k)
+				if raw == nil {
+					return nil
+				}
+
+				var transaction entity.Transaction
+				err := json.Unmarshal(raw, &transaction)
+				if err != nil {
+					return err
+				}
+
+				summary, ok := byCurrency[transaction.CurrencyCode()]
+				if !ok {
+					summary = &entity.TagSummary{Tag: string(tag), Currency: transaction.CurrencyCode()}
+					byCurrency[summary.Currency] = summary
+				}
+				summary.Count++
+				summary.Total += transaction.Amount
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, summary := range byCurrency {
+				tags = append(tags, *summary)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
 func itob(v uint64) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, v)
@@ -0,0 +1,556 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository/idempotence"
+	"enigma/internal/usecase/repository/outbox"
+	"enigma/internal/usecase/repository/transaction"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type repositories struct {
+	db           *bolt.DB
+	transactions *transaction.BoltDBRepository
+	outbox       *outbox.BoltDBRepository
+}
+
+func newRepositories(t *testing.T) repositories {
+	t.Helper()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "enigma.db"), 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = idempotence.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outboxRepository, err := outbox.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionRepository, err := transaction.NewBoltDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repositories{db: db, transactions: transactionRepository, outbox: outboxRepository}
+}
+
+// search returns descriptions of transactions found by query, newest first
+func search(t *testing.T, r repositories, query string) []string {
+	t.Helper()
+
+	filter, err := entity.ParseTransactionFilter(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := r.transactions.Search(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions := make([]string, 0, len(found))
+	for _, transaction := range found {
+		descriptions = append(descriptions, transaction.Description)
+	}
+	return descriptions
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	for _, transaction := range []entity.Transaction{
+		{Date: day("2022-05-01"), FromAccount: "card", ToAccount: "pharmacy", Amount: 1200, Description: "Аптека Ёлка vitamins"},
+		{Date: day("2022-05-14"), FromAccount: "cash", ToAccount: "cafe", Amount: 300, Description: "coffee receipt 2022"},
+		{Date: day("2023-01-10"), FromAccount: "card", ToAccount: "cafe", Amount: 450, Description: "coffee and cake"},
+	} {
+		_, err := r.transactions.Create(ctx, transaction, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"coffee", []string{"coffee and cake", "coffee receipt 2022"}},
+		// terms match prefixes of words, case and "ё" are folded
+		{"COF", []string{"coffee and cake", "coffee receipt 2022"}},
+		{"елка", []string{"Аптека Ёлка vitamins"}},
+		{"coffee cake", []string{"coffee and cake"}},
+		// account names are indexed along with descriptions
+		{"pharmacy", []string{"Аптека Ёлка vitamins"}},
+		{"coffee from:cash", []string{"coffee receipt 2022"}},
+		{">400", []string{"coffee and cake", "Аптека Ёлка vitamins"}},
+		// a bare year is a term, years are filtered with a prefix
+		{"2022", []string{"coffee receipt 2022"}},
+		{"period:2022", []string{"coffee receipt 2022", "Аптека Ёлка vitamins"}},
+		{"coffee 2023-01", []string{"coffee and cake"}},
+		{"tea", []string{}},
+	}
+	for _, tt := range tests {
+		got := search(t, r, tt.query)
+		if !equal(got, tt.want) {
+			t.Errorf("%q found %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestReindexTokens(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	_, err := r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "books", Amount: 20, Description: "novel"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a database of an older version has no index, it is built when the repository is opened
+	err = r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("transactions")).DeleteBucket([]byte("byToken"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := transaction.NewBoltDB(r.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.transactions = reopened
+
+	if got := search(t, r, "novel"); !equal(got, []string{"novel"}) {
+		t.Errorf("found %q after reindexing, want the transaction", got)
+	}
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	for _, transaction := range []entity.Transaction{
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "shop", Amount: 100, Description: "bread #food"},
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "cafe", Amount: 250, Description: "lunch #food #work"},
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "taxi", Amount: 400, Description: "ride #work"},
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "shop", Amount: 50, Description: "gum"},
+	} {
+		transaction.Tags = entity.ExtractTags(transaction.Description)
+		_, err := r.transactions.Create(ctx, transaction, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tags, err := r.transactions.GetTags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []entity.TagSummary{{Tag: "food", Count: 2, Total: 350}, {Tag: "work", Count: 2, Total: 650}}
+	if len(tags) != len(want) {
+		t.Fatalf("tags %+v, want %+v", tags, want)
+	}
+	for i := range want {
+		if tags[i].Tag != want[i].Tag || tags[i].Count != want[i].Count || tags[i].Total != want[i].Total {
+			t.Errorf("tag %+v, want %+v", tags[i], want[i])
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"#food", []string{"lunch #food #work", "bread #food"}},
+		{"#FOOD #work", []string{"lunch #food #work"}},
+		{"#work taxi", []string{"ride #work"}},
+		{"#foo", []string{}},
+	}
+	for _, tt := range tests {
+		got := search(t, r, tt.query)
+		if !equal(got, tt.want) {
+			t.Errorf("%q found %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCreateOnceSkipsRecordedKeys(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	statement := []entity.Transaction{
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "rent", Amount: 2, Description: "rent"},
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "shop", Amount: 3, Description: "groceries"},
+		{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "shop", Amount: 3, Description: "groceries"},
+	}
+	// the last row repeats the second one, e.g. in overlapping statements
+	keys := []string{"import-rent", "import-groceries", "import-groceries"}
+
+	created, err := r.transactions.CreateOnce(ctx, statement, keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 || created[0].ID == 0 || created[1].ID == created[0].ID {
+		t.Fatalf("created %v, want the first two rows with assigned IDs", created)
+	}
+
+	created, err = r.transactions.CreateOnce(ctx, statement, keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 0 {
+		t.Errorf("%d transactions are created by importing the statement again, want 0", len(created))
+	}
+
+	if got := search(t, r, "groceries"); len(got) != 1 {
+		t.Errorf("%d transactions of the repeated row are saved, want 1", len(got))
+	}
+}
+
+func TestReceiptIsEnteredOnce(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	receipt := &entity.Receipt{FN: "1", FD: "2", FP: "3"}
+	_, err := r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "shop", Amount: 1, Description: "first", Receipt: receipt}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "shop", Amount: 1, Description: "second", Receipt: receipt}, nil)
+	if !errors.Is(err, entity.ReceiptExistsErr) {
+		t.Fatalf("err %v, want %v", err, entity.ReceiptExistsErr)
+	}
+
+	found, err := r.transactions.GetByReceipt(ctx, receipt.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || found.Description != "first" {
+		t.Errorf("found %v by the receipt, want the first transaction", found)
+	}
+
+	found, err = r.transactions.GetByReceipt(ctx, "4:5:6")
+	if err != nil || found != nil {
+		t.Errorf("found %v, %v by a receipt which is not entered", found, err)
+	}
+}
+
+func TestCreateOnceRecordsKeysOnlyWithTransactions(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	receipt := &entity.Receipt{FN: "1", FD: "2", FP: "3"}
+	_, err := r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), Amount: 1, Description: "receipt", Receipt: receipt}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := []entity.Transaction{
+		{Date: time.Now().UTC(), Amount: 2, Description: "rent"},
+		{Date: time.Now().UTC(), Amount: 3, Description: "groceries", Receipt: receipt},
+	}
+	keys := []string{"import-rent", "import-groceries"}
+
+	_, err = r.transactions.CreateOnce(ctx, statement, keys, nil)
+	if !errors.Is(err, entity.ReceiptExistsErr) {
+		t.Fatalf("err %v, want %v", err, entity.ReceiptExistsErr)
+	}
+	if got := search(t, r, "rent"); len(got) != 0 {
+		t.Errorf("transaction of the failed import is saved")
+	}
+
+	// the failed import recorded no keys, so fixing the statement and importing it again creates both transactions
+	statement[1].Receipt = nil
+	created, err := r.transactions.CreateOnce(ctx, statement, keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("%d transactions are created after the failed import, want 2", len(created))
+	}
+}
+
+func TestDeleteRemovesIndexEntries(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	receipt := &entity.Receipt{FN: "1", FD: "2", FP: "3"}
+	_, err := r.transactions.Create(ctx, entity.Transaction{
+		Date: time.Now().UTC(), FromAccount: "card", ToAccount: "pharmacy", Amount: 5,
+		Description: "vitamins #health", Tags: []string{"health"}, Receipt: receipt,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "cafe", Amount: 3, Description: "coffee"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.transactions.Delete(ctx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"vitamins", "#health", "pharmacy"} {
+		if got := search(t, r, query); len(got) != 0 {
+			t.Errorf("%q found %q after delete", query, got)
+		}
+	}
+	if got := search(t, r, ""); !equal(got, []string{"coffee"}) {
+		t.Errorf("found %q after delete, want the other transaction", got)
+	}
+
+	tags, err := r.transactions.GetTags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Errorf("tags %+v after delete", tags)
+	}
+
+	// the receipt can be entered again
+	found, err := r.transactions.GetByReceipt(ctx, receipt.Key())
+	if err != nil || found != nil {
+		t.Errorf("found %v, %v by the receipt after delete", found, err)
+	}
+	_, err = r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "pharmacy", Amount: 5, Receipt: receipt}, nil)
+	if err != nil {
+		t.Errorf("receipt is not entered again after delete: %v", err)
+	}
+
+	err = r.transactions.Delete(ctx, 1, nil)
+	if !errors.Is(err, entity.TransactionNotFoundErr) {
+		t.Errorf("err %v deleting again, want %v", err, entity.TransactionNotFoundErr)
+	}
+}
+
+func TestUpdateReindexesTransaction(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	created, err := r.transactions.Create(ctx, entity.Transaction{
+		Date: time.Now().UTC(), FromAccount: "card", ToAccount: "pharmacy", Amount: 5,
+		Description: "vitamins #health", Tags: []string{"health"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := created
+	updated.ToAccount = "shop"
+	updated.Description = "groceries #food"
+	updated.Tags = []string{"food"}
+	previous, err := r.transactions.Update(ctx, updated, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous.Description != "vitamins #health" {
+		t.Errorf("previous version %+v, want the created transaction", previous)
+	}
+
+	for _, query := range []string{"vitamins", "#health", "pharmacy"} {
+		if got := search(t, r, query); len(got) != 0 {
+			t.Errorf("%q found %q after update", query, got)
+		}
+	}
+	for _, query := range []string{"groceries", "#food", "shop"} {
+		if got := search(t, r, query); !equal(got, []string{"groceries #food"}) {
+			t.Errorf("%q found %q after update, want the updated transaction", query, got)
+		}
+	}
+
+	tags, err := r.transactions.GetTags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0].Tag != "food" || tags[0].Count != 1 {
+		t.Errorf("tags %+v after update, want only food", tags)
+	}
+
+	updated.ID = 42
+	_, err = r.transactions.Update(ctx, updated, nil)
+	if !errors.Is(err, entity.TransactionNotFoundErr) {
+		t.Errorf("err %v updating a missing transaction, want %v", err, entity.TransactionNotFoundErr)
+	}
+}
+
+// deliver makes a delivery of every event to a single webhook
+func deliver(eventType entity.EventType, t entity.Transaction, previous *entity.Transaction) []entity.Delivery {
+	return []entity.Delivery{{
+		ID:    string(eventType) + "-" + t.Description,
+		Event: entity.Event{Type: eventType, Transaction: t, Previous: previous},
+	}}
+}
+
+func dueDeliveries(t *testing.T, r repositories) []entity.Delivery {
+	t.Helper()
+
+	deliveries, err := r.outbox.GetDue(context.Background(), time.Now(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deliveries
+}
+
+func TestChangesEnqueueTheirEvents(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	created, err := r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "cafe", Amount: 5, Description: "coffee"}, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := created
+	updated.Amount = 6
+	_, err = r.transactions.Update(ctx, updated, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.transactions.Delete(ctx, created.ID, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries := dueDeliveries(t, r)
+	want := []entity.EventType{entity.TransactionCreatedEvent, entity.TransactionUpdatedEvent, entity.TransactionDeletedEvent}
+	if len(deliveries) != len(want) {
+		t.Fatalf("%d deliveries in the outbox, want %d", len(deliveries), len(want))
+	}
+	for i, d := range deliveries {
+		if d.Event.Type != want[i] || d.Event.Transaction.ID != created.ID {
+			t.Errorf("delivery %d is of %s of transaction %d, want %s of %d", i, d.Event.Type, d.Event.Transaction.ID, want[i], created.ID)
+		}
+	}
+	if previous := deliveries[1].Event.Previous; previous == nil || previous.Amount != 5 {
+		t.Errorf("update event has previous %v, want the transaction before the update", previous)
+	}
+}
+
+func TestFailedChangeEnqueuesNothing(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	receipt := &entity.Receipt{FN: "1", FD: "2", FP: "3"}
+	_, err := r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), Amount: 1, Description: "first", Receipt: receipt}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second transaction of the batch is rejected, so neither it nor the first one nor their events are saved
+	_, err = r.transactions.CreateOnce(ctx, []entity.Transaction{
+		{Date: time.Now().UTC(), Amount: 2, Description: "second"},
+		{Date: time.Now().UTC(), Amount: 3, Description: "third", Receipt: receipt},
+	}, []string{"import-second", "import-third"}, deliver)
+	if !errors.Is(err, entity.ReceiptExistsErr) {
+		t.Fatalf("err %v, want %v", err, entity.ReceiptExistsErr)
+	}
+
+	err = r.transactions.Delete(ctx, 42, deliver)
+	if !errors.Is(err, entity.TransactionNotFoundErr) {
+		t.Fatalf("err %v, want %v", err, entity.TransactionNotFoundErr)
+	}
+
+	if deliveries := dueDeliveries(t, r); len(deliveries) != 0 {
+		t.Errorf("%d deliveries of failed changes are in the outbox", len(deliveries))
+	}
+	found, err := r.transactions.Search(ctx, entity.TransactionFilter{Terms: []string{"second"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 0 {
+		t.Errorf("transaction of the failed batch is saved")
+	}
+}
+
+func TestSplitSavesEveryPartOrNone(t *testing.T) {
+	ctx := context.Background()
+	r := newRepositories(t)
+
+	receipt := &entity.Receipt{FN: "1", FD: "2", FP: "3"}
+	purchase, err := r.transactions.Create(ctx, entity.Transaction{Date: time.Now().UTC(), FromAccount: "card", ToAccount: "groceries", Amount: 100, Description: "market", Receipt: receipt}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := purchase
+	first.Amount = 60
+	parts := []entity.Transaction{
+		{Date: purchase.Date, FromAccount: "card", ToAccount: "household", Amount: 30, Description: "soap"},
+		// the last part can't be saved, its receipt is the one of the purchase
+		{Date: purchase.Date, FromAccount: "card", ToAccount: "books", Amount: 10, Description: "magazine", Receipt: receipt},
+	}
+
+	_, err = r.transactions.Split(ctx, first, parts, deliver)
+	if !errors.Is(err, entity.ReceiptExistsErr) {
+		t.Fatalf("err %v, want %v", err, entity.ReceiptExistsErr)
+	}
+
+	stored, err := r.transactions.GetByID(ctx, purchase.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Amount != 100 {
+		t.Errorf("amount of the purchase is %v after the failed split, want 100", stored.Amount)
+	}
+	if deliveries := dueDeliveries(t, r); len(deliveries) != 0 {
+		t.Errorf("%d deliveries of the failed split are in the outbox", len(deliveries))
+	}
+
+	parts[1].Receipt = nil
+	saved, err := r.transactions.Split(ctx, first, parts, deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 3 || saved[0].ID != purchase.ID {
+		t.Fatalf("split saved %v, want the purchase and two new parts", saved)
+	}
+
+	total := 0.0
+	all, err := r.transactions.GetByDate(ctx, purchase.Date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transaction := range all {
+		total += transaction.Amount
+	}
+	if total != 100 {
+		t.Errorf("total of the parts is %v, want 100", total)
+	}
+	if deliveries := dueDeliveries(t, r); len(deliveries) != 3 {
+		t.Errorf("%d deliveries of the split, want an update and two creations", len(deliveries))
+	}
+}
@@ -0,0 +1,335 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"enigma/internal/entity"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// indexTokens adds transaction to the inverted index over description and account names
+func indexTokens(byTokenBucket *bolt.Bucket, transaction entity.Transaction) error {
+	key := itob(transaction.ID)
+
+	text := transaction.Description + " " + transaction.FromAccount + " " + transaction.ToAccount
+	for _, token := range tokenize(text) {
+		bucket, err := byTokenBucket.CreateBucketIfNotExists([]byte(token))
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(key, []byte{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func reindexTokens(tBucket *bolt.Bucket) error {
+	byTokenBucket := tBucket.Bucket(byTokenBucketName)
+
+	return tBucket.Bucket(byIDBucketName).ForEach(func(k, v []byte) error {
+		var transaction entity.Transaction
+		err := json.Unmarshal(v, &transaction)
+		if err != nil {
+			return err
+		}
+		return indexTokens(byTokenBucket, transaction)
+	})
+}
+
+// searchTokens returns keys of transactions containing every term,
+// a term matches any indexed token it is a prefix of
+func searchTokens(byTokenBucket *bolt.Bucket, terms []string) [][]byte {
+	var result map[string]struct{}
+
+	for _, term := range terms {
+		for _, token := range tokenize(term) {
+			matched := make(map[string]struct{})
+
+			prefix := []byte(token)
+			c := byTokenBucket.Cursor()
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				bucket := byTokenBucket.Bucket(k)
+				if bucket == nil {
+					continue
+				}
+				_ = bucket.ForEach(func(id, _ []byte) error {
+					if result == nil {
+						matched[string(id)] = struct{}{}
+					} else if _, ok := result[string(id)]; ok {
+						matched[string(id)] = struct{}{}
+					}
+					return nil
+				})
+			}
+
+			result = matched
+			if len(result) == 0 {
+				return nil
+			}
+		}
+	}
+
+	keys := make([][]byte, 0, len(result))
+	for id := range result {
+		keys = append(keys, []byte(id))
+	}
+	return keys
+}
+
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		token := foldToken(word)
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
+
+// foldToken lowercases the token and treats "ё" as "е", as people rarely type it consistently
+func foldToken(word string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if r == 'ё' {
+			return 'е'
+		}
+		return r
+	}, word)
+}
+
+func indexTags(byTagBucket *bolt.Bucket, transaction entity.Transaction) error {
+	key := itob(transaction.ID)
+
+	for _, tag := range transaction.Tags {
+		bucket, err := byTagBucket.CreateBucketIfNotExists([]byte(tag))
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(key, []byte{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reindexTags extracts tags of transactions saved before tags existed and rewrites them
+func reindexTags(tBucket *bolt.Bucket) error {
+	byIDBucket := tBucket.Bucket(byIDBucketName)
+	byDateBucket := tBucket.Bucket(byDateBucketName)
+	byTagBucket := tBucket.Bucket(byTagBucketName)
+
+	var transactions []entity.Transaction
+	err := byIDBucket.ForEach(func(k, v []byte) error {
+		var transaction entity.Transaction
+		err := json.Unmarshal(v, &transaction)
+		if err != nil {
+			return err
+		}
+		if len(transaction.Tags) == 0 {
+			transaction.Tags = entity.ExtractTags(transaction.Description)
+		}
+		if len(transaction.Tags) > 0 {
+			transactions = append(transactions, transaction)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		raw, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+
+		key := itob(transaction.ID)
+
+		err = byIDBucket.Put(key, raw)
+		if err != nil {
+			return err
+		}
+
+		dateBucket := byDateBucket.Bucket([]byte(transaction.Date.Format("2006-01-02")))
+		if dateBucket != nil {
+			err = dateBucket.Put(key, raw)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = indexTags(byTagBucket, transaction)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func searchTag(byTagBucket *bolt.Bucket, tag string) [][]byte {
+	bucket := byTagBucket.Bucket([]byte(entity.NormalizeTag(tag)))
+	if bucket == nil {
+		return nil
+	}
+
+	var keys [][]byte
+	_ = bucket.ForEach(func(k, _ []byte) error {
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+	return keys
+}
+
+func indexAccounts(byAccountBucket *bolt.Bucket, transaction entity.Transaction) error {
+	key := itob(transaction.ID)
+
+	for _, account := range []string{transaction.FromAccount, transaction.ToAccount} {
+		if account == "" {
+			continue
+		}
+
+		bucket, err := byAccountBucket.CreateBucketIfNotExists([]byte(account))
+		if err != nil {
+			return err
+		}
+
+		err = bucket.Put(key, []byte{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func reindexAccounts(tBucket *bolt.Bucket) error {
+	byAccountBucket := tBucket.Bucket(byAccountBucketName)
+
+	return tBucket.Bucket(byIDBucketName).ForEach(func(k, v []byte) error {
+		var transaction entity.Transaction
+		err := json.Unmarshal(v, &transaction)
+		if err != nil {
+			return err
+		}
+		return indexAccounts(byAccountBucket, transaction)
+	})
+}
+
+// indexReceipt maps the receipt key of transaction to its ID
+func indexReceipt(byReceiptBucket *bolt.Bucket, transaction entity.Transaction) error {
+	if transaction.Receipt == nil {
+		return nil
+	}
+	return byReceiptBucket.Put([]byte(transaction.Receipt.Key()), itob(transaction.ID))
+}
+
+func reindexReceipts(tBucket *bolt.Bucket) error {
+	byReceiptBucket := tBucket.Bucket(byReceiptBucketName)
+
+	return tBucket.Bucket(byIDBucketName).ForEach(func(k, v []byte) error {
+		var transaction entity.Transaction
+		err := json.Unmarshal(v, &transaction)
+		if err != nil {
+			return err
+		}
+		return indexReceipt(byReceiptBucket, transaction)
+	})
+}
+
+// removeFromIndex deletes key from the subbucket name of index, subbuckets left empty are deleted
+func removeFromIndex(index *bolt.Bucket, name, key []byte) error {
+	bucket := index.Bucket(name)
+	if bucket == nil {
+		return nil
+	}
+
+	err := bucket.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	if k, _ := bucket.Cursor().First(); k == nil {
+		return index.DeleteBucket(name)
+	}
+	return nil
+}
+
+// index adds transaction to every index
+func index(tBucket *bolt.Bucket, transaction entity.Transaction) error {
+	err := indexTokens(tBucket.Bucket(byTokenBucketName), transaction)
+	if err != nil {
+		return err
+	}
+
+	err = indexTags(tBucket.Bucket(byTagBucketName), transaction)
+	if err != nil {
+		return err
+	}
+
+	err = indexAccounts(tBucket.Bucket(byAccountBucketName), transaction)
+	if err != nil {
+		return err
+	}
+
+	return indexReceipt(tBucket.Bucket(byReceiptBucketName), transaction)
+}
+
+// unindex removes transaction from every index
+func unindex(tBucket *bolt.Bucket, transaction entity.Transaction) error {
+	key := itob(transaction.ID)
+
+	text := transaction.Description + " " + transaction.FromAccount + " " + transaction.ToAccount
+	for _, token := range tokenize(text) {
+		err := removeFromIndex(tBucket.Bucket(byTokenBucketName), []byte(token), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range transaction.Tags {
+		err := removeFromIndex(tBucket.Bucket(byTagBucketName), []byte(tag), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, account := range []string{transaction.FromAccount, transaction.ToAccount} {
+		if account == "" {
+			continue
+		}
+		err := removeFromIndex(tBucket.Bucket(byAccountBucketName), []byte(account), key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if transaction.Receipt != nil {
+		err := tBucket.Bucket(byReceiptBucketName).Delete([]byte(transaction.Receipt.Key()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
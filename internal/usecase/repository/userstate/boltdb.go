@@ -1,10 +1,12 @@
 package userstate
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/json"
 
 	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
 
 	bolt "go.etcd.io/bbolt"
 )
@@ -18,14 +20,7 @@ type BoltDBRepository struct {
 }
 
 func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
-	err := db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(stateBucketName)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
-
+	err := db.Update(Migrate)
 	if err != nil {
 		return nil, err
 	}
@@ -33,8 +28,17 @@ func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
 	return &BoltDBRepository{db: db}, nil
 }
 
-func (t *BoltDBRepository) Save(userID int64, state entity.UserState) error {
-	return t.db.Update(func(tx *bolt.Tx) error {
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(stateBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *BoltDBRepository) Save(ctx context.Context, userID int64, state entity.UserState) error {
+	return repository.Update(ctx, t.db, "userstate", func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(stateBucketName)
 
 		raw, err := json.Marshal(state)
@@ -53,10 +57,10 @@ func (t *BoltDBRepository) Save(userID int64, state entity.UserState) error {
 	})
 }
 
-func (t *BoltDBRepository) Get(userID int64) (entity.UserState, error) {
+func (t *BoltDBRepository) Get(ctx context.Context, userID int64) (entity.UserState, error) {
 	var state entity.UserState
 
-	err := t.db.View(func(tx *bolt.Tx) error {
+	err := repository.View(ctx, t.db, "userstate", func(tx *bolt.Tx) error {
 		raw := tx.Bucket(stateBucketName).Get(itob(userID))
 		if raw == nil {
 			return entity.UserStateNotFoundErr
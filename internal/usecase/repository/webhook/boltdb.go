@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	"enigma/internal/entity"
+	"enigma/internal/usecase/repository"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	webhooksBucketName = []byte("webhooks")
+)
+
+type BoltDBRepository struct {
+	db *bolt.DB
+}
+
+func NewBoltDB(db *bolt.DB) (*BoltDBRepository, error) {
+	err := db.Update(Migrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDBRepository{db: db}, nil
+}
+
+// Migrate creates the buckets of the repository which are missing, e.g. in a database restored from an older backup
+func Migrate(tx *bolt.Tx) error {
+	_, err := tx.CreateBucketIfNotExists(webhooksBucketName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create saves webhook and returns it with the assigned ID
+func (t *BoltDBRepository) Create(ctx context.Context, webhook entity.Webhook) (entity.Webhook, error) {
+	err := repository.Update(ctx, t.db, "webhook", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(webhooksBucketName)
+
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		webhook.ID = id
+
+		raw, err := json.Marshal(webhook)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(itob(webhook.ID), raw)
+	})
+
+	if err != nil {
+		return entity.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (t *BoltDBRepository) Delete(ctx context.Context, id uint64) error {
+	return repository.Update(ctx, t.db, "webhook", func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(webhooksBucketName)
+		if bucket.Get(itob(id)) == nil {
+			return entity.WebhookNotFoundErr
+		}
+		return bucket.Delete(itob(id))
+	})
+}
+
+func (t *BoltDBRepository) GetAll(ctx context.Context) ([]entity.Webhook, error) {
+	var webhooks []entity.Webhook
+	err := repository.View(ctx, t.db, "webhook", func(tx *bolt.Tx) error {
+		return tx.Bucket(webhooksBucketName).ForEach(func(k, v []byte) error {
+			var webhook entity.Webhook
+			err := json.Unmarshal(v, &webhook)
+			if err != nil {
+				return err
+			}
+			webhooks = append(webhooks, webhook)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
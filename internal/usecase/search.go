@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"enigma/internal/entity"
+)
+
+type SearchTransactions struct {
+	repo transactionRepository
+}
+
+func NewSearchTransactions(repo transactionRepository) *SearchTransactions {
+	return &SearchTransactions{
+		repo: repo,
+	}
+}
+
+// Execute returns a page of transactions matching filter and the total number of matches
+func (s *SearchTransactions) Execute(ctx context.Context, filter entity.TransactionFilter, offset, limit int) ([]entity.Transaction, int, error) {
+	if filter.IsEmpty() {
+		return nil, 0, errors.New("search query is empty")
+	}
+
+	transactions, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(transactions)
+	if offset >= total {
+		return nil, total, nil
+	}
+
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return transactions[offset:end], total, nil
+}
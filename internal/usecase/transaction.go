@@ -1,23 +1,172 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"sort"
 	"time"
 
 	"enigma/internal/entity"
 )
 
 type CreateTransaction struct {
-	repo transactionRepository
+	repo          transactionRepository
+	publishEvents *PublishEvents
 }
 
-func NewCreateTransaction(repo transactionRepository) *CreateTransaction {
+func NewCreateTransaction(repo transactionRepository, publishEvents *PublishEvents) *CreateTransaction {
 	return &CreateTransaction{
-		repo: repo,
+		repo:          repo,
+		publishEvents: publishEvents,
+	}
+}
+
+// Execute saves t and returns it with the assigned ID
+func (c *CreateTransaction) Execute(ctx context.Context, t entity.Transaction) (entity.Transaction, error) {
+	deliver, err := c.publishEvents.Execute(ctx)
+	if err != nil {
+		return t, err
 	}
+
+	t.Tags = entity.ExtractTags(t.Description)
+	return c.repo.Create(ctx, t, deliver)
 }
 
-func (c *CreateTransaction) Execute(t entity.Transaction) error {
-	return c.repo.Create(t)
+type UpdateTransaction struct {
+	repo          transactionRepository
+	netWorthRepo  netWorthRepository
+	publishEvents *PublishEvents
+}
+
+func NewUpdateTransaction(repo transactionRepository, netWorthRepo netWorthRepository, publishEvents *PublishEvents) *UpdateTransaction {
+	return &UpdateTransaction{
+		repo:          repo,
+		netWorthRepo:  netWorthRepo,
+		publishEvents: publishEvents,
+	}
+}
+
+// Execute replaces the transaction with the ID of t, the receipt of the transaction is kept
+func (u *UpdateTransaction) Execute(ctx context.Context, t entity.Transaction) (entity.Transaction, error) {
+	previous, err := u.repo.GetByID(ctx, t.ID)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	t.Receipt = previous.Receipt
+	t.Tags = entity.ExtractTags(t.Description)
+
+	deliver, err := u.publishEvents.Execute(ctx)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	_, err = u.repo.Update(ctx, t, deliver)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	err = invalidateSnapshots(ctx, u.netWorthRepo)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	return t, nil
+}
+
+type SplitTransaction struct {
+	repo          transactionRepository
+	netWorthRepo  netWorthRepository
+	publishEvents *PublishEvents
+}
+
+func NewSplitTransaction(repo transactionRepository, netWorthRepo netWorthRepository, publishEvents *PublishEvents) *SplitTransaction {
+	return &SplitTransaction{
+		repo:          repo,
+		netWorthRepo:  netWorthRepo,
+		publishEvents: publishEvents,
+	}
+}
+
+// Execute replaces the transaction with the ID of the first part by parts, e.g. one purchase by its categories,
+// the first part keeps the ID and the receipt, the others are created. Either every part is saved or none
+func (s *SplitTransaction) Execute(ctx context.Context, parts []entity.Transaction) ([]entity.Transaction, error) {
+	if len(parts) == 0 {
+		return nil, errors.New("at least one part is required")
+	}
+
+	previous, err := s.repo.GetByID(ctx, parts[0].ID)
+	if err != nil {
+		return nil, err
+	}
+
+	first := parts[0]
+	first.Receipt = previous.Receipt
+	first.Tags = entity.ExtractTags(first.Description)
+
+	rest := make([]entity.Transaction, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		part.ID = 0
+		part.Receipt = nil
+		part.Tags = entity.ExtractTags(part.Description)
+		rest = append(rest, part)
+	}
+
+	deliver, err := s.publishEvents.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.Split(ctx, first, rest, deliver)
+	if err != nil {
+		return nil, err
+	}
+
+	err = invalidateSnapshots(ctx, s.netWorthRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+type DeleteTransaction struct {
+	repo          transactionRepository
+	netWorthRepo  netWorthRepository
+	publishEvents *PublishEvents
+}
+
+func NewDeleteTransaction(repo transactionRepository, netWorthRepo netWorthRepository, publishEvents *PublishEvents) *DeleteTransaction {
+	return &DeleteTransaction{
+		repo:          repo,
+		netWorthRepo:  netWorthRepo,
+		publishEvents: publishEvents,
+	}
+}
+
+func (d *DeleteTransaction) Execute(ctx context.Context, id uint64) error {
+	deliver, err := d.publishEvents.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = d.repo.Delete(ctx, id, deliver)
+	if err != nil {
+		return err
+	}
+
+	err = invalidateSnapshots(ctx, d.netWorthRepo)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// invalidateSnapshots drops cached net worth snapshots after a transaction is changed or deleted,
+// they only follow created transactions
+func invalidateSnapshots(ctx context.Context, repo netWorthRepository) error {
+	return repo.ReplaceSnapshots(ctx, nil, 0)
 }
 
 type GetTransactionByID struct {
@@ -30,8 +179,8 @@ func NewGetTransactionByID(repo transactionRepository) *GetTransactionByID {
 	}
 }
 
-func (g *GetTransactionByID) Execute(id uint64) (entity.Transaction, error) {
-	return g.repo.GetByID(id)
+func (g *GetTransactionByID) Execute(ctx context.Context, id uint64) (entity.Transaction, error) {
+	return g.repo.GetByID(ctx, id)
 }
 
 type GetTransactionsByDate struct {
@@ -44,6 +193,57 @@ func NewGetTransactionsByDate(repo transactionRepository) *GetTransactionsByDate
 	}
 }
 
-func (g *GetTransactionsByDate) Execute(date time.Time) ([]entity.Transaction, error) {
-	return g.repo.GetByDate(date)
+// Execute returns transactions for date having every one of tags
+func (g *GetTransactionsByDate) Execute(ctx context.Context, date time.Time, tags []string) ([]entity.Transaction, error) {
+	transactions, err := g.repo.GetByDate(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tags) == 0 {
+		return transactions, nil
+	}
+
+	filter := entity.TransactionFilter{Tags: tags}
+	filtered := make([]entity.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		if filter.Match(t) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered, nil
+}
+
+type GetTags struct {
+	repo transactionRepository
+}
+
+func NewGetTags(repo transactionRepository) *GetTags {
+	return &GetTags{
+		repo: repo,
+	}
+}
+
+// Execute sorts summaries by total, the default currency goes first
+func (g *GetTags) Execute(ctx context.Context) ([]entity.TagSummary, error) {
+	tags, err := g.repo.GetTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Currency != tags[j].Currency {
+			if tags[i].Currency == entity.DefaultCurrency || tags[j].Currency == entity.DefaultCurrency {
+				return tags[i].Currency == entity.DefaultCurrency
+			}
+			return tags[i].Currency < tags[j].Currency
+		}
+		if tags[i].Total == tags[j].Total {
+			return tags[i].Tag < tags[j].Tag
+		}
+		return tags[i].Total > tags[j].Total
+	})
+
+	return tags, nil
 }
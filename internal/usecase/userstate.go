@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 
 	"enigma/internal/entity"
@@ -16,8 +17,8 @@ func NewGetUserstate(repo userstateRepository) *GetUserstate {
 	}
 }
 
-func (u *GetUserstate) Execute(id int64) (entity.UserState, error) {
-	state, err := u.repo.Get(id)
+func (u *GetUserstate) Execute(ctx context.Context, id int64) (entity.UserState, error) {
+	state, err := u.repo.Get(ctx, id)
 	if err != nil {
 		if errors.Is(err, entity.UserStateNotFoundErr) {
 			return entity.UserState{}, nil
@@ -37,6 +38,6 @@ func NewSaveUserstate(repo userstateRepository) *SaveUserstate {
 	}
 }
 
-func (u *SaveUserstate) Execute(id int64, state entity.UserState) error {
-	return u.repo.Save(id, state)
+func (u *SaveUserstate) Execute(ctx context.Context, id int64, state entity.UserState) error {
+	return u.repo.Save(ctx, id, state)
 }
@@ -0,0 +1,136 @@
+// Package webhook posts ledger events to webhooks and retries them from the outbox
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"enigma/internal/entity"
+	"enigma/internal/logger"
+	"enigma/internal/usecase"
+)
+
+const (
+	EventHeader     = "X-Enigma-Event"
+	DeliveryHeader  = "X-Enigma-Delivery"
+	SignatureHeader = "X-Enigma-Signature-256"
+
+	// pollInterval is how often the outbox is checked for due deliveries
+	pollInterval = time.Second
+)
+
+// Sender posts events as JSON signed with the secret of the webhook as "sha256=" and hex of HMAC-SHA256
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender() *Sender {
+	return &Sender{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts the event of delivery to webhook, any 2xx response means the event is delivered
+func (s *Sender) Send(ctx context.Context, webhook entity.Webhook, delivery entity.Delivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "enigma-webhook")
+	request.Header.Set(EventHeader, string(delivery.Event.Type))
+	request.Header.Set(DeliveryHeader, delivery.ID)
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// the connection is reused only if the body is read to the end
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", response.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher delivers the outbox in the background, deliveries left by a previous run are sent after a restart
+type Dispatcher struct {
+	deliverWebhooksUsecase *usecase.DeliverWebhooks
+
+	// stopping is closed by Shutdown, stopped once the batch being delivered is done
+	stopping chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func NewDispatcher(deliverWebhooksUsecase *usecase.DeliverWebhooks) *Dispatcher {
+	return &Dispatcher{
+		deliverWebhooksUsecase: deliverWebhooksUsecase,
+
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start delivers due deliveries every poll interval until Shutdown, deliveries in flight are cancelled when ctx is
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		defer close(d.stopped)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-d.stopping:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				_, err := d.deliverWebhooksUsecase.Execute(ctx, now)
+				if err != nil && ctx.Err() == nil {
+					logger.Default().Error("deliver webhooks", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// Shutdown waits for the batch being delivered, what is left stays in the outbox for the next run
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.stopOnce.Do(func() {
+		close(d.stopping)
+	})
+
+	select {
+	case <-d.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
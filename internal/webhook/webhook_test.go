@@ -0,0 +1,21 @@
+package webhook
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret string
+		body   string
+		want   string
+	}{
+		// the example of GitHub webhook documentation, receivers check signatures the same way
+		{"It's a Secret to Everybody", "Hello, World!", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"},
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+	}
+
+	for _, test := range tests {
+		if got := Sign(test.secret, []byte(test.body)); got != test.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", test.secret, test.body, got, test.want)
+		}
+	}
+}